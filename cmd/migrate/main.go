@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/preetsinghmakkar/OpenCall/configs"
+	"github.com/preetsinghmakkar/OpenCall/internal/database"
+	"github.com/preetsinghmakkar/OpenCall/internal/database/migrations"
+	"github.com/rs/zerolog/log"
+)
+
+const usage = `usage: migrate <command> [args]
+
+commands:
+  up            apply all pending migrations
+  down [n]      roll back the last n migrations (default 1)
+  status        list migrations and when they were applied
+  create NAME   add an empty up/down pair to internal/database/migrations
+`
+
+const migrationsDir = "internal/database/migrations"
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	command := os.Args[1]
+
+	// create only touches the source tree, no database needed
+	if command == "create" {
+		if len(os.Args) < 3 {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+
+		up, down, err := migrations.Create(migrationsDir, os.Args[2])
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create migration")
+		}
+
+		fmt.Println("created", up)
+		fmt.Println("created", down)
+		return
+	}
+
+	dbConfig := configs.NewDatabaseConfig().SQLConfig()
+
+	// Migrations run on the primary with a small pool and no statement
+	// timeout, since schema changes can legitimately take a while.
+	dbConfig.ReplicaURL = ""
+	dbConfig.AutoMigrate = false
+	dbConfig.StatementTimeout = 0
+	dbConfig.MaxOpenConns = 2
+	dbConfig.MaxIdleConns = 2
+
+	client, err := database.NewSQLClient(dbConfig)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize database")
+	}
+	defer client.Close()
+
+	migrator, err := migrations.NewMigrator(client.DB)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load migrations")
+	}
+
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		ran, err := migrator.Up(ctx)
+		report("applied", ran)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Migration failed")
+		}
+
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			steps, err = strconv.Atoi(os.Args[2])
+			if err != nil || steps < 1 {
+				log.Fatal().Msg("down expects a positive number of steps")
+			}
+		}
+
+		ran, err := migrator.Down(ctx, steps)
+		report("rolled back", ran)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Rollback failed")
+		}
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to read migration status")
+		}
+
+		for _, st := range statuses {
+			applied := "pending"
+			if st.AppliedAt != nil {
+				applied = st.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d  %-40s %s\n", st.Version, st.Name, applied)
+		}
+
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func report(verb string, ran []migrations.Migration) {
+	if len(ran) == 0 {
+		fmt.Println("nothing to do")
+		return
+	}
+
+	for _, mig := range ran {
+		fmt.Printf("%s %04d_%s\n", verb, mig.Version, mig.Name)
+	}
+}
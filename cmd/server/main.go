@@ -2,12 +2,13 @@ package main
 
 import (
 	"os"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/preetsinghmakkar/OpenCall/configs"
+	"github.com/preetsinghmakkar/OpenCall/internal/constants"
 	"github.com/preetsinghmakkar/OpenCall/internal/database"
 	"github.com/preetsinghmakkar/OpenCall/internal/handlers"
+	"github.com/preetsinghmakkar/OpenCall/internal/jobs"
 	"github.com/preetsinghmakkar/OpenCall/internal/repositories"
 	"github.com/preetsinghmakkar/OpenCall/internal/routes"
 	serve "github.com/preetsinghmakkar/OpenCall/internal/server"
@@ -18,18 +19,7 @@ import (
 func main() {
 	config := configs.NewConfig()
 
-	client, err := database.NewSQLClient(database.Config{
-		Driver:            config.Database.DatabaseDriver,
-		Host:              config.Database.DatabaseHost,
-		Port:              config.Database.DatabasePort,
-		User:              config.Database.DatabaseUser,
-		Password:          config.Database.DatabasePassword,
-		DBName:            config.Database.DatabaseName,
-		MaxOpenConns:      25,
-		MaxIdleConns:      25,
-		ConnMaxIdleTime:   15 * time.Minute,
-		ConnectionTimeout: 5 * time.Second,
-	})
+	client, err := database.NewSQLClient(config.Database.SQLConfig())
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to initialize database")
 	}
@@ -41,17 +31,51 @@ func main() {
 	router.GET("/health", handlers.NewHealthHandler(client.DB))
 
 	// repositories
-	userRepo := repositories.NewUserRepository(client.DB)
+	// public browsing queries go to the read replica when one is configured
+	userRepo := repositories.NewUserRepository(client.DB).WithReader(client.Reader())
 	refreshTokenRepo := repositories.NewRefreshTokenRepository(client.DB)
-	mentorRepo := repositories.NewMentorRepository(client.DB)
-	mentorServiceRepo := repositories.NewMentorServiceRepository(client.DB)
-	mentorAvailabilityRepo := repositories.NewMentorAvailabilityRepository(client.DB)
-	bookingRepo := repositories.NewBookingRepository(client.DB)
+	mentorRepo := repositories.NewMentorRepository(client.DB).WithReader(client.Reader())
+	mentorServiceRepo := repositories.NewMentorServiceRepository(client.DB).WithReader(client.Reader())
+	mentorAvailabilityRepo := repositories.NewMentorAvailabilityRepository(client.DB).WithReader(client.Reader())
+	availabilityOverrideRepo := repositories.NewMentorAvailabilityOverrideRepository(client.DB)
+	bookingRepo := repositories.NewBookingRepository(client.DB).WithReader(client.Reader())
 	paymentRepo := repositories.NewPaymentRepository(client.DB)
-	razorpayClient := services.NewRazorpayClient(
-		config.Razorpay.KeyID,
-		config.Razorpay.KeySecret,
+	refundRepo := repositories.NewRefundRepository(client.DB)
+	servicePackageRepo := repositories.NewMentorServicePackageRepository(client.DB).WithReader(client.Reader())
+	packagePurchaseRepo := repositories.NewPackagePurchaseRepository(client.DB)
+	sessionEventRepo := repositories.NewSessionEventRepository(client.DB)
+	videoSessionRepo := repositories.NewVideoSessionRepository(client.DB)
+	ledgerRepo := repositories.NewLedgerRepository(client.DB)
+	payoutRepo := repositories.NewPayoutRepository(client.DB)
+	webhookEventRepo := repositories.NewWebhookEventRepository(client.DB)
+	paymentMismatchRepo := repositories.NewPaymentMismatchRepository(client.DB)
+	couponRepo := repositories.NewCouponRepository(client.DB)
+	invoiceRepo := repositories.NewInvoiceRepository(client.DB)
+
+	// each booking is paid through the gateway registered for its currency
+	paymentGateways := services.NewPaymentGateways()
+	paymentGateways.Register(
+		services.NewRazorpayGateway(
+			config.Razorpay.KeyID,
+			config.Razorpay.KeySecret,
+			config.Razorpay.WebhookSecrets,
+		),
+		config.Razorpay.Currencies...,
 	)
+	if config.Stripe.SecretKey != "" {
+		paymentGateways.Register(
+			services.NewStripeGateway(
+				config.Stripe.SecretKey,
+				config.Stripe.WebhookSecrets,
+			),
+			config.Stripe.Currencies...,
+		)
+	}
+
+	bookingLimits := services.BookingLimits{
+		MinNotice:  config.Booking.MinNotice,
+		MaxHorizon: config.Booking.MaxHorizon,
+	}
 
 	// services
 	userService := services.NewUserService(userRepo)
@@ -67,26 +91,93 @@ func main() {
 	)
 	mentorAvailabilityService := services.NewMentorAvailabilityService(
 		mentorAvailabilityRepo,
+		availabilityOverrideRepo,
 		mentorRepo,
 	)
 	availabilityService := services.NewAvailabilityService(
 		mentorRepo,
 		mentorServiceRepo,
 		mentorAvailabilityRepo,
+		availabilityOverrideRepo,
 		bookingRepo,
+		bookingLimits,
 	)
-	bookingService := services.NewBookingService(
-		bookingRepo,
+	ledgerService := services.NewLedgerService(
+		ledgerRepo,
 		mentorRepo,
-		mentorServiceRepo,
-		mentorAvailabilityRepo,
+		config.Ledger.CommissionPercent,
+		config.Ledger.HoldPeriod,
+	)
+	// payouts stay disabled until a provider is configured
+	var payoutProvider services.PayoutProvider
+	switch config.Payout.Provider {
+	case constants.PayoutProviderRazorpayX:
+		payoutProvider = services.NewRazorpayXPayoutProvider(
+			config.Razorpay.KeyID,
+			config.Razorpay.KeySecret,
+			config.Payout.RazorpayXAccountNumber,
+		)
+	case constants.PayoutProviderFake:
+		payoutProvider = services.NewFakePayoutProvider()
+	}
+	payoutService := services.NewPayoutService(
+		payoutRepo,
+		mentorRepo,
+		ledgerService,
+		payoutProvider,
+		config.Payout.DetailsKey,
+		config.Payout.MinCents,
+	)
+	invoiceService := services.NewInvoiceService(
+		invoiceRepo,
+		mentorRepo,
+		services.InvoiceSupplier{
+			Name:           config.Invoice.SupplierName,
+			Address:        config.Invoice.SupplierAddress,
+			GSTIN:          config.Invoice.GSTIN,
+			SAC:            config.Invoice.SAC,
+			GSTRatePercent: config.Invoice.GSTRatePercent,
+		},
 	)
 	paymentService := services.NewPaymentService(
 		client.DB,
 		paymentRepo,
 		bookingRepo,
-		razorpayClient,
-		config.Razorpay.KeySecret,
+		refundRepo,
+		packagePurchaseRepo,
+		paymentMismatchRepo,
+		ledgerService,
+		invoiceService,
+		paymentGateways,
+	)
+	webhookInbox := services.NewWebhookInboxService(
+		webhookEventRepo,
+		paymentService,
+		config.Webhook.MaxAttempts,
+	)
+	couponService := services.NewCouponService(
+		couponRepo,
+		mentorServiceRepo,
+		mentorRepo,
+	)
+	bookingService := services.NewBookingService(
+		bookingRepo,
+		mentorRepo,
+		mentorServiceRepo,
+		mentorAvailabilityRepo,
+		availabilityOverrideRepo,
+		paymentRepo,
+		packagePurchaseRepo,
+		paymentService,
+		couponService,
+		bookingLimits,
+	)
+	packageService := services.NewPackageService(
+		servicePackageRepo,
+		packagePurchaseRepo,
+		mentorServiceRepo,
+		mentorRepo,
+		paymentService,
 	)
 
 	// services (continued)
@@ -94,6 +185,14 @@ func main() {
 		config.Zego.AppID,
 		config.Zego.ServerSecret,
 	)
+	sessionService := services.NewSessionService(
+		bookingRepo,
+		mentorRepo,
+		userRepo,
+		sessionEventRepo,
+		videoSessionRepo,
+		zegoService,
+	)
 
 	// S3 Service for profile picture uploads
 	s3BucketName := os.Getenv("AWS_S3_BUCKET")
@@ -117,9 +216,15 @@ func main() {
 		mentorAvailabilityService,
 		availabilityService,
 	)
+	packageHandler := handlers.NewPackageHandler(packageService)
 	bookingHandler := handlers.NewBookingHandler(bookingService, mentorRepo)
 	paymentHandler := handlers.NewPaymentHandler(paymentService)
-	zegoHandler := handlers.NewZegoHandler(zegoService)
+	webhookHandler := handlers.NewWebhookHandler(webhookInbox)
+	zegoHandler := handlers.NewZegoHandler(sessionService)
+	earningsHandler := handlers.NewEarningsHandler(ledgerService)
+	payoutHandler := handlers.NewPayoutHandler(payoutService)
+	couponHandler := handlers.NewCouponHandler(couponService)
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceService)
 
 	// routes
 	routes.RegisterPublicEndpoints(
@@ -129,7 +234,8 @@ func main() {
 		mentorHandler,
 		mentorServiceHandler,
 		mentorAvailabilityHandler,
-		paymentHandler,
+		packageHandler,
+		webhookHandler,
 		bookingRepo,
 		userRepo,
 		mentorRepo,
@@ -143,13 +249,90 @@ func main() {
 		mentorHandler,
 		mentorServiceHandler,
 		mentorAvailabilityHandler,
+		packageHandler,
 		bookingHandler,
 		paymentHandler,
 		authHandler,
 		config.JWT.Secret,
 		zegoHandler,
+		earningsHandler,
+		payoutHandler,
+		webhookHandler,
+		couponHandler,
+		invoiceHandler,
+	)
+
+	// background jobs
+	bookingReaper := jobs.NewRunner(
+		log.Logger,
+		"booking-reaper",
+		config.Booking.ReaperInterval,
+		jobs.NewBookingReaper(log.Logger, bookingRepo, config.Booking.HoldWindow, config.Payment.OrderTTL).Run,
+	)
+	bookingReaper.Start()
+
+	sessionSettler := jobs.NewRunner(
+		log.Logger,
+		"session-settler",
+		config.Booking.SettlerInterval,
+		jobs.NewSessionSettler(log.Logger, bookingRepo, config.Booking.SettleDelay).Run,
 	)
+	sessionSettler.Start()
+
+	earningsPoster := jobs.NewRunner(
+		log.Logger,
+		"earnings-poster",
+		config.Booking.SettlerInterval,
+		ledgerService.PostSessionEarnings,
+	)
+	earningsPoster.Start()
+
+	webhookProcessor := jobs.NewRunner(
+		log.Logger,
+		"webhook-processor",
+		config.Webhook.ProcessorInterval,
+		webhookInbox.ProcessDue,
+	)
+	webhookProcessor.Start()
+
+	paymentReconciler := jobs.NewRunner(
+		log.Logger,
+		"payment-reconciler",
+		config.Payment.ReconcilerInterval,
+		jobs.NewPaymentReconciler(log.Logger, paymentRepo, paymentService, config.Payment.ReconcileAfter).Run,
+	)
+	paymentReconciler.Start()
+
+	refundSubmitter := jobs.NewRunner(
+		log.Logger,
+		"refund-submitter",
+		config.Payment.RefundInterval,
+		paymentService.SubmitDueRefunds,
+	)
+	refundSubmitter.Start()
+
+	var payoutJob *jobs.Runner
+	if payoutProvider != nil {
+		payoutJob = jobs.NewRunner(
+			log.Logger,
+			"payout-batches",
+			config.Payout.Interval,
+			payoutService.RunBatch,
+		)
+		payoutJob.Start()
+	} else {
+		log.Warn().Msg("PAYOUT_PROVIDER not set, mentor payouts are disabled")
+	}
 
 	server := serve.NewServer(log.Logger, router, config)
+	server.OnShutdown(bookingReaper.Stop)
+	server.OnShutdown(sessionSettler.Stop)
+	server.OnShutdown(earningsPoster.Stop)
+	server.OnShutdown(webhookProcessor.Stop)
+	server.OnShutdown(paymentReconciler.Stop)
+	server.OnShutdown(refundSubmitter.Stop)
+	if payoutJob != nil {
+		server.OnShutdown(payoutJob.Stop)
+	}
 	server.Serve()
 }
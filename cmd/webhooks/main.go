@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/configs"
+	"github.com/preetsinghmakkar/OpenCall/internal/database"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+	"github.com/preetsinghmakkar/OpenCall/internal/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+const usage = `usage: webhooks <command> [args]
+
+commands:
+  list [-status S] [-gateway G] [-limit N]
+                list stored payment webhooks, newest first
+  show ID       print one webhook with its raw payload
+  replay ID     queue a failed or processed webhook to be processed again;
+                the running server's webhook processor picks it up
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	command := os.Args[1]
+
+	dbConfig := configs.NewDatabaseConfig().SQLConfig()
+	dbConfig.ReplicaURL = ""
+	dbConfig.AutoMigrate = false
+	dbConfig.MaxOpenConns = 2
+	dbConfig.MaxIdleConns = 2
+
+	client, err := database.NewSQLClient(dbConfig)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize database")
+	}
+	defer client.Close()
+
+	repo := repositories.NewWebhookEventRepository(client.DB)
+	ctx := context.Background()
+
+	switch command {
+	case "list":
+		fs := flag.NewFlagSet("list", flag.ExitOnError)
+		status := fs.String("status", "", "only events with this status")
+		gateway := fs.String("gateway", "", "only events from this gateway")
+		limit := fs.Int("limit", 50, "maximum number of events")
+		_ = fs.Parse(os.Args[2:])
+
+		events, err := repo.List(ctx, *status, *gateway, *limit)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to list webhook events")
+		}
+
+		for _, e := range events {
+			fmt.Printf(
+				"%s  %-20s %-9s %-10s %2d  %-28s %s\n",
+				e.ID,
+				e.ReceivedAt.Format(time.RFC3339),
+				e.Gateway,
+				e.Status,
+				e.Attempts,
+				e.EventType,
+				lastError(e),
+			)
+		}
+
+	case "show":
+		e, err := repo.GetByID(ctx, eventID())
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to load webhook event")
+		}
+
+		fmt.Printf("id:              %s\n", e.ID)
+		fmt.Printf("gateway:         %s\n", e.Gateway)
+		fmt.Printf("event id:        %s\n", e.EventID)
+		fmt.Printf("event type:      %s\n", e.EventType)
+		fmt.Printf("signature valid: %t\n", e.SignatureValid)
+		if e.SecretID != nil {
+			fmt.Printf("secret id:       %s\n", *e.SecretID)
+		}
+		fmt.Printf("status:          %s\n", e.Status)
+		fmt.Printf("attempts:        %d\n", e.Attempts)
+		fmt.Printf("received at:     %s\n", e.ReceivedAt.Format(time.RFC3339))
+		if e.NextAttemptAt != nil {
+			fmt.Printf("next attempt at: %s\n", e.NextAttemptAt.Format(time.RFC3339))
+		}
+		if e.ProcessedAt != nil {
+			fmt.Printf("processed at:    %s\n", e.ProcessedAt.Format(time.RFC3339))
+		}
+		fmt.Printf("last error:      %s\n", lastError(e))
+		fmt.Printf("\n%s\n", e.Payload)
+
+	case "replay":
+		e, err := repo.Replay(ctx, eventID())
+		if errors.Is(err, repositories.ErrWebhookEventNotReplayable) {
+			log.Fatal().Msg("Only failed or processed webhook events can be replayed")
+		}
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to replay webhook event")
+		}
+
+		fmt.Println("queued", e.ID)
+
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func eventID() uuid.UUID {
+	if len(os.Args) < 3 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	id, err := uuid.Parse(os.Args[2])
+	if err != nil {
+		log.Fatal().Msg("expected a webhook event id")
+	}
+
+	return id
+}
+
+func lastError(e *models.WebhookEvent) string {
+	if e.LastError == nil {
+		return ""
+	}
+	return *e.LastError
+}
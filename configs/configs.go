@@ -1,36 +1,66 @@
 package configs
 
 import (
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/preetsinghmakkar/OpenCall/internal/constants"
+	"github.com/preetsinghmakkar/OpenCall/internal/database"
 )
 
 type Config struct {
 	Server   serverConfig
-	Database databaseConfig
+	Database DatabaseConfig
 	JWT      jwtConfig
 	Razorpay RazorpayConfig
+	Stripe   StripeConfig
 	Zego     ZegoConfig
+	Booking  BookingConfig
+	Ledger   LedgerConfig
+	Payout   PayoutConfig
+	Webhook  WebhookConfig
+	Payment  PaymentConfig
+	Invoice  InvoiceConfig
 }
 
 type serverConfig struct {
 	Address string
 }
 
-type databaseConfig struct {
+type DatabaseConfig struct {
 	DatabaseDriver   string
+	DatabaseURL      string
 	DatabaseHost     string
 	DatabasePort     int
 	DatabaseUser     string
 	DatabasePassword string
 	DatabaseName     string
+	ReplicaURL       string
+
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	ApplicationName  string
+	StatementTimeout time.Duration
+
+	MaxOpenConns      int
+	MaxIdleConns      int
+	ConnMaxIdleTime   time.Duration
+	ConnMaxLifetime   time.Duration
+	ConnectionTimeout time.Duration
+
+	AutoMigrate bool
 }
 
 type jwtConfig struct {
@@ -38,9 +68,22 @@ type jwtConfig struct {
 }
 
 type RazorpayConfig struct {
-	KeyID         string
-	KeySecret     string
-	WebhookSecret string
+	KeyID     string
+	KeySecret string
+	// WebhookSecrets are accepted for webhooks; list the new secret
+	// alongside the old one while rotating
+	WebhookSecrets []string
+	// Currencies are the booking currencies paid through Razorpay
+	Currencies []string
+}
+
+// StripeConfig is optional; Stripe payments are disabled without a
+// secret key.
+type StripeConfig struct {
+	SecretKey      string
+	WebhookSecrets []string
+	// Currencies are the booking currencies paid through Stripe
+	Currencies []string
 }
 
 type ZegoConfig struct {
@@ -48,52 +91,373 @@ type ZegoConfig struct {
 	ServerSecret string
 }
 
+type BookingConfig struct {
+	// HoldWindow is how long an unpaid pending booking blocks its slot
+	HoldWindow time.Duration
+	// ReaperInterval is how often expired holds are swept
+	ReaperInterval time.Duration
+	// MinNotice is how long before its start a session can still be booked
+	MinNotice time.Duration
+	// MaxHorizon is how far ahead sessions can be booked
+	MaxHorizon time.Duration
+	// SettleDelay is how long after its end a session's attendance is final
+	SettleDelay time.Duration
+	// SettlerInterval is how often ended sessions are settled
+	SettlerInterval time.Duration
+}
+
+type LedgerConfig struct {
+	// CommissionPercent of each session's price is kept by the platform
+	CommissionPercent int
+	// HoldPeriod after a session ends before its earnings can be paid out
+	HoldPeriod time.Duration
+}
+
+type PaymentConfig struct {
+	// ReconcileAfter is how long a payment stays unpaid before the
+	// reconciler asks the gateway about it
+	ReconcileAfter time.Duration
+	// ReconcilerInterval is how often the reconciler runs
+	ReconcilerInterval time.Duration
+	// RefundInterval is how often queued refunds are submitted
+	RefundInterval time.Duration
+	// OrderTTL is how long a checkout may take; a booking with a newer
+	// payment attempt is not expired
+	OrderTTL time.Duration
+}
+
+type InvoiceConfig struct {
+	// SupplierName and SupplierAddress are printed on every invoice
+	SupplierName    string
+	SupplierAddress string
+	// GSTIN turns receipts for INR payments into GST tax invoices; its
+	// first two digits are the platform's state code
+	GSTIN string
+	// SAC is the service accounting code printed for sessions
+	SAC string
+	// GSTRatePercent is the GST included in INR prices
+	GSTRatePercent int
+}
+
+type WebhookConfig struct {
+	// ProcessorInterval is how often stored webhooks are processed
+	ProcessorInterval time.Duration
+	// MaxAttempts before a webhook is marked failed and left for replay
+	MaxAttempts int
+}
+
+type PayoutConfig struct {
+	// Provider is razorpayx or fake; empty disables payouts
+	Provider string
+	// DetailsKey is the AES-256 key payout account details are encrypted with
+	DetailsKey []byte
+	// MinCents is the smallest available balance that is paid out
+	MinCents int64
+	// Interval is how often the payout job runs
+	Interval time.Duration
+	// RazorpayXAccountNumber is the business account payouts are sent from
+	RazorpayXAccountNumber string
+}
+
 func NewConfig() *Config {
 	err := godotenv.Load()
 	if err != nil {
 		fmt.Println("No .env file found")
 	}
 
-	port, err := strconv.Atoi(GetEnvOrPanic(constants.EnvKeys.DBPort))
-	if err != nil {
-		panic("DB_PORT must be a number")
-	}
-
 	zegoAppID, err := strconv.ParseInt(GetEnvOrPanic(constants.EnvKeys.ZegoAppID), 10, 64)
 	if err != nil {
 		panic("ZEGO_APP_ID must be a number")
 	}
 
+	holdMinutes := GetEnvIntOrDefault(
+		constants.EnvKeys.BookingHoldMinutes,
+		constants.DefaultBookingHoldMinutes,
+	)
+	reaperSeconds := GetEnvIntOrDefault(
+		constants.EnvKeys.BookingReaperInterval,
+		constants.DefaultBookingReaperIntervalSeconds,
+	)
+
+	minNoticeMinutes := GetEnvIntOrDefault(
+		constants.EnvKeys.BookingMinNotice,
+		constants.DefaultBookingMinNoticeMinutes,
+	)
+	maxHorizonDays := GetEnvIntOrDefault(
+		constants.EnvKeys.BookingMaxHorizon,
+		constants.DefaultBookingMaxHorizonDays,
+	)
+
+	settleDelayMinutes := GetEnvIntOrDefault(
+		constants.EnvKeys.SessionSettleDelay,
+		constants.DefaultSessionSettleDelayMinutes,
+	)
+	settlerSeconds := GetEnvIntOrDefault(
+		constants.EnvKeys.SessionSettlerInterval,
+		constants.DefaultSessionSettlerIntervalSeconds,
+	)
+
+	commissionPercent := GetEnvPercentOrDefault(
+		constants.EnvKeys.PlatformCommission,
+		constants.DefaultPlatformCommissionPercent,
+	)
+	holdDays := GetEnvIntOrDefault(
+		constants.EnvKeys.EarningsHoldDays,
+		constants.DefaultEarningsHoldDays,
+	)
+	reconcileAfterMinutes := GetEnvIntOrDefault(
+		constants.EnvKeys.PaymentReconcileAfter,
+		constants.DefaultPaymentReconcileAfterMinutes,
+	)
+	reconcilerSeconds := GetEnvIntOrDefault(
+		constants.EnvKeys.PaymentReconcilerInterval,
+		constants.DefaultPaymentReconcilerIntervalSeconds,
+	)
+	orderTTLMinutes := GetEnvIntOrDefault(
+		constants.EnvKeys.PaymentOrderTTL,
+		constants.DefaultPaymentOrderTTLMinutes,
+	)
+	refundSeconds := GetEnvIntOrDefault(
+		constants.EnvKeys.RefundSubmitterInterval,
+		constants.DefaultRefundSubmitterIntervalSeconds,
+	)
+	webhookProcessorSeconds := GetEnvIntOrDefault(
+		constants.EnvKeys.WebhookProcessorInterval,
+		constants.DefaultWebhookProcessorIntervalSeconds,
+	)
+
 	c := &Config{
 		Server: serverConfig{
 			Address: GetEnvOrPanic(constants.EnvKeys.ServerAddress),
 		},
 
-		Database: databaseConfig{
-			DatabaseDriver:   GetEnvOrPanic(constants.EnvKeys.DBDriver),
-			DatabaseHost:     GetEnvOrPanic(constants.EnvKeys.DBHost),
-			DatabasePort:     port,
-			DatabaseUser:     GetEnvOrPanic(constants.EnvKeys.DBUser),
-			DatabasePassword: GetEnvOrPanic(constants.EnvKeys.DBPassword),
-			DatabaseName:     GetEnvOrPanic(constants.EnvKeys.DBName),
-		},
+		Database: NewDatabaseConfig(),
 		JWT: jwtConfig{
 			Secret: GetEnvOrPanic(constants.EnvKeys.JWTSecret),
 		},
 		Razorpay: RazorpayConfig{
-			KeyID:         GetEnvOrPanic(constants.EnvKeys.RazorpayKeyID),
-			KeySecret:     GetEnvOrPanic(constants.EnvKeys.RazorpayKeySecret),
-			WebhookSecret: GetEnvOrPanic(constants.EnvKeys.RazorpayWebhookSecret),
+			KeyID:          GetEnvOrPanic(constants.EnvKeys.RazorpayKeyID),
+			KeySecret:      GetEnvOrPanic(constants.EnvKeys.RazorpayKeySecret),
+			WebhookSecrets: GetEnvSecretsOrPanic(constants.EnvKeys.RazorpayWebhookSecret),
+			Currencies: GetEnvListOrDefault(
+				constants.EnvKeys.RazorpayCurrencies,
+				constants.DefaultRazorpayCurrencies,
+			),
 		},
+		Stripe: NewStripeConfig(),
 		Zego: ZegoConfig{
 			AppID:        zegoAppID,
 			ServerSecret: GetEnvOrPanic(constants.EnvKeys.ZegoServerSecret),
 		},
+		Booking: BookingConfig{
+			HoldWindow:      time.Duration(holdMinutes) * time.Minute,
+			ReaperInterval:  time.Duration(reaperSeconds) * time.Second,
+			MinNotice:       time.Duration(minNoticeMinutes) * time.Minute,
+			MaxHorizon:      time.Duration(maxHorizonDays) * 24 * time.Hour,
+			SettleDelay:     time.Duration(settleDelayMinutes) * time.Minute,
+			SettlerInterval: time.Duration(settlerSeconds) * time.Second,
+		},
+		Ledger: LedgerConfig{
+			CommissionPercent: commissionPercent,
+			HoldPeriod:        time.Duration(holdDays) * 24 * time.Hour,
+		},
+		Payout:  NewPayoutConfig(),
+		Invoice: NewInvoiceConfig(),
+		Payment: PaymentConfig{
+			ReconcileAfter:     time.Duration(reconcileAfterMinutes) * time.Minute,
+			ReconcilerInterval: time.Duration(reconcilerSeconds) * time.Second,
+			RefundInterval:     time.Duration(refundSeconds) * time.Second,
+			OrderTTL:           time.Duration(orderTTLMinutes) * time.Minute,
+		},
+		Webhook: WebhookConfig{
+			ProcessorInterval: time.Duration(webhookProcessorSeconds) * time.Second,
+			MaxAttempts: GetEnvIntOrDefault(
+				constants.EnvKeys.WebhookMaxAttempts,
+				constants.DefaultWebhookMaxAttempts,
+			),
+		},
+	}
+
+	return c
+}
+
+// NewStripeConfig reads the Stripe settings. A webhook secret is required
+// once a secret key is set, since captures are confirmed by webhook.
+func NewStripeConfig() StripeConfig {
+	c := StripeConfig{
+		SecretKey: os.Getenv(constants.EnvKeys.StripeSecretKey),
+		Currencies: GetEnvListOrDefault(
+			constants.EnvKeys.StripeCurrencies,
+			constants.DefaultStripeCurrencies,
+		),
+	}
+
+	if c.SecretKey != "" {
+		c.WebhookSecrets = GetEnvSecretsOrPanic(constants.EnvKeys.StripeWebhookSecret)
 	}
 
 	return c
 }
 
+// NewPayoutConfig reads the payout settings. Payout account details can
+// only be stored when PAYOUT_DETAILS_KEY is set, and a provider needs it
+// to read them back.
+func NewPayoutConfig() PayoutConfig {
+	c := PayoutConfig{
+		Provider: os.Getenv(constants.EnvKeys.PayoutProvider),
+		MinCents: int64(GetEnvIntOrDefault(
+			constants.EnvKeys.PayoutMinCents,
+			constants.DefaultPayoutMinCents,
+		)),
+		Interval: time.Duration(GetEnvIntOrDefault(
+			constants.EnvKeys.PayoutInterval,
+			constants.DefaultPayoutIntervalMinutes,
+		)) * time.Minute,
+	}
+
+	if encoded := os.Getenv(constants.EnvKeys.PayoutDetailsKey); encoded != "" {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			panic(fmt.Sprintf("%s must be 32 bytes, base64 encoded", constants.EnvKeys.PayoutDetailsKey))
+		}
+		c.DetailsKey = key
+	}
+
+	switch c.Provider {
+	case "":
+		return c
+	case constants.PayoutProviderRazorpayX:
+		c.RazorpayXAccountNumber = GetEnvOrPanic(constants.EnvKeys.RazorpayXAccountNumber)
+	case constants.PayoutProviderFake:
+	default:
+		panic(fmt.Sprintf("%s must be %s or %s", constants.EnvKeys.PayoutProvider, constants.PayoutProviderRazorpayX, constants.PayoutProviderFake))
+	}
+
+	if c.DetailsKey == nil {
+		panic(fmt.Sprintf("%s is required when %s is set", constants.EnvKeys.PayoutDetailsKey, constants.EnvKeys.PayoutProvider))
+	}
+
+	return c
+}
+
+var gstinPattern = regexp.MustCompile(`^[0-9]{2}[A-Z0-9]{13}$`)
+
+// NewInvoiceConfig reads the invoice settings. Without a GSTIN every
+// payment gets a receipt without tax.
+func NewInvoiceConfig() InvoiceConfig {
+	c := InvoiceConfig{
+		SupplierName: GetEnvOrDefault(
+			constants.EnvKeys.InvoiceSupplierName,
+			constants.DefaultInvoiceSupplierName,
+		),
+		SupplierAddress: os.Getenv(constants.EnvKeys.InvoiceSupplierAddress),
+		GSTIN:           strings.ToUpper(os.Getenv(constants.EnvKeys.InvoiceGSTIN)),
+		SAC:             GetEnvOrDefault(constants.EnvKeys.InvoiceSAC, constants.DefaultInvoiceSAC),
+		GSTRatePercent: GetEnvPercentOrDefault(
+			constants.EnvKeys.GSTRatePercent,
+			constants.DefaultGSTRatePercent,
+		),
+	}
+
+	if c.GSTIN == "" {
+		return c
+	}
+
+	if !gstinPattern.MatchString(c.GSTIN) {
+		panic(fmt.Sprintf("%s must be a 15 character GSTIN", constants.EnvKeys.InvoiceGSTIN))
+	}
+	if c.SupplierAddress == "" {
+		panic(fmt.Sprintf("%s is required when %s is set", constants.EnvKeys.InvoiceSupplierAddress, constants.EnvKeys.InvoiceGSTIN))
+	}
+
+	return c
+}
+
+// NewDatabaseConfig reads only the database settings, for tools such as
+// cmd/migrate that do not need the rest of the server configuration.
+// DATABASE_URL, when set, replaces the individual DB_HOST/DB_PORT/... keys.
+func NewDatabaseConfig() DatabaseConfig {
+	_ = godotenv.Load()
+
+	c := DatabaseConfig{
+		DatabaseDriver: GetEnvOrDefault(constants.EnvKeys.DBDriver, "postgres"),
+		DatabaseURL:    os.Getenv(constants.EnvKeys.DBURL),
+		ReplicaURL:     os.Getenv(constants.EnvKeys.DBReplicaURL),
+
+		SSLMode:     GetEnvOrDefault(constants.EnvKeys.DBSSLMode, constants.DefaultDBSSLMode),
+		SSLRootCert: os.Getenv(constants.EnvKeys.DBSSLRootCert),
+		SSLCert:     os.Getenv(constants.EnvKeys.DBSSLCert),
+		SSLKey:      os.Getenv(constants.EnvKeys.DBSSLKey),
+
+		ApplicationName: GetEnvOrDefault(
+			constants.EnvKeys.DBApplicationName,
+			constants.DefaultDBApplicationName,
+		),
+		// 0 leaves the server default (no timeout) in place
+		StatementTimeout: time.Duration(GetEnvIntOrDefault(constants.EnvKeys.DBStatementTimeout, 0)) * time.Millisecond,
+
+		MaxOpenConns: GetEnvIntOrDefault(constants.EnvKeys.DBMaxOpenConns, constants.DefaultDBMaxOpenConns),
+		MaxIdleConns: GetEnvIntOrDefault(constants.EnvKeys.DBMaxIdleConns, constants.DefaultDBMaxIdleConns),
+		ConnMaxIdleTime: time.Duration(GetEnvIntOrDefault(
+			constants.EnvKeys.DBConnMaxIdleTime,
+			constants.DefaultDBConnMaxIdleTimeSeconds,
+		)) * time.Second,
+		ConnMaxLifetime: time.Duration(GetEnvIntOrDefault(
+			constants.EnvKeys.DBConnMaxLifetime,
+			constants.DefaultDBConnMaxLifetimeSeconds,
+		)) * time.Second,
+		ConnectionTimeout: time.Duration(GetEnvIntOrDefault(
+			constants.EnvKeys.DBConnectTimeout,
+			constants.DefaultDBConnectTimeoutSeconds,
+		)) * time.Second,
+
+		AutoMigrate: os.Getenv(constants.EnvKeys.DBAutoMigrate) == "true",
+	}
+
+	if c.DatabaseURL != "" {
+		return c
+	}
+
+	port, err := strconv.Atoi(GetEnvOrPanic(constants.EnvKeys.DBPort))
+	if err != nil {
+		panic("DB_PORT must be a number")
+	}
+
+	c.DatabaseHost = GetEnvOrPanic(constants.EnvKeys.DBHost)
+	c.DatabasePort = port
+	c.DatabaseUser = GetEnvOrPanic(constants.EnvKeys.DBUser)
+	c.DatabasePassword = GetEnvOrPanic(constants.EnvKeys.DBPassword)
+	c.DatabaseName = GetEnvOrPanic(constants.EnvKeys.DBName)
+
+	return c
+}
+
+// SQLConfig converts the settings into the database client configuration.
+func (c DatabaseConfig) SQLConfig() database.Config {
+	return database.Config{
+		Driver:            c.DatabaseDriver,
+		URL:               c.DatabaseURL,
+		Host:              c.DatabaseHost,
+		Port:              c.DatabasePort,
+		User:              c.DatabaseUser,
+		Password:          c.DatabasePassword,
+		DBName:            c.DatabaseName,
+		SSLMode:           c.SSLMode,
+		SSLRootCert:       c.SSLRootCert,
+		SSLCert:           c.SSLCert,
+		SSLKey:            c.SSLKey,
+		ApplicationName:   c.ApplicationName,
+		StatementTimeout:  c.StatementTimeout,
+		MaxOpenConns:      c.MaxOpenConns,
+		MaxIdleConns:      c.MaxIdleConns,
+		ConnMaxIdleTime:   c.ConnMaxIdleTime,
+		ConnMaxLifetime:   c.ConnMaxLifetime,
+		ConnectionTimeout: c.ConnectionTimeout,
+		ReplicaURL:        c.ReplicaURL,
+		AutoMigrate:       c.AutoMigrate,
+	}
+}
+
 func GetEnvOrPanic(key string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -103,11 +467,75 @@ func GetEnvOrPanic(key string) string {
 	return value
 }
 
+// GetEnvSecretsOrPanic reads a required comma separated list of secrets
+func GetEnvSecretsOrPanic(key string) []string {
+	var secrets []string
+	for _, secret := range strings.Split(GetEnvOrPanic(key), ",") {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+	if len(secrets) == 0 {
+		panic(fmt.Sprintf("environment variable %s not set", key))
+	}
+	return secrets
+}
+
+// GetEnvOrDefault reads an optional string setting
+func GetEnvOrDefault(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// GetEnvListOrDefault reads an optional comma separated list of
+// currency codes, upper-cased
+func GetEnvListOrDefault(key string, fallback string) []string {
+	var list []string
+	for _, item := range strings.Split(GetEnvOrDefault(key, fallback), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, strings.ToUpper(item))
+		}
+	}
+	return list
+}
+
+// GetEnvIntOrDefault reads an optional positive integer setting
+func GetEnvIntOrDefault(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		panic(fmt.Sprintf("%s must be a positive number", key))
+	}
+
+	return n
+}
+
+// GetEnvPercentOrDefault reads an optional percentage between 0 and 100
+func GetEnvPercentOrDefault(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n > 100 {
+		panic(fmt.Sprintf("%s must be a number between 0 and 100", key))
+	}
+
+	return n
+}
+
 func (conf *Config) CorsNew() gin.HandlerFunc {
 	allowedOrigin := GetEnvOrPanic(constants.EnvKeys.CorsAllowedOrigins)
 
 	return cors.New(cors.Config{
-		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
+		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions},
 		AllowHeaders:     []string{constants.Headers.Origin, constants.Headers.Authorization, constants.Headers.ContentType},
 		ExposeHeaders:    []string{constants.Headers.ContentLength},
 		AllowCredentials: true,
@@ -9,22 +9,164 @@ const (
 	RoleAdmin = "admin"
 )
 
+// Session lengths a service can offer: multiples of the step, up to the max
+const (
+	SessionDurationStepMinutes = 15
+	MaxSessionDurationMinutes  = 4 * 60
+)
+
+// Unpaid pending bookings hold their slot for this long before expiring
+const (
+	DefaultBookingHoldMinutes           = 15
+	DefaultBookingReaperIntervalSeconds = 60
+)
+
+// Confirmed sessions are settled as completed or no-show this long
+// after they end, so late leave events are still counted
+const (
+	DefaultSessionSettleDelayMinutes     = 15
+	DefaultSessionSettlerIntervalSeconds = 300
+)
+
+// Platform commission on each session and how long a mentor's earnings
+// are held for disputes before they can be paid out
+const (
+	DefaultPlatformCommissionPercent = 10
+	DefaultEarningsHoldDays          = 7
+)
+
+// Available mentor balances of at least this many cents are paid out
+// on every run of the payout job
+const (
+	DefaultPayoutMinCents        = 50000
+	DefaultPayoutIntervalMinutes = 60
+)
+
+// Stored payment webhooks are processed this often and retried with
+// backoff until they have failed this many times
+const (
+	DefaultWebhookProcessorIntervalSeconds = 5
+	DefaultWebhookMaxAttempts              = 10
+)
+
+// Payments still unpaid this long after checkout are checked against the
+// gateway in case both the client's verify call and the webhook were lost
+const (
+	DefaultPaymentReconcileAfterMinutes     = 30
+	DefaultPaymentReconcilerIntervalSeconds = 600
+)
+
+// A booking whose payment attempt was opened less than this long ago is
+// not expired, since the mentee may still be paying
+const DefaultPaymentOrderTTLMinutes = 15
+
+// Queued refunds are submitted to the gateway this often until it
+// accepts them
+const DefaultRefundSubmitterIntervalSeconds = 60
+
+// Currencies each payment gateway takes when not configured. Bookings in
+// any other currency cannot be paid.
+const (
+	DefaultRazorpayCurrencies = "INR"
+	DefaultStripeCurrencies   = "USD,EUR"
+)
+
+const (
+	PayoutProviderRazorpayX = "razorpayx"
+	PayoutProviderFake      = "fake"
+)
+
+// Sessions can be booked between the minimum notice and the horizon
+const (
+	DefaultBookingMinNoticeMinutes = 60
+	DefaultBookingMaxHorizonDays   = 90
+)
+
+// Database connection defaults. sslmode=require keeps the previous
+// behaviour when DB_SSLMODE is not set.
+const (
+	DefaultDBSSLMode                = "require"
+	DefaultDBApplicationName        = "opencall"
+	DefaultDBMaxOpenConns           = 25
+	DefaultDBMaxIdleConns           = 25
+	DefaultDBConnMaxIdleTimeSeconds = 15 * 60
+	DefaultDBConnMaxLifetimeSeconds = 30 * 60
+	DefaultDBConnectTimeoutSeconds  = 5
+)
+
+// Invoice defaults. Session prices in INR include GST at this rate once
+// the platform's GSTIN is configured; 999293 is the SAC for commercial
+// training and coaching services.
+const (
+	DefaultInvoiceSupplierName = "OpenCall"
+	DefaultInvoiceSAC          = "999293"
+	DefaultGSTRatePercent      = 18
+)
+
+// Default mentor cancellation policy
+const (
+	DefaultCancellationWindowHours       = 24
+	DefaultLateCancellationRefundPercent = 50
+)
+
 type envKeys struct {
-	Env                   string
-	ServerAddress         string
-	CorsAllowedOrigins    string
-	DBDriver              string
-	DBHost                string
-	DBPort                string
-	DBUser                string
-	DBPassword            string
-	DBName                string
-	JWTSecret             string
-	RazorpayKeyID         string
-	RazorpayKeySecret     string
-	RazorpayWebhookSecret string
-	ZegoAppID             string
-	ZegoServerSecret      string
+	Env                       string
+	ServerAddress             string
+	CorsAllowedOrigins        string
+	DBDriver                  string
+	DBHost                    string
+	DBPort                    string
+	DBUser                    string
+	DBPassword                string
+	DBName                    string
+	DBAutoMigrate             string
+	DBURL                     string
+	DBReplicaURL              string
+	DBSSLMode                 string
+	DBSSLRootCert             string
+	DBSSLCert                 string
+	DBSSLKey                  string
+	DBApplicationName         string
+	DBStatementTimeout        string
+	DBMaxOpenConns            string
+	DBMaxIdleConns            string
+	DBConnMaxIdleTime         string
+	DBConnMaxLifetime         string
+	DBConnectTimeout          string
+	JWTSecret                 string
+	RazorpayKeyID             string
+	RazorpayKeySecret         string
+	RazorpayWebhookSecret     string
+	RazorpayCurrencies        string
+	StripeSecretKey           string
+	StripeWebhookSecret       string
+	StripeCurrencies          string
+	ZegoAppID                 string
+	ZegoServerSecret          string
+	BookingHoldMinutes        string
+	BookingReaperInterval     string
+	BookingMinNotice          string
+	BookingMaxHorizon         string
+	SessionSettleDelay        string
+	SessionSettlerInterval    string
+	PlatformCommission        string
+	EarningsHoldDays          string
+	PayoutProvider            string
+	PayoutDetailsKey          string
+	PayoutMinCents            string
+	PayoutInterval            string
+	WebhookProcessorInterval  string
+	WebhookMaxAttempts        string
+	PaymentReconcileAfter     string
+	PaymentReconcilerInterval string
+	RefundSubmitterInterval   string
+	PaymentOrderTTL           string
+	RazorpayXAccountNumber    string
+	InvoiceSupplierName       string
+	InvoiceSupplierAddress    string
+	InvoiceGSTIN              string
+	InvoiceSAC                string
+	GSTRatePercent            string
 }
 
 type header struct {
@@ -35,21 +177,63 @@ type header struct {
 }
 
 var EnvKeys = envKeys{
-	Env:                   "ENV",
-	ServerAddress:         "SERVER_ADDRESS",
-	CorsAllowedOrigins:    "CORS_ALLOWED_ORIGINS",
-	DBDriver:              "DB_DRIVER",
-	DBHost:                "DB_HOST",
-	DBPort:                "DB_PORT",
-	DBUser:                "DB_USER",
-	DBPassword:            "DB_PASSWORD",
-	DBName:                "DB_NAME",
-	JWTSecret:             "JWT_SECRET",
-	RazorpayKeyID:         "RAZORPAY_KEY_ID",
-	RazorpayKeySecret:     "RAZORPAY_KEY_SECRET",
-	RazorpayWebhookSecret: "RAZORPAY_WEBHOOK_SECRET",
-	ZegoAppID:             "ZEGO_APP_ID",
-	ZegoServerSecret:      "ZEGO_SERVER_SECRET",
+	Env:                       "ENV",
+	ServerAddress:             "SERVER_ADDRESS",
+	CorsAllowedOrigins:        "CORS_ALLOWED_ORIGINS",
+	DBDriver:                  "DB_DRIVER",
+	DBHost:                    "DB_HOST",
+	DBPort:                    "DB_PORT",
+	DBUser:                    "DB_USER",
+	DBPassword:                "DB_PASSWORD",
+	DBName:                    "DB_NAME",
+	DBAutoMigrate:             "DB_AUTO_MIGRATE",
+	DBURL:                     "DATABASE_URL",
+	DBReplicaURL:              "DATABASE_REPLICA_URL",
+	DBSSLMode:                 "DB_SSLMODE",
+	DBSSLRootCert:             "DB_SSLROOTCERT",
+	DBSSLCert:                 "DB_SSLCERT",
+	DBSSLKey:                  "DB_SSLKEY",
+	DBApplicationName:         "DB_APPLICATION_NAME",
+	DBStatementTimeout:        "DB_STATEMENT_TIMEOUT_MS",
+	DBMaxOpenConns:            "DB_MAX_OPEN_CONNS",
+	DBMaxIdleConns:            "DB_MAX_IDLE_CONNS",
+	DBConnMaxIdleTime:         "DB_CONN_MAX_IDLE_TIME_SECONDS",
+	DBConnMaxLifetime:         "DB_CONN_MAX_LIFETIME_SECONDS",
+	DBConnectTimeout:          "DB_CONNECT_TIMEOUT_SECONDS",
+	JWTSecret:                 "JWT_SECRET",
+	RazorpayKeyID:             "RAZORPAY_KEY_ID",
+	RazorpayKeySecret:         "RAZORPAY_KEY_SECRET",
+	RazorpayWebhookSecret:     "RAZORPAY_WEBHOOK_SECRET",
+	RazorpayCurrencies:        "RAZORPAY_CURRENCIES",
+	StripeSecretKey:           "STRIPE_SECRET_KEY",
+	StripeWebhookSecret:       "STRIPE_WEBHOOK_SECRET",
+	StripeCurrencies:          "STRIPE_CURRENCIES",
+	ZegoAppID:                 "ZEGO_APP_ID",
+	ZegoServerSecret:          "ZEGO_SERVER_SECRET",
+	BookingHoldMinutes:        "BOOKING_HOLD_MINUTES",
+	BookingReaperInterval:     "BOOKING_REAPER_INTERVAL_SECONDS",
+	BookingMinNotice:          "BOOKING_MIN_NOTICE_MINUTES",
+	BookingMaxHorizon:         "BOOKING_MAX_HORIZON_DAYS",
+	SessionSettleDelay:        "SESSION_SETTLE_DELAY_MINUTES",
+	SessionSettlerInterval:    "SESSION_SETTLER_INTERVAL_SECONDS",
+	PlatformCommission:        "PLATFORM_COMMISSION_PERCENT",
+	EarningsHoldDays:          "EARNINGS_HOLD_DAYS",
+	PayoutProvider:            "PAYOUT_PROVIDER",
+	PayoutDetailsKey:          "PAYOUT_DETAILS_KEY",
+	PayoutMinCents:            "PAYOUT_MIN_CENTS",
+	PayoutInterval:            "PAYOUT_INTERVAL_MINUTES",
+	WebhookProcessorInterval:  "WEBHOOK_PROCESSOR_INTERVAL_SECONDS",
+	WebhookMaxAttempts:        "WEBHOOK_MAX_ATTEMPTS",
+	PaymentReconcileAfter:     "PAYMENT_RECONCILE_AFTER_MINUTES",
+	PaymentReconcilerInterval: "PAYMENT_RECONCILER_INTERVAL_SECONDS",
+	RefundSubmitterInterval:   "REFUND_SUBMITTER_INTERVAL_SECONDS",
+	PaymentOrderTTL:           "PAYMENT_ORDER_TTL_MINUTES",
+	RazorpayXAccountNumber:    "RAZORPAYX_ACCOUNT_NUMBER",
+	InvoiceSupplierName:       "INVOICE_SUPPLIER_NAME",
+	InvoiceSupplierAddress:    "INVOICE_SUPPLIER_ADDRESS",
+	InvoiceGSTIN:              "INVOICE_GSTIN",
+	InvoiceSAC:                "INVOICE_SAC",
+	GSTRatePercent:            "GST_RATE_PERCENT",
 }
 
 var Headers = header{
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS bookings;
+DROP TABLE IF EXISTS mentor_availability_rules;
+DROP TABLE IF EXISTS mentor_services;
+DROP TABLE IF EXISTS mentor_profiles;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS users;
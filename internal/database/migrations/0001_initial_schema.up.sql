@@ -0,0 +1,104 @@
+-- Baseline schema. IF NOT EXISTS lets databases that were created by
+-- hand before migrations existed adopt this version without changes.
+
+CREATE TABLE IF NOT EXISTS users (
+    id              UUID PRIMARY KEY,
+    first_name      TEXT NOT NULL,
+    last_name       TEXT NOT NULL,
+    username        TEXT NOT NULL UNIQUE,
+    email           TEXT NOT NULL UNIQUE,
+    password_hash   TEXT NOT NULL,
+    role            TEXT NOT NULL DEFAULT 'user',
+    profile_picture TEXT NOT NULL DEFAULT '',
+    bio             TEXT NOT NULL DEFAULT '',
+    is_active       BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at      TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+
+CREATE TABLE IF NOT EXISTS mentor_profiles (
+    id         UUID PRIMARY KEY,
+    user_id    UUID NOT NULL UNIQUE REFERENCES users (id) ON DELETE CASCADE,
+    title      TEXT NOT NULL,
+    bio        TEXT NOT NULL DEFAULT '',
+    timezone   TEXT NOT NULL,
+    is_active  BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS mentor_services (
+    id               UUID PRIMARY KEY,
+    mentor_id        UUID NOT NULL REFERENCES mentor_profiles (id) ON DELETE CASCADE,
+    title            TEXT NOT NULL,
+    description      TEXT NOT NULL DEFAULT '',
+    duration_minutes INT NOT NULL CHECK (duration_minutes > 0),
+    price_cents      INT NOT NULL CHECK (price_cents >= 0),
+    currency         CHAR(3) NOT NULL,
+    is_active        BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS mentor_services_mentor_id_idx ON mentor_services (mentor_id);
+
+CREATE TABLE IF NOT EXISTS mentor_availability_rules (
+    id          UUID PRIMARY KEY,
+    mentor_id   UUID NOT NULL REFERENCES mentor_profiles (id) ON DELETE CASCADE,
+    day_of_week SMALLINT NOT NULL CHECK (day_of_week BETWEEN 0 AND 6),
+    start_time  TIME NOT NULL,
+    end_time    TIME NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (end_time > start_time)
+);
+
+CREATE INDEX IF NOT EXISTS mentor_availability_rules_mentor_day_idx
+    ON mentor_availability_rules (mentor_id, day_of_week);
+
+CREATE TABLE IF NOT EXISTS bookings (
+    id           UUID PRIMARY KEY,
+    mentor_id    UUID NOT NULL REFERENCES mentor_profiles (id),
+    user_id      UUID NOT NULL REFERENCES users (id),
+    service_id   UUID NOT NULL REFERENCES mentor_services (id),
+    booking_date DATE NOT NULL,
+    start_time   TIME NOT NULL,
+    end_time     TIME NOT NULL,
+    status       TEXT NOT NULL,
+    price_cents  INT NOT NULL,
+    currency     CHAR(3) NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS bookings_mentor_date_idx ON bookings (mentor_id, booking_date);
+CREATE INDEX IF NOT EXISTS bookings_user_id_idx ON bookings (user_id);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id                 UUID PRIMARY KEY,
+    booking_id         UUID NOT NULL REFERENCES bookings (id),
+    user_id            UUID NOT NULL REFERENCES users (id),
+    gateway            TEXT NOT NULL,
+    gateway_order_id   TEXT NOT NULL UNIQUE,
+    gateway_payment_id TEXT,
+    gateway_signature  TEXT,
+    amount             BIGINT NOT NULL,
+    currency           CHAR(3) NOT NULL,
+    status             TEXT NOT NULL,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS payments_booking_id_idx ON payments (booking_id);
@@ -0,0 +1,9 @@
+ALTER TABLE bookings
+    DROP COLUMN refund_cents,
+    DROP COLUMN cancellation_reason,
+    DROP COLUMN cancelled_by,
+    DROP COLUMN cancelled_at;
+
+ALTER TABLE mentor_profiles
+    DROP COLUMN late_cancellation_refund_percent,
+    DROP COLUMN cancellation_window_hours;
@@ -0,0 +1,11 @@
+ALTER TABLE mentor_profiles
+    ADD COLUMN cancellation_window_hours INT NOT NULL DEFAULT 24
+        CHECK (cancellation_window_hours >= 0),
+    ADD COLUMN late_cancellation_refund_percent INT NOT NULL DEFAULT 50
+        CHECK (late_cancellation_refund_percent BETWEEN 0 AND 100);
+
+ALTER TABLE bookings
+    ADD COLUMN cancelled_at TIMESTAMPTZ,
+    ADD COLUMN cancelled_by UUID REFERENCES users (id),
+    ADD COLUMN cancellation_reason TEXT,
+    ADD COLUMN refund_cents INT NOT NULL DEFAULT 0;
@@ -0,0 +1,15 @@
+CREATE TABLE refunds (
+    id                UUID PRIMARY KEY,
+    payment_id        UUID NOT NULL REFERENCES payments (id),
+    booking_id        UUID NOT NULL REFERENCES bookings (id),
+    gateway           TEXT NOT NULL,
+    gateway_refund_id TEXT UNIQUE,
+    amount            BIGINT NOT NULL CHECK (amount > 0),
+    currency          CHAR(3) NOT NULL,
+    reason            TEXT NOT NULL DEFAULT '',
+    status            TEXT NOT NULL,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX refunds_payment_id_idx ON refunds (payment_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS bookings_pending_created_idx;
+DROP INDEX IF EXISTS bookings_mentor_start_idx;
+
+ALTER TABLE bookings
+    ALTER COLUMN start_time TYPE TIME USING (start_time AT TIME ZONE 'UTC')::TIME,
+    ALTER COLUMN end_time TYPE TIME USING (end_time AT TIME ZONE 'UTC')::TIME;
@@ -0,0 +1,16 @@
+-- Bookings used to keep UTC wall-clock TIME values next to booking_date.
+-- Convert them to absolute instants; a session ending at midnight rolls
+-- its end over to the next day.
+ALTER TABLE bookings
+    ALTER COLUMN start_time TYPE TIMESTAMPTZ
+        USING (booking_date + start_time) AT TIME ZONE 'UTC',
+    ALTER COLUMN end_time TYPE TIMESTAMPTZ
+        USING (
+            CASE
+                WHEN end_time <= start_time THEN booking_date + 1 + end_time
+                ELSE booking_date + end_time
+            END
+        ) AT TIME ZONE 'UTC';
+
+CREATE INDEX bookings_mentor_start_idx ON bookings (mentor_id, start_time);
+CREATE INDEX bookings_pending_created_idx ON bookings (created_at) WHERE status = 'pending';
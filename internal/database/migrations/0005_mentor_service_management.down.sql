@@ -0,0 +1,3 @@
+ALTER TABLE mentor_services
+    DROP COLUMN deleted_at,
+    DROP COLUMN sort_order;
@@ -0,0 +1,12 @@
+ALTER TABLE mentor_services
+    ADD COLUMN sort_order INT NOT NULL DEFAULT 0,
+    ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- keep the current listing order (oldest first) for existing services
+UPDATE mentor_services ms
+SET sort_order = ordered.position
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY mentor_id ORDER BY created_at) AS position
+    FROM mentor_services
+) ordered
+WHERE ordered.id = ms.id;
@@ -0,0 +1,25 @@
+-- One-off changes to a mentor's weekly schedule on a specific mentor-local
+-- date. Times are wall-clock times in the mentor's timezone, like
+-- mentor_availability_rules.
+--   blackout: the whole day is unavailable
+--   block:    start_time..end_time is unavailable
+--   extra:    start_time..end_time is available in addition to the rules
+CREATE TABLE mentor_availability_overrides (
+    id            UUID PRIMARY KEY,
+    mentor_id     UUID NOT NULL REFERENCES mentor_profiles (id) ON DELETE CASCADE,
+    override_date DATE NOT NULL,
+    kind          TEXT NOT NULL CHECK (kind IN ('blackout', 'block', 'extra')),
+    start_time    TIME,
+    end_time      TIME,
+    reason        TEXT NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CHECK (
+        (kind = 'blackout' AND start_time IS NULL AND end_time IS NULL)
+        OR (kind <> 'blackout' AND start_time IS NOT NULL AND end_time > start_time)
+    )
+);
+
+CREATE INDEX mentor_availability_overrides_mentor_date_idx
+    ON mentor_availability_overrides (mentor_id, override_date);
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS bookings_mentor_blocked_idx;
+
+ALTER TABLE bookings
+    DROP COLUMN min_gap_minutes,
+    DROP COLUMN blocked_until,
+    DROP COLUMN blocked_from;
+
+ALTER TABLE mentor_services
+    DROP COLUMN min_gap_minutes,
+    DROP COLUMN max_sessions_per_day,
+    DROP COLUMN slot_step_minutes,
+    DROP COLUMN buffer_after_minutes,
+    DROP COLUMN buffer_before_minutes;
@@ -0,0 +1,31 @@
+ALTER TABLE mentor_services
+    ADD COLUMN buffer_before_minutes INT NOT NULL DEFAULT 0
+        CHECK (buffer_before_minutes BETWEEN 0 AND 240),
+    ADD COLUMN buffer_after_minutes INT NOT NULL DEFAULT 0
+        CHECK (buffer_after_minutes BETWEEN 0 AND 240),
+    -- 0 steps by the session duration
+    ADD COLUMN slot_step_minutes INT NOT NULL DEFAULT 0
+        CHECK (slot_step_minutes = 0 OR slot_step_minutes BETWEEN 5 AND 240),
+    -- 0 is unlimited
+    ADD COLUMN max_sessions_per_day INT NOT NULL DEFAULT 0
+        CHECK (max_sessions_per_day >= 0),
+    ADD COLUMN min_gap_minutes INT NOT NULL DEFAULT 0
+        CHECK (min_gap_minutes BETWEEN 0 AND 240);
+
+-- The time a booking takes from the mentor's calendar, buffers included,
+-- and the gap it requires to its neighbours. Both are copied from the
+-- service when booking so later service edits leave bookings unchanged.
+ALTER TABLE bookings
+    ADD COLUMN blocked_from TIMESTAMPTZ,
+    ADD COLUMN blocked_until TIMESTAMPTZ,
+    ADD COLUMN min_gap_minutes INT NOT NULL DEFAULT 0;
+
+UPDATE bookings
+SET blocked_from = start_time,
+    blocked_until = end_time;
+
+ALTER TABLE bookings
+    ALTER COLUMN blocked_from SET NOT NULL,
+    ALTER COLUMN blocked_until SET NOT NULL;
+
+CREATE INDEX bookings_mentor_blocked_idx ON bookings (mentor_id, blocked_from);
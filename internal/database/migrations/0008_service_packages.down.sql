@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS payments_package_purchase_id_idx;
+
+DELETE FROM payments WHERE booking_id IS NULL;
+
+ALTER TABLE payments
+    DROP CONSTRAINT payments_subject_check,
+    DROP COLUMN package_purchase_id,
+    ALTER COLUMN booking_id SET NOT NULL;
+
+DROP TABLE IF EXISTS session_credits;
+DROP TABLE IF EXISTS package_purchases;
+DROP TABLE IF EXISTS mentor_service_packages;
+
+ALTER TABLE mentor_services
+    DROP CONSTRAINT mentor_services_duration_step_check;
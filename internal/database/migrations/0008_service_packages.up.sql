@@ -0,0 +1,66 @@
+-- Any length in 15-minute steps, up to 4 hours
+ALTER TABLE mentor_services
+    ADD CONSTRAINT mentor_services_duration_step_check
+        CHECK (duration_minutes % 15 = 0 AND duration_minutes <= 240);
+
+-- A bundle of sessions of one service sold at once
+CREATE TABLE IF NOT EXISTS mentor_service_packages (
+    id            UUID PRIMARY KEY,
+    mentor_id     UUID NOT NULL REFERENCES mentor_profiles (id) ON DELETE CASCADE,
+    service_id    UUID NOT NULL REFERENCES mentor_services (id) ON DELETE CASCADE,
+    title         TEXT NOT NULL,
+    session_count INT NOT NULL CHECK (session_count BETWEEN 2 AND 50),
+    price_cents   INT NOT NULL CHECK (price_cents >= 0),
+    currency      CHAR(3) NOT NULL,
+    is_active     BOOLEAN NOT NULL DEFAULT true,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS mentor_service_packages_service_idx
+    ON mentor_service_packages (service_id);
+
+-- One mentee buying one package. Price and session count are copied so
+-- later package edits leave purchases unchanged.
+CREATE TABLE IF NOT EXISTS package_purchases (
+    id            UUID PRIMARY KEY,
+    package_id    UUID NOT NULL REFERENCES mentor_service_packages (id),
+    user_id       UUID NOT NULL REFERENCES users (id),
+    mentor_id     UUID NOT NULL REFERENCES mentor_profiles (id),
+    service_id    UUID NOT NULL REFERENCES mentor_services (id),
+    session_count INT NOT NULL,
+    price_cents   INT NOT NULL,
+    currency      CHAR(3) NOT NULL,
+    status        TEXT NOT NULL CHECK (status IN ('pending', 'paid', 'failed')),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS package_purchases_user_idx ON package_purchases (user_id);
+
+-- One prepaid session, created when the purchase is paid. booking_id is
+-- set while a booking holds the credit and cleared if it is given back.
+CREATE TABLE IF NOT EXISTS session_credits (
+    id          UUID PRIMARY KEY,
+    purchase_id UUID NOT NULL REFERENCES package_purchases (id),
+    user_id     UUID NOT NULL REFERENCES users (id),
+    service_id  UUID NOT NULL REFERENCES mentor_services (id),
+    booking_id  UUID UNIQUE REFERENCES bookings (id),
+    redeemed_at TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS session_credits_available_idx
+    ON session_credits (user_id, service_id)
+    WHERE booking_id IS NULL;
+
+-- A payment pays for either a booking or a package purchase
+ALTER TABLE payments
+    ALTER COLUMN booking_id DROP NOT NULL,
+    ADD COLUMN package_purchase_id UUID REFERENCES package_purchases (id),
+    ADD CONSTRAINT payments_subject_check
+        CHECK (num_nonnulls(booking_id, package_purchase_id) = 1);
+
+CREATE INDEX IF NOT EXISTS payments_package_purchase_id_idx
+    ON payments (package_purchase_id);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS bookings_confirmed_end_idx;
+
+UPDATE bookings
+SET status = 'confirmed'
+WHERE status IN ('no_show_mentor', 'no_show_user');
+
+DROP TABLE IF EXISTS session_events;
@@ -0,0 +1,15 @@
+-- What each participant did around a booking's video session
+CREATE TABLE session_events (
+    id          UUID PRIMARY KEY,
+    booking_id  UUID NOT NULL REFERENCES bookings (id) ON DELETE CASCADE,
+    user_id     UUID NOT NULL REFERENCES users (id),
+    role        TEXT NOT NULL CHECK (role IN ('mentee', 'mentor')),
+    event_type  TEXT NOT NULL CHECK (event_type IN ('token_issued', 'joined', 'left')),
+    occurred_at TIMESTAMPTZ NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX session_events_booking_idx ON session_events (booking_id, event_type);
+
+-- Finished confirmed sessions are picked up by the completion job
+CREATE INDEX bookings_confirmed_end_idx ON bookings (end_time) WHERE status = 'confirmed';
@@ -0,0 +1,3 @@
+ALTER TABLE session_events DROP COLUMN IF EXISTS source;
+
+DROP TABLE IF EXISTS video_sessions;
@@ -0,0 +1,19 @@
+-- The video room of a booking and how long it actually ran, as reported
+-- by ZEGOCLOUD server callbacks
+CREATE TABLE video_sessions (
+    booking_id       UUID PRIMARY KEY REFERENCES bookings (id) ON DELETE CASCADE,
+    room_id          TEXT NOT NULL UNIQUE,
+    started_at       TIMESTAMPTZ,
+    ended_at         TIMESTAMPTZ,
+    -- set while the room is open; the time it was (re)created
+    opened_at        TIMESTAMPTZ,
+    duration_seconds INT NOT NULL DEFAULT 0 CHECK (duration_seconds >= 0),
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Client-reported events and ZEGOCLOUD callbacks are kept apart so
+-- disputes can rely on the server-verified ones
+ALTER TABLE session_events
+    ADD COLUMN source TEXT NOT NULL DEFAULT 'client'
+        CHECK (source IN ('client', 'zego'));
@@ -0,0 +1,31 @@
+-- One balanced business event in the ledger, e.g. a session's earnings
+-- or a refund. idempotency_key stops the same event being posted twice.
+CREATE TABLE ledger_transactions (
+    id              UUID PRIMARY KEY,
+    kind            TEXT NOT NULL CHECK (kind IN ('session_earning', 'refund')),
+    idempotency_key TEXT NOT NULL UNIQUE,
+    mentor_id       UUID REFERENCES mentor_profiles (id),
+    booking_id      UUID REFERENCES bookings (id),
+    refund_id       UUID REFERENCES refunds (id),
+    memo            TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX ledger_transactions_booking_idx ON ledger_transactions (booking_id, kind);
+
+-- Double-entry lines: the debits and credits of a transaction are equal.
+-- available_at is when a mentor_payable credit leaves its hold.
+CREATE TABLE ledger_entries (
+    id             UUID PRIMARY KEY,
+    transaction_id UUID NOT NULL REFERENCES ledger_transactions (id),
+    account        TEXT NOT NULL CHECK (account IN ('gateway_clearing', 'mentor_payable', 'platform_revenue')),
+    mentor_id      UUID REFERENCES mentor_profiles (id),
+    direction      TEXT NOT NULL CHECK (direction IN ('debit', 'credit')),
+    amount_cents   BIGINT NOT NULL CHECK (amount_cents > 0),
+    currency       CHAR(3) NOT NULL,
+    available_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX ledger_entries_transaction_idx ON ledger_entries (transaction_id);
+CREATE INDEX ledger_entries_mentor_idx ON ledger_entries (mentor_id, account, created_at);
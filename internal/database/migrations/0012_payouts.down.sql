@@ -0,0 +1,23 @@
+DELETE FROM ledger_entries
+WHERE transaction_id IN (
+    SELECT id FROM ledger_transactions
+    WHERE kind IN ('payout', 'payout_paid', 'payout_failed')
+);
+
+DELETE FROM ledger_transactions
+WHERE kind IN ('payout', 'payout_paid', 'payout_failed');
+
+ALTER TABLE ledger_entries
+    DROP CONSTRAINT ledger_entries_account_check,
+    ADD CONSTRAINT ledger_entries_account_check
+        CHECK (account IN ('gateway_clearing', 'mentor_payable', 'platform_revenue'));
+
+ALTER TABLE ledger_transactions
+    DROP COLUMN payout_id,
+    DROP CONSTRAINT ledger_transactions_kind_check,
+    ADD CONSTRAINT ledger_transactions_kind_check
+        CHECK (kind IN ('session_earning', 'refund'));
+
+DROP TABLE IF EXISTS payouts;
+DROP TABLE IF EXISTS payout_batches;
+DROP TABLE IF EXISTS mentor_payout_accounts;
@@ -0,0 +1,54 @@
+-- Where a mentor is paid. The bank account or UPI details are encrypted
+-- by the application; display_hint is a masked form safe to show.
+CREATE TABLE mentor_payout_accounts (
+    mentor_id               UUID PRIMARY KEY REFERENCES mentor_profiles (id) ON DELETE CASCADE,
+    method                  TEXT NOT NULL CHECK (method IN ('bank_account', 'vpa')),
+    details_ciphertext      BYTEA NOT NULL,
+    display_hint            TEXT NOT NULL,
+    provider                TEXT,
+    provider_destination_id TEXT,
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One run of the payout job that paid at least one mentor
+CREATE TABLE payout_batches (
+    id           UUID PRIMARY KEY,
+    payout_count INT NOT NULL DEFAULT 0,
+    total_cents  BIGINT NOT NULL DEFAULT 0,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE payouts (
+    id                 UUID PRIMARY KEY,
+    batch_id           UUID NOT NULL REFERENCES payout_batches (id),
+    mentor_id          UUID NOT NULL REFERENCES mentor_profiles (id),
+    amount_cents       BIGINT NOT NULL CHECK (amount_cents > 0),
+    currency           CHAR(3) NOT NULL,
+    status             TEXT NOT NULL CHECK (status IN ('pending', 'processing', 'paid', 'failed')),
+    provider           TEXT NOT NULL,
+    provider_payout_id TEXT UNIQUE,
+    failure_reason     TEXT,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- A mentor has at most one payout in flight, even with several servers
+-- running the job
+CREATE UNIQUE INDEX payouts_one_in_flight_idx
+    ON payouts (mentor_id)
+    WHERE status IN ('pending', 'processing');
+
+CREATE INDEX payouts_mentor_idx ON payouts (mentor_id, created_at);
+
+-- Payouts move money from mentor_payable through payouts_in_transit
+ALTER TABLE ledger_transactions
+    DROP CONSTRAINT ledger_transactions_kind_check,
+    ADD CONSTRAINT ledger_transactions_kind_check
+        CHECK (kind IN ('session_earning', 'refund', 'payout', 'payout_paid', 'payout_failed')),
+    ADD COLUMN payout_id UUID REFERENCES payouts (id);
+
+ALTER TABLE ledger_entries
+    DROP CONSTRAINT ledger_entries_account_check,
+    ADD CONSTRAINT ledger_entries_account_check
+        CHECK (account IN ('gateway_clearing', 'mentor_payable', 'platform_revenue', 'payouts_in_transit'));
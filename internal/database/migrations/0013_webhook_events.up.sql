@@ -0,0 +1,35 @@
+-- Every payment gateway webhook as received. Events are stored before
+-- they are processed, so a crash or a failing handler never loses one,
+-- and a redelivery of the same gateway event is recognised by event_id.
+CREATE TABLE webhook_events (
+    id              UUID PRIMARY KEY,
+    gateway         TEXT NOT NULL,
+    -- the gateway's event ID, or a hash of the payload when it has none
+    event_id        TEXT NOT NULL,
+    event_type      TEXT NOT NULL DEFAULT '',
+    -- raw body and signature header, exactly as received
+    payload         TEXT NOT NULL,
+    signature       TEXT NOT NULL DEFAULT '',
+    signature_valid BOOLEAN NOT NULL,
+    status          TEXT NOT NULL
+        CHECK (status IN ('pending', 'processing', 'processed', 'failed', 'rejected')),
+    attempts        INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ,
+    last_error      TEXT,
+    received_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at    TIMESTAMPTZ,
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Only verified events claim their event ID; a forged delivery must not
+-- block the real one
+CREATE UNIQUE INDEX webhook_events_event_idx
+    ON webhook_events (gateway, event_id)
+    WHERE signature_valid;
+
+CREATE INDEX webhook_events_due_idx
+    ON webhook_events (next_attempt_at)
+    WHERE status IN ('pending', 'processing');
+
+CREATE INDEX webhook_events_status_idx
+    ON webhook_events (status, received_at DESC);
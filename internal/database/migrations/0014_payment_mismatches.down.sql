@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS payments_created_idx;
+
+DROP TABLE IF EXISTS payment_mismatches;
@@ -0,0 +1,18 @@
+-- Payments whose captured amount or currency at the gateway differs from
+-- what we asked for, as found by the reconciler
+CREATE TABLE payment_mismatches (
+    id                 UUID PRIMARY KEY,
+    payment_id         UUID NOT NULL UNIQUE REFERENCES payments (id) ON DELETE CASCADE,
+    gateway            TEXT NOT NULL,
+    gateway_payment_id TEXT NOT NULL,
+    expected_amount    BIGINT NOT NULL,
+    expected_currency  TEXT NOT NULL,
+    actual_amount      BIGINT NOT NULL,
+    actual_currency    TEXT NOT NULL,
+    detected_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- The reconciler scans payments still waiting for their capture
+CREATE INDEX payments_created_idx
+    ON payments (created_at)
+    WHERE status = 'created';
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS payments_unpaid_idx;
+CREATE INDEX payments_created_idx
+    ON payments (created_at)
+    WHERE status = 'created';
+
+DROP INDEX IF EXISTS payments_one_open_attempt_idx;
+
+-- Without the attempt states, unconfirmed payments are simply unpaid
+UPDATE payments
+SET status = 'created',
+    updated_at = NOW()
+WHERE status IN ('superseded', 'amount_mismatch');
@@ -0,0 +1,25 @@
+-- A booking has at most one open payment attempt. Opening a new one
+-- supersedes the previous attempt, which can still be captured while the
+-- booking is unpaid. Older duplicates are superseded here first.
+UPDATE payments p
+SET status = 'superseded',
+    updated_at = NOW()
+WHERE p.status = 'created'
+  AND p.booking_id IS NOT NULL
+  AND EXISTS (
+    SELECT 1
+    FROM payments newer
+    WHERE newer.booking_id = p.booking_id
+      AND newer.status = 'created'
+      AND (newer.created_at, newer.id) > (p.created_at, p.id)
+  );
+
+CREATE UNIQUE INDEX payments_one_open_attempt_idx
+    ON payments (booking_id)
+    WHERE status = 'created' AND booking_id IS NOT NULL;
+
+-- The reconciler also checks superseded attempts, which may have been paid
+DROP INDEX IF EXISTS payments_created_idx;
+CREATE INDEX payments_unpaid_idx
+    ON payments (created_at)
+    WHERE status IN ('created', 'superseded');
@@ -0,0 +1,3 @@
+-- Which webhook secret signed a verified event, as a fingerprint of the
+-- secret, so deliveries can be audited while secrets are rotated
+ALTER TABLE webhook_events ADD COLUMN secret_id TEXT;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS bookings_service_user_idx;
+
+ALTER TABLE mentor_services
+    DROP COLUMN IF EXISTS free_bookings_per_mentee;
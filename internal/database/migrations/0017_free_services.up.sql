@@ -0,0 +1,7 @@
+-- How many bookings of a free service one mentee may hold, counting
+-- sessions already held; 0 is unlimited. Ignored for paid services.
+ALTER TABLE mentor_services
+    ADD COLUMN free_bookings_per_mentee INT NOT NULL DEFAULT 0
+        CHECK (free_bookings_per_mentee >= 0);
+
+CREATE INDEX bookings_service_user_idx ON bookings (service_id, user_id);
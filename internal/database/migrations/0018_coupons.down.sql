@@ -0,0 +1,7 @@
+ALTER TABLE ledger_entries
+    DROP CONSTRAINT ledger_entries_account_check,
+    ADD CONSTRAINT ledger_entries_account_check
+        CHECK (account IN ('gateway_clearing', 'mentor_payable', 'platform_revenue', 'payouts_in_transit'));
+
+DROP TABLE IF EXISTS coupon_redemptions;
+DROP TABLE IF EXISTS coupons;
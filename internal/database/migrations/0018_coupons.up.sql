@@ -0,0 +1,54 @@
+-- Discount codes. Mentors issue codes for their own services; platform
+-- codes (mentor_id NULL) work on any service and the platform funds the
+-- discount. Codes are stored upper-case.
+CREATE TABLE coupons (
+    id                       UUID PRIMARY KEY,
+    code                     TEXT NOT NULL UNIQUE,
+    mentor_id                UUID REFERENCES mentor_profiles (id) ON DELETE CASCADE,
+    -- NULL applies the coupon to every eligible service
+    service_id               UUID REFERENCES mentor_services (id) ON DELETE CASCADE,
+    discount_type            TEXT NOT NULL CHECK (discount_type IN ('percent', 'fixed')),
+    percent_off              INT NOT NULL DEFAULT 0,
+    amount_off_cents         INT NOT NULL DEFAULT 0,
+    currency                 CHAR(3),
+    -- 0 is unlimited
+    max_redemptions          INT NOT NULL DEFAULT 0 CHECK (max_redemptions >= 0),
+    max_redemptions_per_user INT NOT NULL DEFAULT 0 CHECK (max_redemptions_per_user >= 0),
+    starts_at                TIMESTAMPTZ,
+    expires_at               TIMESTAMPTZ,
+    is_active                BOOLEAN NOT NULL DEFAULT true,
+    created_by               UUID NOT NULL REFERENCES users (id),
+    created_at               TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at               TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CHECK (
+        (discount_type = 'percent' AND percent_off BETWEEN 1 AND 100)
+        OR (discount_type = 'fixed' AND amount_off_cents > 0 AND currency IS NOT NULL)
+    ),
+    CHECK (starts_at IS NULL OR expires_at IS NULL OR expires_at > starts_at)
+);
+
+CREATE INDEX coupons_mentor_idx ON coupons (mentor_id);
+
+-- A coupon applied to a booking, written in the booking's transaction.
+-- Redemptions of bookings that were cancelled, expired or never paid no
+-- longer count towards the coupon's limits.
+CREATE TABLE coupon_redemptions (
+    id               UUID PRIMARY KEY,
+    coupon_id        UUID NOT NULL REFERENCES coupons (id),
+    booking_id       UUID NOT NULL UNIQUE REFERENCES bookings (id),
+    user_id          UUID NOT NULL REFERENCES users (id),
+    list_price_cents INT NOT NULL,
+    discount_cents   INT NOT NULL CHECK (discount_cents > 0),
+    currency         CHAR(3) NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX coupon_redemptions_coupon_user_idx ON coupon_redemptions (coupon_id, user_id);
+
+-- The platform's side of its own coupons: mentors earn on the list price
+-- and the platform pays the discount.
+ALTER TABLE ledger_entries
+    DROP CONSTRAINT ledger_entries_account_check,
+    ADD CONSTRAINT ledger_entries_account_check
+        CHECK (account IN ('gateway_clearing', 'mentor_payable', 'platform_revenue', 'payouts_in_transit', 'platform_promotions'));
@@ -0,0 +1,52 @@
+-- GST state code of the mentee's billing address, given at checkout. It
+-- decides the place of supply on the booking's invoice.
+ALTER TABLE payments ADD COLUMN billing_state CHAR(2);
+
+-- Last number issued in each invoice series and financial year. The row
+-- is incremented in the transaction that issues the invoice, so numbers
+-- are sequential and a rolled back capture leaves no gap.
+CREATE TABLE invoice_sequences (
+    series         TEXT NOT NULL,
+    financial_year TEXT NOT NULL,
+    last_number    INT NOT NULL,
+    PRIMARY KEY (series, financial_year)
+);
+
+-- One invoice per paid booking: a GST tax invoice for INR payments when
+-- the platform has a GSTIN, a receipt otherwise. Supplier and buyer
+-- details are copied in, so issued invoices never change.
+CREATE TABLE invoices (
+    id               UUID PRIMARY KEY,
+    number           TEXT NOT NULL UNIQUE,
+    kind             TEXT NOT NULL CHECK (kind IN ('tax_invoice', 'receipt')),
+    financial_year   TEXT NOT NULL,
+    sequence         INT NOT NULL,
+    booking_id       UUID NOT NULL UNIQUE REFERENCES bookings (id),
+    payment_id       UUID NOT NULL REFERENCES payments (id),
+    user_id          UUID NOT NULL REFERENCES users (id),
+    mentor_id        UUID NOT NULL REFERENCES mentor_profiles (id),
+
+    supplier_name    TEXT NOT NULL,
+    supplier_address TEXT NOT NULL,
+    supplier_gstin   TEXT,
+    supplier_state   CHAR(2),
+    buyer_name       TEXT NOT NULL,
+    buyer_email      TEXT NOT NULL,
+    billing_state    CHAR(2),
+    place_of_supply  CHAR(2),
+
+    description      TEXT NOT NULL,
+    sac              TEXT NOT NULL,
+    payment_ref      TEXT NOT NULL,
+    currency         CHAR(3) NOT NULL,
+    taxable_cents    BIGINT NOT NULL,
+    gst_rate_percent INT NOT NULL DEFAULT 0,
+    cgst_cents       BIGINT NOT NULL DEFAULT 0,
+    sgst_cents       BIGINT NOT NULL DEFAULT 0,
+    igst_cents       BIGINT NOT NULL DEFAULT 0,
+    total_cents      BIGINT NOT NULL,
+    issued_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE (kind, financial_year, sequence),
+    CHECK (taxable_cents + cgst_cents + sgst_cents + igst_cents = total_cents)
+);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS refunds_unsubmitted_idx;
+
+ALTER TABLE refunds
+    DROP COLUMN IF EXISTS last_error,
+    DROP COLUMN IF EXISTS next_attempt_at,
+    DROP COLUMN IF EXISTS attempts;
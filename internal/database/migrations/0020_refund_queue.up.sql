@@ -0,0 +1,11 @@
+-- Refunds are queued in the transaction that owes them and submitted to
+-- the gateway by a background job, which retries with backoff until the
+-- gateway accepts the refund.
+ALTER TABLE refunds
+    ADD COLUMN attempts        INT NOT NULL DEFAULT 0,
+    ADD COLUMN next_attempt_at TIMESTAMPTZ,
+    ADD COLUMN last_error      TEXT;
+
+CREATE INDEX refunds_unsubmitted_idx
+    ON refunds (next_attempt_at)
+    WHERE status = 'pending' AND gateway_refund_id IS NULL;
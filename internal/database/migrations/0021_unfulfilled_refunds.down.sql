@@ -0,0 +1,7 @@
+DELETE FROM refunds WHERE booking_id IS NULL;
+
+ALTER TABLE refunds
+    DROP CONSTRAINT IF EXISTS refunds_target_check,
+    DROP COLUMN IF EXISTS unfulfilled,
+    DROP COLUMN IF EXISTS package_purchase_id,
+    ALTER COLUMN booking_id SET NOT NULL;
@@ -0,0 +1,10 @@
+-- A capture that arrives after its booking stopped awaiting payment, or
+-- for a package purchase that is already paid, is refunded in full
+-- without fulfilling anything. Such refunds are unfulfilled: they leave
+-- the booking and the ledger alone.
+ALTER TABLE refunds
+    ALTER COLUMN booking_id DROP NOT NULL,
+    ADD COLUMN package_purchase_id UUID REFERENCES package_purchases (id),
+    ADD COLUMN unfulfilled         BOOLEAN NOT NULL DEFAULT false,
+    ADD CONSTRAINT refunds_target_check
+        CHECK (num_nonnulls(booking_id, package_purchase_id) = 1);
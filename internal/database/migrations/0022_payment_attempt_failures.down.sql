@@ -0,0 +1,3 @@
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS last_failed_at,
+    DROP COLUMN IF EXISTS last_failure;
@@ -0,0 +1,6 @@
+-- A declined try at paying an order that can still be paid, e.g. a card
+-- declined on a Stripe PaymentIntent, is recorded on the payment while
+-- its booking stays pending
+ALTER TABLE payments
+    ADD COLUMN last_failure   TEXT,
+    ADD COLUMN last_failed_at TIMESTAMPTZ;
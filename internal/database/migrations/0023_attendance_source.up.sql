@@ -0,0 +1,6 @@
+-- Which session events a settled booking's attendance was decided from:
+-- ZEGOCLOUD callbacks when there are any, otherwise what the clients
+-- reported
+ALTER TABLE bookings
+    ADD COLUMN attendance_source TEXT
+        CHECK (attendance_source IN ('client', 'zego'));
@@ -0,0 +1,4 @@
+ALTER TABLE mentor_service_packages
+    DROP CONSTRAINT IF EXISTS mentor_service_packages_price_cents_check,
+    ADD CONSTRAINT mentor_service_packages_price_cents_check
+        CHECK (price_cents >= 0);
@@ -0,0 +1,15 @@
+-- Packages are always paid for through a gateway order, which cannot be
+-- opened for nothing. Free packages already on sale are withdrawn, since
+-- they could never be bought; the constraint leaves those rows as they
+-- were and applies to every package created or edited from now on.
+UPDATE mentor_service_packages
+SET is_active = false,
+    deleted_at = NOW(),
+    updated_at = NOW()
+WHERE price_cents = 0
+  AND deleted_at IS NULL;
+
+ALTER TABLE mentor_service_packages
+    DROP CONSTRAINT IF EXISTS mentor_service_packages_price_cents_check,
+    ADD CONSTRAINT mentor_service_packages_price_cents_check
+        CHECK (price_cents > 0) NOT VALID;
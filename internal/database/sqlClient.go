@@ -4,39 +4,94 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
+	"github.com/preetsinghmakkar/OpenCall/internal/database/migrations"
 )
 
 type Config struct {
-	Driver            string
-	Host              string
-	Port              int
-	User              string
-	Password          string
-	DBName            string
+	Driver string
+
+	// URL is a full connection string (postgres://... or key=value).
+	// When set, Host, Port, User, Password and DBName are ignored.
+	URL string
+
+	Host     string
+	Port     int
+	User     string
+	Password string
+	DBName   string
+
+	// TLS settings, passed through to the driver
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	ApplicationName  string
+	StatementTimeout time.Duration
+
 	MaxOpenConns      int
 	MaxIdleConns      int
 	ConnMaxIdleTime   time.Duration
+	ConnMaxLifetime   time.Duration
 	ConnectionTimeout time.Duration
+
+	// ReplicaURL optionally points at a read replica used by read-only
+	// repository methods. It shares the TLS and pool settings above.
+	ReplicaURL string
+
+	// AutoMigrate applies pending embedded migrations after connecting
+	AutoMigrate bool
 }
 
 type SQLClient struct {
 	DB *sql.DB
+
+	// Replica is nil when no read replica is configured
+	Replica *sql.DB
 }
 
 func NewSQLClient(cfg Config) (*SQLClient, error) {
-	dbSource := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=require",
-		cfg.Host,
-		cfg.Port,
-		cfg.User,
-		cfg.Password,
-		cfg.DBName,
-	)
-
-	db, err := sql.Open(cfg.Driver, dbSource)
+	db, err := open(cfg, cfg.DSN())
+	if err != nil {
+		return nil, err
+	}
+
+	client := &SQLClient{DB: db}
+
+	if cfg.ReplicaURL != "" {
+		replica, err := open(cfg, withParams(cfg.ReplicaURL, cfg.params()))
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("read replica: %w", err)
+		}
+		client.Replica = replica
+	}
+
+	if cfg.AutoMigrate {
+		migrator, err := migrations.NewMigrator(db)
+		if err != nil {
+			client.Close()
+			return nil, err
+		}
+
+		if _, err := migrator.Up(context.Background()); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("auto-migrate: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+func open(cfg Config, dsn string) (*sql.DB, error) {
+	db, err := sql.Open(cfg.Driver, dsn)
 	if err != nil {
 		return nil, err
 	}
@@ -44,19 +99,140 @@ func NewSQLClient(cfg Config) (*SQLClient, error) {
 	db.SetMaxOpenConns(cfg.MaxOpenConns)
 	db.SetMaxIdleConns(cfg.MaxIdleConns)
 	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectionTimeout)
 	defer cancel()
 
 	// Ping the database to ensure a successful connection
 	if err := db.PingContext(ctx); err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	return &SQLClient{DB: db}, nil
+	return db, nil
+}
+
+// DSN builds the primary connection string. Options that are already
+// present in URL take precedence over the individual settings.
+func (cfg Config) DSN() string {
+	if cfg.URL != "" {
+		return withParams(cfg.URL, cfg.params())
+	}
+
+	params := map[string]string{
+		"host":     cfg.Host,
+		"port":     strconv.Itoa(cfg.Port),
+		"user":     cfg.User,
+		"password": cfg.Password,
+		"dbname":   cfg.DBName,
+	}
+	for k, v := range cfg.params() {
+		params[k] = v
+	}
+
+	return keyValueDSN(params)
+}
+
+// params returns the driver options shared by the primary and replica.
+func (cfg Config) params() map[string]string {
+	params := map[string]string{}
+
+	set := func(key, value string) {
+		if value != "" {
+			params[key] = value
+		}
+	}
+
+	set("sslmode", cfg.SSLMode)
+	set("sslrootcert", cfg.SSLRootCert)
+	set("sslcert", cfg.SSLCert)
+	set("sslkey", cfg.SSLKey)
+	set("application_name", cfg.ApplicationName)
+
+	if cfg.StatementTimeout > 0 {
+		// unknown keys are sent to the server as run-time parameters
+		params["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+
+	if cfg.ConnectionTimeout > 0 {
+		params["connect_timeout"] = strconv.Itoa(int(cfg.ConnectionTimeout.Seconds()))
+	}
+
+	return params
+}
+
+// withParams adds params to a URL or key=value DSN without overriding
+// options the DSN already sets.
+func withParams(dsn string, params map[string]string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return dsn
+		}
+
+		q := u.Query()
+		for k, v := range params {
+			if q.Get(k) == "" {
+				q.Set(k, v)
+			}
+		}
+		u.RawQuery = q.Encode()
+
+		return u.String()
+	}
+
+	var extra []string
+	for _, k := range sortedKeys(params) {
+		if !strings.Contains(dsn, k+"=") {
+			extra = append(extra, k+"="+quote(params[k]))
+		}
+	}
+
+	if len(extra) == 0 {
+		return dsn
+	}
+
+	return strings.TrimSpace(dsn + " " + strings.Join(extra, " "))
+}
+
+func keyValueDSN(params map[string]string) string {
+	parts := make([]string, 0, len(params))
+	for _, k := range sortedKeys(params) {
+		parts = append(parts, k+"="+quote(params[k]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// quote escapes a libpq key=value option value.
+func quote(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Reader returns the pool for read-only queries: the replica when one is
+// configured, otherwise the primary.
+func (c *SQLClient) Reader() *sql.DB {
+	if c.Replica != nil {
+		return c.Replica
+	}
+	return c.DB
 }
 
 func (c *SQLClient) Close() error {
+	if c.Replica != nil {
+		c.Replica.Close()
+	}
 	if c.DB != nil {
 		return c.DB.Close()
 	}
@@ -0,0 +1,24 @@
+package dtos
+
+import "github.com/google/uuid"
+
+// CreateAvailabilityOverrideRequest changes the schedule on one date in
+// the mentor's timezone. Blackouts take no times; blocks and extra windows
+// need both.
+type CreateAvailabilityOverrideRequest struct {
+	Date      string `json:"date" binding:"required"` // YYYY-MM-DD
+	Kind      string `json:"kind" binding:"required,oneof=blackout block extra"`
+	StartTime string `json:"start_time"` // HH:MM
+	EndTime   string `json:"end_time"`   // HH:MM
+	Reason    string `json:"reason" binding:"max=200"`
+}
+
+type AvailabilityOverrideResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Date      string    `json:"date"`
+	Kind      string    `json:"kind"`
+	StartTime string    `json:"start_time,omitempty"`
+	EndTime   string    `json:"end_time,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	Timezone  string    `json:"timezone"` // zone the date and times are in
+}
@@ -1,11 +1,28 @@
 package dtos
 
+import "time"
+
 type AvailableSlot struct {
-	Start string `json:"start"`
-	End   string `json:"end"`
+	Start   string    `json:"start"` // HH:MM in the response timezone
+	End     string    `json:"end"`   // HH:MM in the response timezone
+	StartAt time.Time `json:"start_at"`
+	EndAt   time.Time `json:"end_at"`
 }
 
 type AvailabilityResponse struct {
-	Date  string          `json:"date"`
+	Date     string          `json:"date"`
+	Timezone string          `json:"timezone"`
+	Slots    []AvailableSlot `json:"slots"`
+}
+
+type SlotCalendarDay struct {
+	Date  string          `json:"date"` // YYYY-MM-DD in the response timezone
 	Slots []AvailableSlot `json:"slots"`
 }
+
+type SlotCalendarResponse struct {
+	From     string            `json:"from"`
+	To       string            `json:"to"`
+	Timezone string            `json:"timezone"`
+	Days     []SlotCalendarDay `json:"days"`
+}
@@ -0,0 +1,39 @@
+package dtos
+
+import "github.com/google/uuid"
+
+// --------------------
+// CANCEL BOOKING
+// --------------------
+
+type CancelBookingRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+type CancelBookingResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Status      string    `json:"status"`
+	RefundType  string    `json:"refund_type"` // full | partial | none
+	RefundCents int       `json:"refund_cents"`
+	Currency    string    `json:"currency"`
+
+	// pending | processed | failed, empty when nothing is refunded
+	RefundStatus string `json:"refund_status,omitempty"`
+
+	// Set when the booking was paid with a session credit that the
+	// mentee got back
+	CreditReturned bool `json:"credit_returned,omitempty"`
+}
+
+// --------------------
+// RESCHEDULE BOOKING
+// --------------------
+
+type RescheduleBookingRequest struct {
+	BookingDate string `json:"booking_date" binding:"required"` // YYYY-MM-DD
+	StartTime   string `json:"start_time" binding:"required"`   // HH:MM
+
+	// Timezone the date and start time are expressed in. Defaults to the
+	// mentor's timezone when empty.
+	Timezone string `json:"timezone"`
+}
@@ -0,0 +1,54 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateCouponRequest issues a discount code. Percent coupons take
+// percent_off off the price; fixed coupons take amount_off_cents off
+// prices in currency, which defaults to the service's when service_id is
+// set. Limits of 0 are unlimited.
+type CreateCouponRequest struct {
+	Code         string     `json:"code" binding:"required,min=3,max=32"`
+	ServiceID    *uuid.UUID `json:"service_id"`
+	DiscountType string     `json:"discount_type" binding:"required,oneof=percent fixed"`
+
+	PercentOff     int    `json:"percent_off" binding:"min=0,max=100"`
+	AmountOffCents int    `json:"amount_off_cents" binding:"min=0"`
+	Currency       string `json:"currency" binding:"omitempty,len=3"`
+
+	MaxRedemptions        int `json:"max_redemptions" binding:"min=0"`
+	MaxRedemptionsPerUser int `json:"max_redemptions_per_user" binding:"min=0"`
+
+	StartsAt  *time.Time `json:"starts_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type SetCouponActiveRequest struct {
+	IsActive *bool `json:"is_active" binding:"required"`
+}
+
+type CouponResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	Code         string     `json:"code"`
+	Platform     bool       `json:"platform"`
+	ServiceID    *uuid.UUID `json:"service_id,omitempty"`
+	DiscountType string     `json:"discount_type"`
+
+	PercentOff     int     `json:"percent_off,omitempty"`
+	AmountOffCents int     `json:"amount_off_cents,omitempty"`
+	Currency       *string `json:"currency,omitempty"`
+
+	MaxRedemptions        int `json:"max_redemptions"`
+	MaxRedemptionsPerUser int `json:"max_redemptions_per_user"`
+	// Redemptions counts bookings holding the coupon; cancelled, expired
+	// and unpaid bookings give theirs back
+	Redemptions int `json:"redemptions"`
+
+	StartsAt  *time.Time `json:"starts_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	IsActive  bool       `json:"is_active"`
+	CreatedAt time.Time  `json:"created_at"`
+}
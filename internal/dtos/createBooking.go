@@ -1,6 +1,10 @@
 package dtos
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // --------------------
 // CREATE BOOKING
@@ -11,6 +15,13 @@ type CreateBookingRequest struct {
 	ServiceID   uuid.UUID `json:"service_id" binding:"required"`
 	BookingDate string    `json:"booking_date" binding:"required"` // YYYY-MM-DD
 	StartTime   string    `json:"start_time" binding:"required"`   // HH:MM
+
+	// Timezone the date and start time are expressed in. Defaults to the
+	// mentor's timezone when empty.
+	Timezone string `json:"timezone"`
+
+	// Optional discount code, matched case-insensitively
+	CouponCode string `json:"coupon_code" binding:"omitempty,max=32"`
 }
 
 // --------------------
@@ -23,6 +34,17 @@ type BookingResponse struct {
 	Date      string    `json:"date"`
 	StartTime string    `json:"start_time"`
 	EndTime   string    `json:"end_time"`
+	StartAt   time.Time `json:"start_at"`
+	EndAt     time.Time `json:"end_at"`
+	Timezone  string    `json:"timezone"`
 	Price     int       `json:"price_cents"`
 	Currency  string    `json:"currency"`
+
+	// The booking redeemed a package credit and needs no payment
+	PaidWithCredit bool `json:"paid_with_credit,omitempty"`
+
+	// Set when a coupon was applied: the service's price and the discount
+	// taken off it to give price_cents
+	ListPrice int `json:"list_price_cents,omitempty"`
+	Discount  int `json:"discount_cents,omitempty"`
 }
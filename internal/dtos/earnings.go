@@ -0,0 +1,46 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EarningsBalance is what the platform owes a mentor in one currency.
+// Pending money is still inside the dispute window.
+type EarningsBalance struct {
+	Currency            string `json:"currency"`
+	PendingCents        int64  `json:"pending_cents"`
+	AvailableCents      int64  `json:"available_cents"`
+	LifetimeEarnedCents int64  `json:"lifetime_earned_cents"`
+	PaidOutCents        int64  `json:"paid_out_cents"`
+}
+
+// EarningsStatement sums one month of a mentor's ledger in one currency.
+type EarningsStatement struct {
+	Month           string `json:"month"` // YYYY-MM in the mentor's timezone
+	Currency        string `json:"currency"`
+	GrossCents      int64  `json:"gross_cents"`
+	CommissionCents int64  `json:"commission_cents"`
+	RefundCents     int64  `json:"refund_cents"`
+	NetCents        int64  `json:"net_cents"`
+	PaidOutCents    int64  `json:"paid_out_cents"`
+}
+
+type EarningsResponse struct {
+	Timezone   string              `json:"timezone"`
+	Balances   []EarningsBalance   `json:"balances"`
+	Statements []EarningsStatement `json:"statements"`
+}
+
+// EarningsEntry is one movement on a mentor's payable account, as
+// exported to CSV.
+type EarningsEntry struct {
+	CreatedAt   time.Time
+	Kind        string
+	BookingID   *uuid.UUID
+	Direction   string
+	AmountCents int64
+	Currency    string
+	AvailableAt time.Time
+}
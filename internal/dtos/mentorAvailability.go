@@ -4,6 +4,7 @@ import (
 	"github.com/google/uuid"
 )
 
+// Start and end are wall-clock times in the mentor's profile timezone
 type CreateMentorAvailabilityRequest struct {
 	DayOfWeek int    `json:"day_of_week" binding:"min=0,max=6"`
 	StartTime string `json:"start_time" binding:"required"` // "10:00"
@@ -15,4 +16,11 @@ type MentorAvailabilityResponse struct {
 	DayOfWeek int       `json:"day_of_week"`
 	StartTime string    `json:"start_time"`
 	EndTime   string    `json:"end_time"`
+	Timezone  string    `json:"timezone"` // zone the wall-clock times are in
+}
+
+// ReplaceMentorAvailabilityRequest is the complete weekly schedule. An
+// empty list clears it.
+type ReplaceMentorAvailabilityRequest struct {
+	Rules []CreateMentorAvailabilityRequest `json:"rules" binding:"required,dive"`
 }
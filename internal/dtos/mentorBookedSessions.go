@@ -1,14 +1,24 @@
 package dtos
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type MentorBookedSessionResponse struct {
 	ID           uuid.UUID `json:"id"`
 	UserUsername string    `json:"user_username"`
 	ServiceTitle string    `json:"service_title"`
-	BookingDate  string    `json:"booking_date"`
-	StartTime    string    `json:"start_time"`
-	EndTime      string    `json:"end_time"`
+	BookingDate  string    `json:"booking_date"` // mentor's local date
+	StartTime    string    `json:"start_time"`   // mentor's local HH:MM
+	EndTime      string    `json:"end_time"`     // mentor's local HH:MM
+	StartAt      time.Time `json:"start_at"`
+	EndAt        time.Time `json:"end_at"`
+	Timezone     string    `json:"timezone"` // mentor's timezone
+	Status       string    `json:"status"`
 	PriceCents   int       `json:"price_cents"`
 	Currency     string    `json:"currency"`
+
+	Attendance SessionAttendance `json:"attendance"`
 }
@@ -10,15 +10,23 @@ type CreateMentorProfileRequest struct {
 	Title    string `json:"title" binding:"required,min=3"`
 	Bio      string `json:"bio"`
 	Timezone string `json:"timezone" binding:"required"`
+
+	// Optional cancellation policy, defaults are applied when omitted
+	CancellationWindowHours       *int `json:"cancellation_window_hours" binding:"omitempty,min=0,max=168"`
+	LateCancellationRefundPercent *int `json:"late_cancellation_refund_percent" binding:"omitempty,min=0,max=100"`
 }
 
 type CreateMentorProfileResponse struct {
-	ID        uuid.UUID `json:"id"`
-	UserID    uuid.UUID `json:"user_id"`
-	Title     string    `json:"title"`
-	Bio       string    `json:"bio"`
-	Timezone  string    `json:"timezone"`
-	IsActive  bool      `json:"is_active"`
+	ID       uuid.UUID `json:"id"`
+	UserID   uuid.UUID `json:"user_id"`
+	Title    string    `json:"title"`
+	Bio      string    `json:"bio"`
+	Timezone string    `json:"timezone"`
+	IsActive bool      `json:"is_active"`
+
+	CancellationWindowHours       int `json:"cancellation_window_hours"`
+	LateCancellationRefundPercent int `json:"late_cancellation_refund_percent"`
+
 	CreatedAt time.Time `json:"created_at"`
 }
 type MentorProfileResponse struct {
@@ -40,4 +48,7 @@ type MentorInfo struct {
 	Bio      string    `json:"bio"`
 	Timezone string    `json:"timezone"`
 	IsActive bool      `json:"is_active"`
+
+	CancellationWindowHours       int `json:"cancellation_window_hours"`
+	LateCancellationRefundPercent int `json:"late_cancellation_refund_percent"`
 }
@@ -5,9 +5,52 @@ import "github.com/google/uuid"
 type CreateMentorServiceRequest struct {
 	Title           string `json:"title" binding:"required,min=3"`
 	Description     string `json:"description"`
-	DurationMinutes int    `json:"duration_minutes" binding:"required,oneof=30 60"`
-	PriceCents      int    `json:"price_cents" binding:"required,min=0"`
+	DurationMinutes int    `json:"duration_minutes" binding:"required,min=15,max=240"` // multiple of 15
+	PriceCents      *int   `json:"price_cents" binding:"required,min=0"`
 	Currency        string `json:"currency" binding:"required,len=3"`
+
+	// Optional scheduling settings, all in minutes. 0 leaves a setting off;
+	// slot_step_minutes 0 steps by the duration.
+	BufferBeforeMinutes int `json:"buffer_before_minutes" binding:"min=0,max=240"`
+	BufferAfterMinutes  int `json:"buffer_after_minutes" binding:"min=0,max=240"`
+	SlotStepMinutes     int `json:"slot_step_minutes" binding:"omitempty,min=5,max=240"`
+	MaxSessionsPerDay   int `json:"max_sessions_per_day" binding:"min=0,max=48"`
+	MinGapMinutes       int `json:"min_gap_minutes" binding:"min=0,max=240"`
+
+	// Free services (price_cents 0) only: bookings one mentee may hold,
+	// 0 for no cap
+	FreeBookingsPerMentee int `json:"free_bookings_per_mentee" binding:"min=0,max=100"`
+}
+
+// UpdateMentorServiceRequest replaces the editable fields of a service.
+// Existing bookings keep the price and duration they were booked with.
+type UpdateMentorServiceRequest struct {
+	Title           string `json:"title" binding:"required,min=3"`
+	Description     string `json:"description"`
+	DurationMinutes int    `json:"duration_minutes" binding:"required,min=15,max=240"` // multiple of 15
+	PriceCents      *int   `json:"price_cents" binding:"required,min=0"`
+	Currency        string `json:"currency" binding:"required,len=3"`
+
+	// Optional scheduling settings, all in minutes. 0 leaves a setting off;
+	// slot_step_minutes 0 steps by the duration.
+	BufferBeforeMinutes int `json:"buffer_before_minutes" binding:"min=0,max=240"`
+	BufferAfterMinutes  int `json:"buffer_after_minutes" binding:"min=0,max=240"`
+	SlotStepMinutes     int `json:"slot_step_minutes" binding:"omitempty,min=5,max=240"`
+	MaxSessionsPerDay   int `json:"max_sessions_per_day" binding:"min=0,max=48"`
+	MinGapMinutes       int `json:"min_gap_minutes" binding:"min=0,max=240"`
+
+	// Free services (price_cents 0) only: bookings one mentee may hold,
+	// 0 for no cap
+	FreeBookingsPerMentee int `json:"free_bookings_per_mentee" binding:"min=0,max=100"`
+}
+
+type SetMentorServiceActiveRequest struct {
+	IsActive *bool `json:"is_active" binding:"required"`
+}
+
+// ReorderMentorServicesRequest lists every service id in display order
+type ReorderMentorServicesRequest struct {
+	ServiceIDs []uuid.UUID `json:"service_ids" binding:"required,min=1"`
 }
 
 type MentorServiceResponse struct {
@@ -18,4 +61,13 @@ type MentorServiceResponse struct {
 	PriceCents      int       `json:"price_cents"`
 	Currency        string    `json:"currency"`
 	IsActive        bool      `json:"is_active"`
+	SortOrder       int       `json:"sort_order"`
+
+	BufferBeforeMinutes int `json:"buffer_before_minutes"`
+	BufferAfterMinutes  int `json:"buffer_after_minutes"`
+	SlotStepMinutes     int `json:"slot_step_minutes"`
+	MaxSessionsPerDay   int `json:"max_sessions_per_day"`
+	MinGapMinutes       int `json:"min_gap_minutes"`
+
+	FreeBookingsPerMentee int `json:"free_bookings_per_mentee"`
 }
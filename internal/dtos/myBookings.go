@@ -1,15 +1,24 @@
 package dtos
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type MyBookingResponse struct {
 	ID        uuid.UUID `json:"id"`
 	Mentor    string    `json:"mentor"` // username
 	Service   string    `json:"service"`
-	Date      string    `json:"date"`
-	StartTime string    `json:"start_time"`
-	EndTime   string    `json:"end_time"`
+	Date      string    `json:"date"`       // mentor's local date
+	StartTime string    `json:"start_time"` // mentor's local HH:MM
+	EndTime   string    `json:"end_time"`   // mentor's local HH:MM
+	StartAt   time.Time `json:"start_at"`
+	EndAt     time.Time `json:"end_at"`
+	Timezone  string    `json:"timezone"` // mentor's timezone
 	Status    string    `json:"status"`
 	Price     int       `json:"price_cents"`
 	Currency  string    `json:"currency"`
+
+	Attendance SessionAttendance `json:"attendance"`
 }
@@ -1,22 +1,49 @@
 package dtos
 
-import "github.com/google/uuid"
+import (
+	"time"
 
-// Step 1: create Razorpay order
+	"github.com/google/uuid"
+)
+
+// Step 1: create a gateway order. BillingState is the mentee's GST state
+// code, e.g. "29"; it decides the tax on the invoice.
 type CreatePaymentRequest struct {
-	BookingID uuid.UUID `json:"booking_id" binding:"required"`
+	BookingID    uuid.UUID `json:"booking_id" binding:"required"`
+	BillingState string    `json:"billing_state" binding:"omitempty,len=2,numeric"`
 }
 
+// CreatePaymentResponse is paid with Razorpay Checkout when Gateway is
+// "razorpay", or confirmed with Stripe.js using ClientSecret when it is
+// "stripe". RazorpayOrderID repeats GatewayOrderID for older clients.
 type CreatePaymentResponse struct {
 	PaymentID       uuid.UUID `json:"payment_id"`
+	Gateway         string    `json:"gateway"`
+	GatewayOrderID  string    `json:"gateway_order_id"`
 	RazorpayOrderID string    `json:"razorpay_order_id"`
+	ClientSecret    string    `json:"client_secret,omitempty"`
 	Amount          int64     `json:"amount"`
 	Currency        string    `json:"currency"`
 }
 
-// Step 2: verify payment
+// Step 2: verify payment. The Razorpay fields are required for Razorpay
+// payments only.
 type VerifyPaymentRequest struct {
 	PaymentID         uuid.UUID `json:"payment_id" binding:"required"`
-	RazorpayPaymentID string    `json:"razorpay_payment_id" binding:"required"`
-	RazorpaySignature string    `json:"razorpay_signature" binding:"required"`
+	RazorpayPaymentID string    `json:"razorpay_payment_id"`
+	RazorpaySignature string    `json:"razorpay_signature"`
+}
+
+// PaymentMismatchResponse is a payment captured at the gateway for a
+// different amount or currency than we expected
+type PaymentMismatchResponse struct {
+	ID               uuid.UUID `json:"id"`
+	PaymentID        uuid.UUID `json:"payment_id"`
+	Gateway          string    `json:"gateway"`
+	GatewayPaymentID string    `json:"gateway_payment_id"`
+	ExpectedAmount   int64     `json:"expected_amount"`
+	ExpectedCurrency string    `json:"expected_currency"`
+	ActualAmount     int64     `json:"actual_amount"`
+	ActualCurrency   string    `json:"actual_currency"`
+	DetectedAt       time.Time `json:"detected_at"`
 }
@@ -0,0 +1,35 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UpsertPayoutAccountRequest sets where the mentor is paid: a bank
+// account (account_number and ifsc) or a UPI ID (vpa).
+type UpsertPayoutAccountRequest struct {
+	Method            string `json:"method" binding:"required,oneof=bank_account vpa"`
+	AccountHolderName string `json:"account_holder_name" binding:"required"`
+	AccountNumber     string `json:"account_number"`
+	IFSC              string `json:"ifsc"`
+	VPA               string `json:"vpa"`
+}
+
+// PayoutAccountResponse never contains the full account details.
+type PayoutAccountResponse struct {
+	Method      string    `json:"method"`
+	DisplayHint string    `json:"display_hint"`
+	Verified    bool      `json:"verified"` // registered with the payout provider
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type PayoutResponse struct {
+	ID            uuid.UUID `json:"id"`
+	AmountCents   int64     `json:"amount_cents"`
+	Currency      string    `json:"currency"`
+	Status        string    `json:"status"`
+	FailureReason *string   `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
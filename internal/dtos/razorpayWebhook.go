@@ -5,11 +5,23 @@ type RazorpayWebhookEvent struct {
 	Payload struct {
 		Payment struct {
 			Entity struct {
-				ID      string `json:"id"`
-				OrderID string `json:"order_id"`
-				Status  string `json:"status"`
-				Amount  int64  `json:"amount"`
+				ID       string `json:"id"`
+				OrderID  string `json:"order_id"`
+				Status   string `json:"status"`
+				Amount   int64  `json:"amount"`
+				Currency string `json:"currency"`
+				// ErrorDescription explains a failed payment
+				ErrorDescription string `json:"error_description"`
 			} `json:"entity"`
 		} `json:"payment"`
+		Refund struct {
+			Entity struct {
+				ID        string `json:"id"`
+				PaymentID string `json:"payment_id"`
+				Status    string `json:"status"`
+				Amount    int64  `json:"amount"`
+				Receipt   string `json:"receipt"` // our refunds.id
+			} `json:"entity"`
+		} `json:"refund"`
 	} `json:"payload"`
 }
@@ -0,0 +1,46 @@
+package dtos
+
+import "github.com/google/uuid"
+
+// CreateServicePackageRequest bundles sessions of one of the mentor's
+// services. The currency is the service's.
+type CreateServicePackageRequest struct {
+	ServiceID    uuid.UUID `json:"service_id" binding:"required"`
+	Title        string    `json:"title" binding:"required,min=3"`
+	SessionCount int       `json:"session_count" binding:"required,min=2,max=50"`
+	PriceCents   int       `json:"price_cents" binding:"required,min=1"`
+}
+
+type ServicePackageResponse struct {
+	ID              uuid.UUID `json:"id"`
+	ServiceID       uuid.UUID `json:"service_id"`
+	Title           string    `json:"title"`
+	SessionCount    int       `json:"session_count"`
+	DurationMinutes int       `json:"duration_minutes"` // per session
+	PriceCents      int       `json:"price_cents"`
+	Currency        string    `json:"currency"`
+	IsActive        bool      `json:"is_active"`
+}
+
+// PackagePurchaseResponse carries the gateway order the mentee pays to
+// receive the package's credits.
+type PackagePurchaseResponse struct {
+	PurchaseID      uuid.UUID `json:"purchase_id"`
+	PaymentID       uuid.UUID `json:"payment_id"`
+	Gateway         string    `json:"gateway"`
+	GatewayOrderID  string    `json:"gateway_order_id"`
+	RazorpayOrderID string    `json:"razorpay_order_id"`
+	ClientSecret    string    `json:"client_secret,omitempty"`
+	Amount          int64     `json:"amount"`
+	Currency        string    `json:"currency"`
+	SessionCount    int       `json:"session_count"`
+}
+
+// SessionCreditBalance counts a mentee's prepaid sessions of one service
+type SessionCreditBalance struct {
+	ServiceID    uuid.UUID `json:"service_id"`
+	ServiceTitle string    `json:"service_title"`
+	Mentor       string    `json:"mentor"` // username
+	Available    int       `json:"available"`
+	Redeemed     int       `json:"redeemed"`
+}
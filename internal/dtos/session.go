@@ -0,0 +1,41 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ZegoTokenRequest asks for a token to join the video room of a booking.
+// The user, display name and room are derived server-side.
+type ZegoTokenRequest struct {
+	BookingID uuid.UUID `json:"booking_id" binding:"required"`
+}
+
+type ZegoTokenResponse struct {
+	Token     string    `json:"token"`
+	AppID     int64     `json:"app_id"`
+	RoomID    string    `json:"room_id"`
+	UserID    string    `json:"user_id"`
+	UserName  string    `json:"user_name"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SessionEventRequest is reported by the client when the caller enters
+// or leaves a booking's video room.
+type SessionEventRequest struct {
+	Event string `json:"event" binding:"required,oneof=joined left"`
+}
+
+// SessionInfoResponse tells a participant whether they can join a
+// booking's video session right now.
+type SessionInfoResponse struct {
+	BookingID uuid.UUID `json:"booking_id"`
+	AppID     int64     `json:"app_id"`
+	RoomID    string    `json:"room_id"`
+	Role      string    `json:"role"` // mentee | mentor
+	StartAt   time.Time `json:"start_at"`
+	EndAt     time.Time `json:"end_at"`
+	CanJoin   bool      `json:"can_join"`
+	Message   string    `json:"message,omitempty"`
+}
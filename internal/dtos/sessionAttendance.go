@@ -0,0 +1,12 @@
+package dtos
+
+import "time"
+
+// SessionAttendance is when each participant first joined and last left
+// a booking's video session. Nil means no such event was recorded.
+type SessionAttendance struct {
+	MenteeJoinedAt *time.Time `json:"mentee_joined_at"`
+	MenteeLeftAt   *time.Time `json:"mentee_left_at"`
+	MentorJoinedAt *time.Time `json:"mentor_joined_at"`
+	MentorLeftAt   *time.Time `json:"mentor_left_at"`
+}
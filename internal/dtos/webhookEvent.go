@@ -0,0 +1,30 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookEventResponse summarises an inbox event for the admin list
+type WebhookEventResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	Gateway        string     `json:"gateway"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	SignatureValid bool       `json:"signature_valid"`
+	SecretID       *string    `json:"secret_id,omitempty"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	LastError      *string    `json:"last_error,omitempty"`
+	ReceivedAt     time.Time  `json:"received_at"`
+	ProcessedAt    *time.Time `json:"processed_at,omitempty"`
+}
+
+// WebhookEventDetail adds the raw payload and signature header
+type WebhookEventDetail struct {
+	WebhookEventResponse
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
@@ -0,0 +1,54 @@
+package dtos
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// ZegoCallback is the JSON body of a ZEGOCLOUD room callback. Only the
+// fields of the room_create, room_login, room_logout and room_close
+// events that we use are listed.
+type ZegoCallback struct {
+	Event     string    `json:"event"`
+	AppID     ZegoValue `json:"appid"`
+	Timestamp ZegoValue `json:"timestamp"` // unix seconds
+	Nonce     ZegoValue `json:"nonce"`
+	Signature string    `json:"signature"`
+
+	RoomID        string    `json:"room_id"`
+	RoomSessionID ZegoValue `json:"room_session_id"`
+	UserAccount   string    `json:"user_account"` // our user ID
+
+	// Event times in unix milliseconds; absent for some events
+	CreateTime ZegoValue `json:"create_time"`
+	LoginTime  ZegoValue `json:"login_time"`
+	LogoutTime ZegoValue `json:"logout_time"`
+	CloseTime  ZegoValue `json:"close_time"`
+}
+
+// ZegoValue holds a callback field that ZEGOCLOUD may send either as a
+// JSON string or as a number. Its string form is what gets signed.
+type ZegoValue string
+
+func (v *ZegoValue) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*v = ""
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*v = ZegoValue(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*v = ZegoValue(n.String())
+	return nil
+}
@@ -0,0 +1,51 @@
+package errors
+
+import "net/http"
+
+func AvailabilityRuleNotFound() *AppError {
+	return &AppError{
+		Code:    "AVAILABILITY_RULE_NOT_FOUND",
+		Message: "availability rule not found",
+		Status:  http.StatusNotFound,
+	}
+}
+
+func AvailabilityRuleForbidden() *AppError {
+	return &AppError{
+		Code:    "AVAILABILITY_RULE_FORBIDDEN",
+		Message: "availability rule belongs to another mentor",
+		Status:  http.StatusForbidden,
+	}
+}
+
+func AvailabilityOverlap() *AppError {
+	return &AppError{
+		Code:    "AVAILABILITY_OVERLAP",
+		Message: "availability overlaps an existing rule on the same day",
+		Status:  http.StatusConflict,
+	}
+}
+
+func InvalidAvailability(message string) *AppError {
+	return &AppError{
+		Code:    "INVALID_AVAILABILITY",
+		Message: message,
+		Status:  http.StatusBadRequest,
+	}
+}
+
+func AvailabilityOverrideNotFound() *AppError {
+	return &AppError{
+		Code:    "AVAILABILITY_OVERRIDE_NOT_FOUND",
+		Message: "availability override not found",
+		Status:  http.StatusNotFound,
+	}
+}
+
+func AvailabilityOverrideForbidden() *AppError {
+	return &AppError{
+		Code:    "AVAILABILITY_OVERRIDE_FORBIDDEN",
+		Message: "availability override belongs to another mentor",
+		Status:  http.StatusForbidden,
+	}
+}
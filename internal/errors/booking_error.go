@@ -0,0 +1,51 @@
+package errors
+
+import "net/http"
+
+func BookingNotFound() *AppError {
+	return &AppError{
+		Code:    "BOOKING_NOT_FOUND",
+		Message: "booking not found",
+		Status:  http.StatusNotFound,
+	}
+}
+
+func BookingForbidden() *AppError {
+	return &AppError{
+		Code:    "BOOKING_FORBIDDEN",
+		Message: "you are not a participant of this booking",
+		Status:  http.StatusForbidden,
+	}
+}
+
+func BookingNotModifiable() *AppError {
+	return &AppError{
+		Code:    "BOOKING_NOT_MODIFIABLE",
+		Message: "booking can no longer be changed",
+		Status:  http.StatusConflict,
+	}
+}
+
+func RescheduleWindowClosed() *AppError {
+	return &AppError{
+		Code:    "RESCHEDULE_WINDOW_CLOSED",
+		Message: "booking is inside the mentor's cancellation window and can no longer be rescheduled",
+		Status:  http.StatusConflict,
+	}
+}
+
+func SlotAlreadyBooked() *AppError {
+	return &AppError{
+		Code:    "SLOT_ALREADY_BOOKED",
+		Message: "slot already booked",
+		Status:  http.StatusConflict,
+	}
+}
+
+func InvalidSlot(message string) *AppError {
+	return &AppError{
+		Code:    "INVALID_SLOT",
+		Message: message,
+		Status:  http.StatusBadRequest,
+	}
+}
@@ -0,0 +1,35 @@
+package errors
+
+import "net/http"
+
+func CouponNotFound() *AppError {
+	return &AppError{
+		Code:    "COUPON_NOT_FOUND",
+		Message: "coupon not found",
+		Status:  http.StatusNotFound,
+	}
+}
+
+func CouponForbidden() *AppError {
+	return &AppError{
+		Code:    "COUPON_FORBIDDEN",
+		Message: "coupon belongs to another mentor",
+		Status:  http.StatusForbidden,
+	}
+}
+
+func CouponCodeTaken() *AppError {
+	return &AppError{
+		Code:    "COUPON_CODE_TAKEN",
+		Message: "coupon code already exists",
+		Status:  http.StatusConflict,
+	}
+}
+
+func InvalidCoupon(message string) *AppError {
+	return &AppError{
+		Code:    "INVALID_COUPON",
+		Message: message,
+		Status:  http.StatusBadRequest,
+	}
+}
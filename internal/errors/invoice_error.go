@@ -0,0 +1,19 @@
+package errors
+
+import "net/http"
+
+func InvoiceNotFound() *AppError {
+	return &AppError{
+		Code:    "INVOICE_NOT_FOUND",
+		Message: "no invoice has been issued for this booking",
+		Status:  http.StatusNotFound,
+	}
+}
+
+func InvoiceForbidden() *AppError {
+	return &AppError{
+		Code:    "INVOICE_FORBIDDEN",
+		Message: "invoice belongs to another booking party",
+		Status:  http.StatusForbidden,
+	}
+}
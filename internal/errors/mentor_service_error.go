@@ -0,0 +1,43 @@
+package errors
+
+import "net/http"
+
+func MentorProfileRequired() *AppError {
+	return &AppError{
+		Code:    "MENTOR_PROFILE_REQUIRED",
+		Message: "mentor profile not found",
+		Status:  http.StatusForbidden,
+	}
+}
+
+func MentorServiceNotFound() *AppError {
+	return &AppError{
+		Code:    "MENTOR_SERVICE_NOT_FOUND",
+		Message: "service not found",
+		Status:  http.StatusNotFound,
+	}
+}
+
+func MentorServiceForbidden() *AppError {
+	return &AppError{
+		Code:    "MENTOR_SERVICE_FORBIDDEN",
+		Message: "service belongs to another mentor",
+		Status:  http.StatusForbidden,
+	}
+}
+
+func InvalidServiceOrder(message string) *AppError {
+	return &AppError{
+		Code:    "INVALID_SERVICE_ORDER",
+		Message: message,
+		Status:  http.StatusBadRequest,
+	}
+}
+
+func InvalidServiceDuration() *AppError {
+	return &AppError{
+		Code:    "INVALID_SERVICE_DURATION",
+		Message: "duration must be a multiple of 15 minutes, up to 4 hours",
+		Status:  http.StatusBadRequest,
+	}
+}
@@ -0,0 +1,27 @@
+package errors
+
+import "net/http"
+
+func ServicePackageNotFound() *AppError {
+	return &AppError{
+		Code:    "SERVICE_PACKAGE_NOT_FOUND",
+		Message: "package not found",
+		Status:  http.StatusNotFound,
+	}
+}
+
+func ServicePackageForbidden() *AppError {
+	return &AppError{
+		Code:    "SERVICE_PACKAGE_FORBIDDEN",
+		Message: "package belongs to another mentor",
+		Status:  http.StatusForbidden,
+	}
+}
+
+func OwnPackagePurchase() *AppError {
+	return &AppError{
+		Code:    "OWN_PACKAGE_PURCHASE",
+		Message: "cannot buy your own package",
+		Status:  http.StatusBadRequest,
+	}
+}
@@ -0,0 +1,11 @@
+package errors
+
+import "net/http"
+
+func CurrencyNotPayable() *AppError {
+	return &AppError{
+		Code:    "CURRENCY_NOT_PAYABLE",
+		Message: "payments in this currency are not accepted",
+		Status:  http.StatusUnprocessableEntity,
+	}
+}
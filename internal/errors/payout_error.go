@@ -0,0 +1,27 @@
+package errors
+
+import "net/http"
+
+func PayoutsNotConfigured() *AppError {
+	return &AppError{
+		Code:    "PAYOUTS_NOT_CONFIGURED",
+		Message: "payouts are not enabled on this server",
+		Status:  http.StatusServiceUnavailable,
+	}
+}
+
+func PayoutAccountNotFound() *AppError {
+	return &AppError{
+		Code:    "PAYOUT_ACCOUNT_NOT_FOUND",
+		Message: "no payout account registered",
+		Status:  http.StatusNotFound,
+	}
+}
+
+func InvalidPayoutAccount(message string) *AppError {
+	return &AppError{
+		Code:    "INVALID_PAYOUT_ACCOUNT",
+		Message: message,
+		Status:  http.StatusBadRequest,
+	}
+}
@@ -0,0 +1,11 @@
+package errors
+
+import "net/http"
+
+func SessionNotJoinable(message string) *AppError {
+	return &AppError{
+		Code:    "SESSION_NOT_JOINABLE",
+		Message: message,
+		Status:  http.StatusConflict,
+	}
+}
@@ -0,0 +1,27 @@
+package errors
+
+import "net/http"
+
+func WebhookEventNotFound() *AppError {
+	return &AppError{
+		Code:    "WEBHOOK_EVENT_NOT_FOUND",
+		Message: "webhook event not found",
+		Status:  http.StatusNotFound,
+	}
+}
+
+func WebhookEventNotReplayable() *AppError {
+	return &AppError{
+		Code:    "WEBHOOK_EVENT_NOT_REPLAYABLE",
+		Message: "only failed or processed webhook events can be replayed",
+		Status:  http.StatusConflict,
+	}
+}
+
+func InvalidWebhookEventFilter() *AppError {
+	return &AppError{
+		Code:    "INVALID_WEBHOOK_EVENT_FILTER",
+		Message: "unknown webhook event status",
+		Status:  http.StatusBadRequest,
+	}
+}
@@ -103,3 +103,74 @@ func (h *BookingHandler) GetMentorBookedSessions(c *gin.Context) {
 
 	c.JSON(http.StatusOK, sessions)
 }
+
+// CancelBooking cancels a booking for its mentee or mentor
+// POST /api/bookings/:id/cancel
+func (h *BookingHandler) CancelBooking(c *gin.Context) {
+	var req dtos.CancelBookingRequest
+
+	// the body is optional, an empty body cancels without a reason
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid booking id"})
+		return
+	}
+
+	resp, appErr := h.bookingService.CancelBooking(userID, bookingID, &req)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RescheduleBooking moves a booking to another available slot
+// POST /api/bookings/:id/reschedule
+func (h *BookingHandler) RescheduleBooking(c *gin.Context) {
+	var req dtos.RescheduleBookingRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid booking id"})
+		return
+	}
+
+	resp, appErr := h.bookingService.RescheduleBooking(userID, bookingID, &req)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
+	"github.com/preetsinghmakkar/OpenCall/internal/services"
+)
+
+type CouponHandler struct {
+	service *services.CouponService
+}
+
+func NewCouponHandler(service *services.CouponService) *CouponHandler {
+	return &CouponHandler{service: service}
+}
+
+// CreateMentorCoupon issues a coupon for the mentor's services
+// POST /api/mentor/coupons
+func (h *CouponHandler) CreateMentorCoupon(c *gin.Context) {
+	var req dtos.CreateCouponRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	resp, appErr := h.service.CreateMentorCoupon(userID, &req)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListMentorCoupons returns the mentor's coupons, newest first
+// GET /api/mentor/coupons
+func (h *CouponHandler) ListMentorCoupons(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	coupons, appErr := h.service.ListMentorCoupons(userID)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, coupons)
+}
+
+// SetMentorCouponActive turns one of the mentor's coupons on or off
+// PATCH /api/mentor/coupons/:id/active
+func (h *CouponHandler) SetMentorCouponActive(c *gin.Context) {
+	var req dtos.SetCouponActiveRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	couponID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid coupon id"})
+		return
+	}
+
+	resp, appErr := h.service.SetMentorCouponActive(userID, couponID, *req.IsActive)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreatePlatformCoupon issues a coupon valid on every mentor's services
+// POST /api/admin/coupons
+func (h *CouponHandler) CreatePlatformCoupon(c *gin.Context) {
+	var req dtos.CreateCouponRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	resp, appErr := h.service.CreatePlatformCoupon(userID, &req)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListPlatformCoupons returns the platform's coupons, newest first
+// GET /api/admin/coupons
+func (h *CouponHandler) ListPlatformCoupons(c *gin.Context) {
+	coupons, appErr := h.service.ListPlatformCoupons()
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, coupons)
+}
+
+// SetPlatformCouponActive turns a platform coupon on or off
+// PATCH /api/admin/coupons/:id/active
+func (h *CouponHandler) SetPlatformCouponActive(c *gin.Context) {
+	var req dtos.SetCouponActiveRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	couponID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid coupon id"})
+		return
+	}
+
+	resp, appErr := h.service.SetPlatformCouponActive(couponID, *req.IsActive)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/services"
+)
+
+type EarningsHandler struct {
+	service *services.LedgerService
+}
+
+func NewEarningsHandler(service *services.LedgerService) *EarningsHandler {
+	return &EarningsHandler{service: service}
+}
+
+// GetEarnings returns the mentor's balances and monthly statements, or
+// with ?format=csv every ledger movement as a CSV download
+// GET /api/mentor/earnings
+func (h *EarningsHandler) GetEarnings(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		data, appErr := h.service.ExportEarningsCSV(userID)
+		if appErr != nil {
+			c.JSON(appErr.Status, gin.H{
+				"code":  appErr.Code,
+				"error": appErr.Message,
+			})
+			return
+		}
+
+		c.Header("Content-Disposition", `attachment; filename="earnings.csv"`)
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+		return
+	}
+
+	resp, appErr := h.service.GetEarnings(userID)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
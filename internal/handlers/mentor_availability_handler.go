@@ -39,15 +39,202 @@ func (h *MentorAvailabilityHandler) Create(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.mentorAvailabilityService.CreateRule(userID, &req)
+	resp, appErr := h.mentorAvailabilityService.CreateRule(userID, &req)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListOwn returns the mentor's weekly schedule
+// GET /api/mentor/availability
+func (h *MentorAvailabilityHandler) ListOwn(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	resp, appErr := h.mentorAvailabilityService.ListOwnRules(userID)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Replace atomically replaces the whole weekly schedule
+// PUT /api/mentor/availability
+func (h *MentorAvailabilityHandler) Replace(c *gin.Context) {
+	var req dtos.ReplaceMentorAvailabilityRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot create availability"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	resp, appErr := h.mentorAvailabilityService.ReplaceSchedule(userID, &req)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Update changes one availability rule
+// PUT /api/mentor/availability/:id
+func (h *MentorAvailabilityHandler) Update(c *gin.Context) {
+	var req dtos.CreateMentorAvailabilityRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	ruleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid availability id"})
+		return
+	}
+
+	resp, appErr := h.mentorAvailabilityService.UpdateRule(userID, ruleID, &req)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Delete removes one availability rule
+// DELETE /api/mentor/availability/:id
+func (h *MentorAvailabilityHandler) Delete(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	ruleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid availability id"})
+		return
+	}
+
+	if appErr := h.mentorAvailabilityService.DeleteRule(userID, ruleID); appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateOverride adds a blackout, block or extra window on one date
+// POST /api/mentor/availability/overrides
+func (h *MentorAvailabilityHandler) CreateOverride(c *gin.Context) {
+	var req dtos.CreateAvailabilityOverrideRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	resp, appErr := h.mentorAvailabilityService.CreateOverride(userID, &req)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
 		return
 	}
 
 	c.JSON(http.StatusCreated, resp)
 }
 
+// ListOverrides returns the mentor's overrides in a date range
+// GET /api/mentor/availability/overrides?from=YYYY-MM-DD&to=YYYY-MM-DD
+func (h *MentorAvailabilityHandler) ListOverrides(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	resp, appErr := h.mentorAvailabilityService.ListOverrides(userID, c.Query("from"), c.Query("to"))
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeleteOverride removes one override
+// DELETE /api/mentor/availability/overrides/:id
+func (h *MentorAvailabilityHandler) DeleteOverride(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	overrideID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid override id"})
+		return
+	}
+
+	if appErr := h.mentorAvailabilityService.DeleteOverride(userID, overrideID); appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 func (h *MentorAvailabilityHandler) GetByUsername(c *gin.Context) {
 	username := c.Param("username")
 
@@ -63,7 +250,7 @@ func (h *MentorAvailabilityHandler) GetByUsername(c *gin.Context) {
 			return
 		}
 
-		resp, err := h.availabilityService.GetAvailableSlots(username, serviceID, date)
+		resp, err := h.availabilityService.GetAvailableSlots(username, serviceID, date, c.Query("tz"))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
@@ -83,6 +270,30 @@ func (h *MentorAvailabilityHandler) GetByUsername(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// GetSlotCalendar returns bookable slots for a range of days
+// GET /api/mentors/:username/services/:serviceID/slots?from=&to=&tz=
+func (h *MentorAvailabilityHandler) GetSlotCalendar(c *gin.Context) {
+	serviceID, err := uuid.Parse(c.Param("serviceID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid service id"})
+		return
+	}
+
+	resp, err := h.availabilityService.GetSlotCalendar(
+		c.Param("username"),
+		serviceID,
+		c.Query("from"),
+		c.Query("to"),
+		c.Query("tz"),
+	)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 func (h *MentorAvailabilityHandler) Get(c *gin.Context) {
 	username := c.Param("username")
 	date := c.Query("date")
@@ -94,7 +305,7 @@ func (h *MentorAvailabilityHandler) Get(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.availabilityService.GetAvailableSlots(username, serviceID, date)
+	resp, err := h.availabilityService.GetAvailableSlots(username, serviceID, date, c.Query("tz"))
 	if err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
@@ -56,12 +56,16 @@ func (h *MentorHandler) CreateProfile(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusCreated, dtos.CreateMentorProfileResponse{
-		ID:        profile.ID,
-		UserID:    profile.UserID,
-		Title:     profile.Title,
-		Bio:       profile.Bio,
-		Timezone:  profile.Timezone,
-		IsActive:  profile.IsActive,
+		ID:       profile.ID,
+		UserID:   profile.UserID,
+		Title:    profile.Title,
+		Bio:      profile.Bio,
+		Timezone: profile.Timezone,
+		IsActive: profile.IsActive,
+
+		CancellationWindowHours:       profile.CancellationWindowHours,
+		LateCancellationRefundPercent: profile.LateCancellationRefundPercent,
+
 		CreatedAt: profile.CreatedAt,
 	})
 }
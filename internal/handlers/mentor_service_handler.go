@@ -34,10 +34,11 @@ func (h *MentorServiceHandler) Create(c *gin.Context) {
 		return
 	}
 
-	service, err := h.service.CreateService(userID, &req)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "mentor profile not found",
+	service, appErr := h.service.CreateService(userID, &req)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
 		})
 		return
 	}
@@ -50,6 +51,13 @@ func (h *MentorServiceHandler) Create(c *gin.Context) {
 		PriceCents:      service.PriceCents,
 		Currency:        service.Currency,
 		IsActive:        service.IsActive,
+		SortOrder:       service.SortOrder,
+
+		BufferBeforeMinutes: service.BufferBeforeMinutes,
+		BufferAfterMinutes:  service.BufferAfterMinutes,
+		SlotStepMinutes:     service.SlotStepMinutes,
+		MaxSessionsPerDay:   service.MaxSessionsPerDay,
+		MinGapMinutes:       service.MinGapMinutes,
 	})
 }
 
@@ -66,3 +74,146 @@ func (h *MentorServiceHandler) GetByUsername(c *gin.Context) {
 
 	c.JSON(http.StatusOK, services)
 }
+
+// ListOwn returns the mentor's services, including inactive ones
+// GET /api/mentor/services
+func (h *MentorServiceHandler) ListOwn(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	services, appErr := h.service.GetOwnServices(userID)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, services)
+}
+
+// Update edits a service the mentor owns
+// PUT /api/mentor/services/:id
+func (h *MentorServiceHandler) Update(c *gin.Context) {
+	var req dtos.UpdateMentorServiceRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid service id"})
+		return
+	}
+
+	resp, appErr := h.service.UpdateService(userID, serviceID, &req)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// SetActive activates or deactivates a service
+// PATCH /api/mentor/services/:id/active
+func (h *MentorServiceHandler) SetActive(c *gin.Context) {
+	var req dtos.SetMentorServiceActiveRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid service id"})
+		return
+	}
+
+	resp, appErr := h.service.SetServiceActive(userID, serviceID, *req.IsActive)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Delete soft-deletes a service
+// DELETE /api/mentor/services/:id
+func (h *MentorServiceHandler) Delete(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid service id"})
+		return
+	}
+
+	if appErr := h.service.DeleteService(userID, serviceID); appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Reorder sets the display order of the mentor's services
+// PUT /api/mentor/services/order
+func (h *MentorServiceHandler) Reorder(c *gin.Context) {
+	var req dtos.ReorderMentorServicesRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	services, appErr := h.service.ReorderServices(userID, &req)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, services)
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
+	"github.com/preetsinghmakkar/OpenCall/internal/services"
+)
+
+type PackageHandler struct {
+	service *services.PackageService
+}
+
+func NewPackageHandler(service *services.PackageService) *PackageHandler {
+	return &PackageHandler{service: service}
+}
+
+// Create adds a package to one of the mentor's services
+// POST /api/mentor/packages
+func (h *PackageHandler) Create(c *gin.Context) {
+	var req dtos.CreateServicePackageRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	resp, appErr := h.service.CreatePackage(userID, &req)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListOwn returns the mentor's packages, including inactive ones
+// GET /api/mentor/packages
+func (h *PackageHandler) ListOwn(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	packages, appErr := h.service.ListOwnPackages(userID)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, packages)
+}
+
+// Delete stops selling a package
+// DELETE /api/mentor/packages/:id
+func (h *PackageHandler) Delete(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	packageID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid package id"})
+		return
+	}
+
+	if appErr := h.service.DeletePackage(userID, packageID); appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetByUsername lists the packages a mentor sells
+// GET /api/mentors/:username/packages
+func (h *PackageHandler) GetByUsername(c *gin.Context) {
+	packages, err := h.service.GetPackagesByUsername(c.Param("username"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch packages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, packages)
+}
+
+// Purchase starts buying a package and returns the order to pay
+// POST /api/packages/:id/purchase
+func (h *PackageHandler) Purchase(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	packageID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid package id"})
+		return
+	}
+
+	resp, appErr := h.service.PurchasePackage(userID, packageID)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// MyCredits returns the caller's session credits per service
+// GET /api/credits/me
+func (h *PackageHandler) MyCredits(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	credits, err := h.service.GetMyCredits(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch credits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, credits)
+}
@@ -1,9 +1,9 @@
 package handlers
 
 import (
-	"encoding/json"
-	"io"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -28,17 +28,29 @@ func (h *PaymentHandler) CreatePayment(c *gin.Context) {
 
 	userID, _ := uuid.Parse(c.GetString("user_id"))
 
-	payment, err := h.service.CreatePayment(c.Request.Context(), req.BookingID, userID)
+	payment, err := h.service.CreatePayment(
+		c.Request.Context(),
+		req.BookingID,
+		userID,
+		req.BillingState,
+	)
+	if errors.Is(err, services.ErrBookingNotPayable) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"payment_id":        payment.ID,
-		"razorpay_order_id": payment.GatewayOrderID,
-		"amount":            payment.Amount,
-		"currency":          payment.Currency,
+	c.JSON(http.StatusOK, dtos.CreatePaymentResponse{
+		PaymentID:       payment.ID,
+		Gateway:         payment.Gateway,
+		GatewayOrderID:  payment.GatewayOrderID,
+		RazorpayOrderID: payment.GatewayOrderID,
+		ClientSecret:    payment.ClientSecret,
+		Amount:          payment.Amount,
+		Currency:        payment.Currency,
 	})
 }
 
@@ -49,12 +61,25 @@ func (h *PaymentHandler) VerifyPayment(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.VerifyPayment(
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	err = h.service.VerifyPayment(
 		c.Request.Context(),
+		userID,
 		req.PaymentID,
 		req.RazorpayPaymentID,
 		req.RazorpaySignature,
-	); err != nil {
+	)
+	if errors.Is(err, services.ErrPaymentAmountMismatch) ||
+		errors.Is(err, services.ErrPaymentRefunded) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -62,38 +87,17 @@ func (h *PaymentHandler) VerifyPayment(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "payment verified"})
 }
 
-func (h *PaymentHandler) RazorpayWebhook(c *gin.Context) {
-	signature := c.GetHeader("X-Razorpay-Signature")
+// ListMismatches lists payments captured for a different amount or
+// currency than expected
+// GET /api/admin/payments/mismatches?limit=50
+func (h *PaymentHandler) ListMismatches(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
 
-	body, err := io.ReadAll(c.Request.Body)
+	mismatches, err := h.service.ListMismatches(c.Request.Context(), limit)
 	if err != nil {
-		c.Status(http.StatusBadRequest)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.service.VerifyWebhookSignature(body, signature); err != nil {
-		c.Status(http.StatusUnauthorized)
-		return
-	}
-
-	var event dtos.RazorpayWebhookEvent
-	if err := json.Unmarshal(body, &event); err != nil {
-		c.Status(http.StatusBadRequest)
-		return
-	}
-
-	switch event.Event {
-	case "payment.captured":
-		if err := h.service.HandlePaymentCaptured(event); err != nil {
-			c.Status(http.StatusInternalServerError)
-			return
-		}
-	case "payment.failed":
-		if err := h.service.HandlePaymentFailed(event); err != nil {
-			c.Status(http.StatusInternalServerError)
-			return
-		}
-	}
-
-	c.Status(http.StatusOK)
+	c.JSON(http.StatusOK, mismatches)
 }
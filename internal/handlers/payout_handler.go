@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
+	"github.com/preetsinghmakkar/OpenCall/internal/services"
+)
+
+type PayoutHandler struct {
+	service *services.PayoutService
+}
+
+func NewPayoutHandler(service *services.PayoutService) *PayoutHandler {
+	return &PayoutHandler{service: service}
+}
+
+// UpsertAccount sets the bank account or UPI ID the mentor is paid to
+// PUT /api/mentor/payout-account
+func (h *PayoutHandler) UpsertAccount(c *gin.Context) {
+	var req dtos.UpsertPayoutAccountRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	resp, appErr := h.service.UpsertAccount(userID, &req)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetAccount returns the mentor's payout account, masked
+// GET /api/mentor/payout-account
+func (h *PayoutHandler) GetAccount(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	resp, appErr := h.service.GetAccount(userID)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListPayouts returns the mentor's payouts, newest first
+// GET /api/mentor/payouts
+func (h *PayoutHandler) ListPayouts(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	payouts, appErr := h.service.ListPayouts(userID)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, payouts)
+}
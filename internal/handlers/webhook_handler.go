@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/services"
+)
+
+// webhookMaxBodyBytes caps a webhook delivery; gateway events are a few
+// kilobytes
+const webhookMaxBodyBytes = 1 << 20
+
+type WebhookHandler struct {
+	inbox *services.WebhookInboxService
+}
+
+func NewWebhookHandler(inbox *services.WebhookInboxService) *WebhookHandler {
+	return &WebhookHandler{inbox: inbox}
+}
+
+// RazorpayWebhook stores a Razorpay webhook for processing
+// POST /api/webhooks/razorpay
+func (h *WebhookHandler) RazorpayWebhook(c *gin.Context) {
+	h.receive(
+		c,
+		"razorpay",
+		c.GetHeader("X-Razorpay-Event-Id"),
+		c.GetHeader("X-Razorpay-Signature"),
+	)
+}
+
+// StripeWebhook stores a Stripe webhook for processing; the event ID is
+// in the payload
+// POST /api/webhooks/stripe
+func (h *WebhookHandler) StripeWebhook(c *gin.Context) {
+	h.receive(c, "stripe", "", c.GetHeader("Stripe-Signature"))
+}
+
+// receive answers 200 once the event is stored, so the gateway only
+// retries deliveries we never saw.
+func (h *WebhookHandler) receive(
+	c *gin.Context,
+	gateway string,
+	eventID string,
+	signature string,
+) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, webhookMaxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.inbox.Receive(gateway, eventID, body, signature); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidWebhookSignature):
+			c.Status(http.StatusUnauthorized)
+		case errors.Is(err, services.ErrUnknownPaymentGateway):
+			c.Status(http.StatusNotFound)
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// ListEvents lists inbox events, newest first
+// GET /api/admin/webhooks?status=failed&gateway=razorpay&limit=50
+func (h *WebhookHandler) ListEvents(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	events, appErr := h.inbox.ListEvents(c.Query("status"), c.Query("gateway"), limit)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// GetEvent returns one event with its raw payload
+// GET /api/admin/webhooks/:id
+func (h *WebhookHandler) GetEvent(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook event id"})
+		return
+	}
+
+	event, appErr := h.inbox.GetEvent(id)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, event)
+}
+
+// ReplayEvent queues a failed or processed event to be processed again
+// POST /api/admin/webhooks/:id/replay
+func (h *WebhookHandler) ReplayEvent(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook event id"})
+		return
+	}
+
+	event, appErr := h.inbox.ReplayEvent(id)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, event)
+}
@@ -1,120 +1,150 @@
 package handlers
 
 import (
-	"fmt"
+	"encoding/json"
+	"io"
 	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
 	"github.com/preetsinghmakkar/OpenCall/internal/services"
 )
 
 // ZegoHandler handles Zego Cloud video session endpoints
 type ZegoHandler struct {
-	zegoService *services.ZegoCloudService
+	sessionService *services.SessionService
 }
 
 // NewZegoHandler creates a new Zego handler
-func NewZegoHandler(zegoService *services.ZegoCloudService) *ZegoHandler {
+func NewZegoHandler(sessionService *services.SessionService) *ZegoHandler {
 	return &ZegoHandler{
-		zegoService: zegoService,
+		sessionService: sessionService,
 	}
 }
 
-// GenerateTokenRequest is the request body for token generation
-type GenerateTokenRequest struct {
-	UserID            string `json:"user_id" binding:"required"`
-	UserName          string `json:"user_name" binding:"required"`
-	RoomID            string `json:"room_id" binding:"required"`
-	ExpirationSeconds int64  `json:"expiration_seconds"`
-}
-
-// GenerateTokenResponse is the response for token generation
-type GenerateTokenResponse struct {
-	Token  string `json:"token"`
-	AppID  int64  `json:"app_id"`
-	RoomID string `json:"room_id"`
-	UserID string `json:"user_id"`
-}
-
-// GenerateToken generates a Zego Cloud token for joining a video session
+// GenerateToken issues a Zego Cloud token for the caller to join the video
+// session of one of their confirmed bookings
 // POST /api/zego/token
 func (h *ZegoHandler) GenerateToken(c *gin.Context) {
-	var req GenerateTokenRequest
-
-	log.Println("[ZegoHandler] GenerateToken called")
+	var req dtos.ZegoTokenRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Println("[ZegoHandler] JSON binding error:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request: %v", err)})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "booking_id is required"})
 		return
 	}
 
-	log.Println("[ZegoHandler] Request:", map[string]interface{}{
-		"userID": req.UserID,
-		"roomID": req.RoomID,
-	})
-
-	// Set default expiration if not provided
-	if req.ExpirationSeconds <= 0 {
-		req.ExpirationSeconds = 3600 // 1 hour default
-	}
-
-	// Generate token
-	token, err := h.zegoService.GenerateToken(
-		req.UserID,
-		req.UserName,
-		req.RoomID,
-		req.ExpirationSeconds,
-	)
+	userID, err := uuid.Parse(c.GetString("user_id"))
 	if err != nil {
-		log.Println("[ZegoHandler] Token generation error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
 		return
 	}
 
-	log.Println("[ZegoHandler] Token generated successfully, appID:", h.zegoService.GetAppID())
-
-	responseData := GenerateTokenResponse{
-		Token:  token,
-		AppID:  h.zegoService.GetAppID(),
-		RoomID: req.RoomID,
-		UserID: req.UserID,
+	resp, appErr := h.sessionService.IssueToken(userID, req.BookingID)
+	if appErr != nil {
+		log.Println("[ZegoHandler] Token refused for booking:", req.BookingID, appErr.Code)
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
 	}
 
-	log.Println("[ZegoHandler] Sending response:", map[string]interface{}{
-		"token_length": len(token),
-		"app_id":       responseData.AppID,
-		"room_id":      responseData.RoomID,
-		"user_id":      responseData.UserID,
-	})
-
 	c.JSON(http.StatusOK, gin.H{
-		"data": responseData,
+		"data": resp,
 	})
 }
 
-// GetSessionInfo provides information needed to join a Zego session
+// GetSessionInfo tells a participant whether they can join a booking's
+// video session now
 // GET /api/zego/session/:bookingID
 func (h *ZegoHandler) GetSessionInfo(c *gin.Context) {
-	bookingID := c.Param("bookingID")
+	bookingID, err := uuid.Parse(c.Param("bookingID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid booking id"})
+		return
+	}
 
-	log.Println("[ZegoHandler] GetSessionInfo called for booking:", bookingID)
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
 
-	if bookingID == "" {
-		log.Println("[ZegoHandler] Missing booking ID")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Booking ID is required"})
+	resp, appErr := h.sessionService.GetSessionInfo(userID, bookingID)
+	if appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
 		return
 	}
 
-	// Return session info with app ID and room setup
-	log.Println("[ZegoHandler] Returning session info")
 	c.JSON(http.StatusOK, gin.H{
-		"data": gin.H{
-			"app_id":   h.zegoService.GetAppID(),
-			"room_id":  bookingID, // Use booking ID as room ID
-			"can_join": true,
-			"message":  "Ready to join Zego session",
-		},
+		"data": resp,
 	})
 }
+
+// RecordSessionEvent records that the caller joined or left a booking's
+// video room
+// POST /api/zego/session/:bookingID/events
+func (h *ZegoHandler) RecordSessionEvent(c *gin.Context) {
+	bookingID, err := uuid.Parse(c.Param("bookingID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid booking id"})
+		return
+	}
+
+	var req dtos.SessionEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "event must be joined or left"})
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	if appErr := h.sessionService.RecordEvent(userID, bookingID, req.Event); appErr != nil {
+		c.JSON(appErr.Status, gin.H{
+			"code":  appErr.Code,
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ZegoCallback receives ZEGOCLOUD room callbacks (room created/closed,
+// user login/logout) and records them against the booking
+// POST /api/webhooks/zego
+func (h *ZegoHandler) ZegoCallback(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	var cb dtos.ZegoCallback
+	if err := json.Unmarshal(body, &cb); err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.sessionService.VerifyZegoCallback(cb); err != nil {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.sessionService.HandleZegoCallback(cb); err != nil {
+		log.Println("[ZegoHandler] Callback failed:", cb.Event, cb.RoomID, err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
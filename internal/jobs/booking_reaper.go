@@ -0,0 +1,53 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/preetsinghmakkar/OpenCall/internal/repositories"
+	"github.com/rs/zerolog"
+)
+
+// BookingReaper expires pending bookings whose checkout was abandoned so
+// the mentor's slot becomes bookable again. A booking whose payment
+// attempt is younger than the order TTL is still being paid and is left
+// for a later run.
+type BookingReaper struct {
+	l           zerolog.Logger
+	bookingRepo *repositories.BookingRepository
+	holdWindow  time.Duration
+	orderTTL    time.Duration
+}
+
+func NewBookingReaper(
+	l zerolog.Logger,
+	bookingRepo *repositories.BookingRepository,
+	holdWindow time.Duration,
+	orderTTL time.Duration,
+) *BookingReaper {
+	return &BookingReaper{
+		l:           l,
+		bookingRepo: bookingRepo,
+		holdWindow:  holdWindow,
+		orderTTL:    orderTTL,
+	}
+}
+
+func (r *BookingReaper) Run(ctx context.Context) error {
+	now := time.Now()
+
+	expired, err := r.bookingRepo.ExpireUnpaidPending(
+		ctx,
+		now.Add(-r.holdWindow),
+		now.Add(-r.orderTTL),
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range expired {
+		r.l.Info().Str("booking_id", id.String()).Msg("expired unpaid booking")
+	}
+
+	return nil
+}
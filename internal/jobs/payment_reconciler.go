@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/preetsinghmakkar/OpenCall/internal/repositories"
+	"github.com/preetsinghmakkar/OpenCall/internal/services"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// paymentReconcileLookback bounds how far back unpaid orders are
+	// checked; older checkouts are long abandoned
+	paymentReconcileLookback = 7 * 24 * time.Hour
+	paymentReconcileBatch    = 100
+)
+
+// PaymentReconciler checks payment attempts left unpaid against the
+// gateway, so a capture is not lost when both the client's verify call
+// and the webhook are.
+type PaymentReconciler struct {
+	l           zerolog.Logger
+	paymentRepo *repositories.PaymentRepository
+	payments    *services.PaymentService
+	after       time.Duration
+}
+
+func NewPaymentReconciler(
+	l zerolog.Logger,
+	paymentRepo *repositories.PaymentRepository,
+	payments *services.PaymentService,
+	after time.Duration,
+) *PaymentReconciler {
+	return &PaymentReconciler{
+		l:           l,
+		paymentRepo: paymentRepo,
+		payments:    payments,
+		after:       after,
+	}
+}
+
+func (r *PaymentReconciler) Run(ctx context.Context) error {
+	now := time.Now()
+
+	payments, err := r.paymentRepo.FindStaleUnpaid(
+		ctx,
+		now.Add(-paymentReconcileLookback),
+		now.Add(-r.after),
+		paymentReconcileBatch,
+	)
+	if err != nil {
+		return err
+	}
+
+	applied, mismatches := 0, 0
+
+	for _, p := range payments {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		outcome, mismatch, err := r.payments.ReconcilePayment(ctx, p)
+
+		if mismatch != nil {
+			mismatches++
+			r.l.Warn().
+				Str("payment_id", p.ID.String()).
+				Str("gateway", mismatch.Gateway).
+				Int64("expected_amount", mismatch.ExpectedAmount).
+				Str("expected_currency", mismatch.ExpectedCurrency).
+				Int64("actual_amount", mismatch.ActualAmount).
+				Str("actual_currency", mismatch.ActualCurrency).
+				Msg("payment captured with a different amount or currency")
+		}
+
+		if err != nil {
+			r.l.Error().
+				Err(err).
+				Str("payment_id", p.ID.String()).
+				Msg("failed to reconcile payment")
+			continue
+		}
+
+		if outcome != "" {
+			applied++
+			r.l.Info().
+				Str("payment_id", p.ID.String()).
+				Str("outcome", outcome).
+				Msg("reconciled payment")
+		}
+	}
+
+	if applied > 0 || mismatches > 0 {
+		r.l.Info().
+			Int("checked", len(payments)).
+			Int("applied", applied).
+			Int("mismatches", mismatches).
+			Msg("payment reconciliation report")
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Runner calls a job function on a fixed interval in its own goroutine
+// until it is stopped.
+type Runner struct {
+	l        zerolog.Logger
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context) error
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewRunner(
+	l zerolog.Logger,
+	name string,
+	interval time.Duration,
+	run func(ctx context.Context) error,
+) *Runner {
+	return &Runner{
+		l:        l.With().Str("job", name).Logger(),
+		name:     name,
+		interval: interval,
+		run:      run,
+	}
+}
+
+// Start launches the job loop. The job runs once immediately and then
+// on every tick.
+func (r *Runner) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		r.l.Info().Dur("interval", r.interval).Msg("job started")
+
+		for {
+			if err := r.run(ctx); err != nil && ctx.Err() == nil {
+				r.l.Error().Err(err).Msg("job run failed")
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop cancels the current run and waits for the loop to exit.
+func (r *Runner) Stop() {
+	if r.cancel == nil {
+		return
+	}
+
+	r.cancel()
+	<-r.done
+	r.l.Info().Msg("job stopped")
+}
@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/preetsinghmakkar/OpenCall/internal/repositories"
+	"github.com/rs/zerolog"
+)
+
+// SessionSettler marks confirmed bookings whose session has ended as
+// completed or no-show from the participants' recorded attendance.
+type SessionSettler struct {
+	l           zerolog.Logger
+	bookingRepo *repositories.BookingRepository
+	settleDelay time.Duration
+}
+
+func NewSessionSettler(
+	l zerolog.Logger,
+	bookingRepo *repositories.BookingRepository,
+	settleDelay time.Duration,
+) *SessionSettler {
+	return &SessionSettler{
+		l:           l,
+		bookingRepo: bookingRepo,
+		settleDelay: settleDelay,
+	}
+}
+
+func (s *SessionSettler) Run(ctx context.Context) error {
+	cutoff := time.Now().Add(-s.settleDelay)
+
+	settled, err := s.bookingRepo.SettleEndedSessions(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+
+	for _, st := range settled {
+		s.l.Info().
+			Str("booking_id", st.BookingID.String()).
+			Str("status", string(st.Status)).
+			Str("attendance_source", st.AttendanceSource).
+			Msg("settled session")
+	}
+
+	return nil
+}
@@ -13,6 +13,12 @@ const (
 	BookingStatusConfirmed BookingStatus = "confirmed"
 	BookingStatusCancelled BookingStatus = "cancelled"
 	BookingStatusCompleted BookingStatus = "completed"
+	BookingStatusRefunded  BookingStatus = "refunded"
+	BookingStatusExpired   BookingStatus = "expired"
+
+	// Set after the session ended when a participant never joined
+	BookingStatusNoShowMentor BookingStatus = "no_show_mentor"
+	BookingStatusNoShowUser   BookingStatus = "no_show_user"
 )
 
 type Booking struct {
@@ -22,16 +28,30 @@ type Booking struct {
 	UserID    uuid.UUID `db:"user_id"`
 	ServiceID uuid.UUID `db:"service_id"`
 
-	// Date & time are intentionally split
+	// BookingDate is the session's calendar date in the mentor's
+	// timezone; StartTime and EndTime are absolute instants (timestamptz).
 	BookingDate time.Time `db:"booking_date"` // DATE only
-	StartTime   time.Time `db:"start_time"`   // TIME only
-	EndTime     time.Time `db:"end_time"`     // TIME only
+	StartTime   time.Time `db:"start_time"`
+	EndTime     time.Time `db:"end_time"`
+
+	// BlockedFrom and BlockedUntil are StartTime and EndTime widened by the
+	// service's buffers; MinGapMinutes is the free time required between
+	// this booking and the mentor's other bookings.
+	BlockedFrom   time.Time `db:"blocked_from"`
+	BlockedUntil  time.Time `db:"blocked_until"`
+	MinGapMinutes int       `db:"min_gap_minutes"`
 
 	Status BookingStatus `db:"status"`
 
 	PriceCents int    `db:"price_cents"`
 	Currency   string `db:"currency"`
 
+	// Set when the booking is cancelled by either participant
+	CancelledAt        *time.Time `db:"cancelled_at"`
+	CancelledBy        *uuid.UUID `db:"cancelled_by"`
+	CancellationReason *string    `db:"cancellation_reason"`
+	RefundCents        int        `db:"refund_cents"`
+
 	CreatedAt time.Time `db:"created_at"`
 	UpdatedAt time.Time `db:"updated_at"`
 }
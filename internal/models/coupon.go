@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CouponDiscountPercent = "percent"
+	CouponDiscountFixed   = "fixed"
+)
+
+// Coupon is a discount code applied when booking. Mentor coupons only
+// work on the issuing mentor's services; platform coupons have no
+// MentorID, work on any service and are funded by the platform.
+type Coupon struct {
+	ID   uuid.UUID `db:"id"`
+	Code string    `db:"code"` // upper-case
+
+	MentorID *uuid.UUID `db:"mentor_id"`
+	// ServiceID limits the coupon to one service; nil applies it to every
+	// service it is eligible for
+	ServiceID *uuid.UUID `db:"service_id"`
+
+	DiscountType   string  `db:"discount_type"` // percent | fixed
+	PercentOff     int     `db:"percent_off"`
+	AmountOffCents int     `db:"amount_off_cents"`
+	Currency       *string `db:"currency"` // fixed discounts only
+
+	// 0 is unlimited
+	MaxRedemptions        int `db:"max_redemptions"`
+	MaxRedemptionsPerUser int `db:"max_redemptions_per_user"`
+
+	StartsAt  *time.Time `db:"starts_at"`
+	ExpiresAt *time.Time `db:"expires_at"`
+	IsActive  bool       `db:"is_active"`
+
+	CreatedBy uuid.UUID `db:"created_by"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+
+	// Redemptions counts the bookings currently holding the coupon. It is
+	// not filled when the coupon is loaded for a booking.
+	Redemptions int `db:"-"`
+}
+
+// CouponRedemption is a coupon applied to one booking. The booking's
+// price is ListPriceCents less DiscountCents.
+type CouponRedemption struct {
+	ID             uuid.UUID `db:"id"`
+	CouponID       uuid.UUID `db:"coupon_id"`
+	BookingID      uuid.UUID `db:"booking_id"`
+	UserID         uuid.UUID `db:"user_id"`
+	ListPriceCents int       `db:"list_price_cents"`
+	DiscountCents  int       `db:"discount_cents"`
+	Currency       string    `db:"currency"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+// Discount returns what the coupon takes off a price, at most the whole
+// price. Fixed discounts in another currency take nothing off.
+func (c *Coupon) Discount(priceCents int, currency string) int {
+	var discount int
+
+	switch c.DiscountType {
+	case CouponDiscountPercent:
+		discount = priceCents * c.PercentOff / 100
+	case CouponDiscountFixed:
+		if c.Currency != nil && *c.Currency == currency {
+			discount = c.AmountOffCents
+		}
+	}
+
+	if discount > priceCents {
+		return priceCents
+	}
+	return discount
+}
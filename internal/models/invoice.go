@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invoice kinds. INR payments get a GST tax invoice when the platform is
+// registered for GST; everything else gets a receipt without tax.
+const (
+	InvoiceKindTax     = "tax_invoice"
+	InvoiceKindReceipt = "receipt"
+)
+
+// Invoice is the document issued for a paid booking. Numbers run without
+// gaps within each kind and financial year, e.g. INV/26-27/000042.
+type Invoice struct {
+	ID            uuid.UUID `db:"id"`
+	Number        string    `db:"number"`
+	Kind          string    `db:"kind"` // see InvoiceKind*
+	FinancialYear string    `db:"financial_year"`
+	Sequence      int       `db:"sequence"`
+
+	BookingID uuid.UUID `db:"booking_id"`
+	PaymentID uuid.UUID `db:"payment_id"`
+	UserID    uuid.UUID `db:"user_id"`
+	MentorID  uuid.UUID `db:"mentor_id"`
+
+	SupplierName    string  `db:"supplier_name"`
+	SupplierAddress string  `db:"supplier_address"`
+	SupplierGSTIN   *string `db:"supplier_gstin"`
+	SupplierState   *string `db:"supplier_state"`
+	BuyerName       string  `db:"buyer_name"`
+	BuyerEmail      string  `db:"buyer_email"`
+	// BillingState and PlaceOfSupply are GST state codes such as "29"
+	BillingState  *string `db:"billing_state"`
+	PlaceOfSupply *string `db:"place_of_supply"`
+
+	Description string `db:"description"`
+	SAC         string `db:"sac"`
+	PaymentRef  string `db:"payment_ref"`
+
+	// Prices include GST: TaxableCents plus the tax is TotalCents.
+	// CGST and SGST are charged within the supplier's state, IGST
+	// across states.
+	Currency       string `db:"currency"`
+	TaxableCents   int64  `db:"taxable_cents"`
+	GSTRatePercent int    `db:"gst_rate_percent"`
+	CGSTCents      int64  `db:"cgst_cents"`
+	SGSTCents      int64  `db:"sgst_cents"`
+	IGSTCents      int64  `db:"igst_cents"`
+	TotalCents     int64  `db:"total_cents"`
+
+	IssuedAt time.Time `db:"issued_at"`
+}
+
+// InvoiceParty is what an invoice needs to know about a booking: who
+// booked which service from whom.
+type InvoiceParty struct {
+	BookingID    uuid.UUID
+	UserID       uuid.UUID
+	MentorID     uuid.UUID
+	MentorUserID uuid.UUID
+	BuyerName    string
+	BuyerEmail   string
+	MentorName   string
+	ServiceTitle string
+	BookingDate  time.Time
+}
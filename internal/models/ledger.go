@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ledger accounts. gateway_clearing holds what mentees paid,
+// mentor_payable what the platform owes each mentor, platform_revenue
+// the commission it keeps, payouts_in_transit money sent to mentors
+// that the provider has not confirmed yet and platform_promotions the
+// discounts of platform coupons, which the platform pays mentors.
+const (
+	LedgerAccountGatewayClearing    = "gateway_clearing"
+	LedgerAccountMentorPayable      = "mentor_payable"
+	LedgerAccountPlatformRevenue    = "platform_revenue"
+	LedgerAccountPayoutsInTransit   = "payouts_in_transit"
+	LedgerAccountPlatformPromotions = "platform_promotions"
+)
+
+const (
+	LedgerDebit  = "debit"
+	LedgerCredit = "credit"
+)
+
+const (
+	LedgerKindSessionEarning = "session_earning"
+	LedgerKindRefund         = "refund"
+	LedgerKindPayout         = "payout"
+	LedgerKindPayoutPaid     = "payout_paid"
+	LedgerKindPayoutFailed   = "payout_failed"
+)
+
+type LedgerTransaction struct {
+	ID uuid.UUID `db:"id"`
+
+	Kind           string `db:"kind"` // session_earning | refund | payout | payout_paid | payout_failed
+	IdempotencyKey string `db:"idempotency_key"`
+
+	MentorID  *uuid.UUID `db:"mentor_id"`
+	BookingID *uuid.UUID `db:"booking_id"`
+	RefundID  *uuid.UUID `db:"refund_id"`
+	PayoutID  *uuid.UUID `db:"payout_id"`
+	Memo      string     `db:"memo"`
+
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type LedgerEntry struct {
+	ID            uuid.UUID `db:"id"`
+	TransactionID uuid.UUID `db:"transaction_id"`
+
+	Account  string     `db:"account"`
+	MentorID *uuid.UUID `db:"mentor_id"`
+
+	Direction   string `db:"direction"` // debit | credit
+	AmountCents int64  `db:"amount_cents"`
+	Currency    string `db:"currency"`
+
+	// AvailableAt is when a mentor_payable credit can be paid out
+	AvailableAt time.Time `db:"available_at"`
+
+	CreatedAt time.Time `db:"created_at"`
+}
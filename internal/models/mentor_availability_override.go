@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	OverrideKindBlackout = "blackout" // whole day off
+	OverrideKindBlock    = "block"    // part of the day off
+	OverrideKindExtra    = "extra"    // extra hours on top of the weekly rules
+)
+
+// MentorAvailabilityOverride changes the weekly schedule on one date.
+// Date is the mentor-local calendar day; StartTime and EndTime are
+// wall-clock times in the mentor's timezone and are nil for blackouts.
+type MentorAvailabilityOverride struct {
+	ID        uuid.UUID
+	MentorID  uuid.UUID
+	Date      time.Time
+	Kind      string
+	StartTime *time.Time
+	EndTime   *time.Time
+	Reason    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
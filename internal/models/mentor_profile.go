@@ -7,12 +7,19 @@ import (
 )
 
 type MentorProfile struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	UserID    uuid.UUID `json:"user_id" db:"user_id"`
-	Title     string    `json:"title" db:"title"`
-	Bio       string    `json:"bio" db:"bio"`
-	Timezone  string    `json:"timezone" db:"timezone"`
-	IsActive  bool      `json:"is_active" db:"is_active"`
+	ID       uuid.UUID `json:"id" db:"id"`
+	UserID   uuid.UUID `json:"user_id" db:"user_id"`
+	Title    string    `json:"title" db:"title"`
+	Bio      string    `json:"bio" db:"bio"`
+	Timezone string    `json:"timezone" db:"timezone"`
+	IsActive bool      `json:"is_active" db:"is_active"`
+
+	// Cancellation policy. A mentee cancelling at least
+	// CancellationWindowHours before the session gets a full refund,
+	// later cancellations get LateCancellationRefundPercent of the amount.
+	CancellationWindowHours       int `json:"cancellation_window_hours" db:"cancellation_window_hours"`
+	LateCancellationRefundPercent int `json:"late_cancellation_refund_percent" db:"late_cancellation_refund_percent"`
+
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -15,6 +15,24 @@ type MentorService struct {
 	PriceCents      int
 	Currency        string
 	IsActive        bool
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
+	SortOrder       int
+
+	// Scheduling. Buffers keep the mentor free around each session, slots
+	// start every SlotStepMinutes (0 means every DurationMinutes), at most
+	// MaxSessionsPerDay are booked per day (0 means no cap) and sessions
+	// are at least MinGapMinutes apart.
+	BufferBeforeMinutes int
+	BufferAfterMinutes  int
+	SlotStepMinutes     int
+	MaxSessionsPerDay   int
+	MinGapMinutes       int
+
+	// FreeBookingsPerMentee caps how many bookings of a free service one
+	// mentee may hold, including past sessions. 0 means no cap; paid
+	// services ignore it.
+	FreeBookingsPerMentee int
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time
 }
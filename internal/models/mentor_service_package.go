@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MentorServicePackage sells SessionCount sessions of one service for a
+// single price.
+type MentorServicePackage struct {
+	ID           uuid.UUID
+	MentorID     uuid.UUID
+	ServiceID    uuid.UUID
+	Title        string
+	SessionCount int
+	PriceCents   int
+	Currency     string
+	IsActive     bool
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time
+}
+
+const (
+	PackagePurchaseStatusPending = "pending"
+	PackagePurchaseStatusPaid    = "paid"
+	PackagePurchaseStatusFailed  = "failed"
+)
+
+// PackagePurchase is a mentee buying a package. Its session credits are
+// issued once the payment is captured.
+type PackagePurchase struct {
+	ID        uuid.UUID `db:"id"`
+	PackageID uuid.UUID `db:"package_id"`
+	UserID    uuid.UUID `db:"user_id"`
+	MentorID  uuid.UUID `db:"mentor_id"`
+	ServiceID uuid.UUID `db:"service_id"`
+
+	SessionCount int    `db:"session_count"`
+	PriceCents   int    `db:"price_cents"`
+	Currency     string `db:"currency"`
+
+	Status string `db:"status"` // pending | paid | failed
+
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// SessionCredit is one prepaid session of a service. It is available
+// while BookingID is nil.
+type SessionCredit struct {
+	ID         uuid.UUID  `db:"id"`
+	PurchaseID uuid.UUID  `db:"purchase_id"`
+	UserID     uuid.UUID  `db:"user_id"`
+	ServiceID  uuid.UUID  `db:"service_id"`
+	BookingID  *uuid.UUID `db:"booking_id"`
+	RedeemedAt *time.Time `db:"redeemed_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
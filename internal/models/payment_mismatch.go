@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentMismatch records a payment captured at the gateway for a
+// different amount or currency than the booking or package price. The
+// payment is left in amount_mismatch for review.
+type PaymentMismatch struct {
+	ID               uuid.UUID `db:"id"`
+	PaymentID        uuid.UUID `db:"payment_id"`
+	Gateway          string    `db:"gateway"`
+	GatewayPaymentID string    `db:"gateway_payment_id"`
+
+	ExpectedAmount   int64  `db:"expected_amount"`
+	ExpectedCurrency string `db:"expected_currency"`
+	ActualAmount     int64  `db:"actual_amount"`
+	ActualCurrency   string `db:"actual_currency"`
+
+	DetectedAt time.Time `db:"detected_at"`
+}
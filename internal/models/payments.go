@@ -6,11 +6,34 @@ import (
 	"github.com/google/uuid"
 )
 
+// A payment is one attempt to pay for a booking or package purchase:
+//
+//	created        → paid | failed | superseded | amount_mismatch
+//	superseded     → paid | failed | amount_mismatch
+//	failed         → paid | amount_mismatch (a retry on the same order)
+//	paid           → refund_pending
+//	refund_pending → refunded | paid
+//
+// A booking has at most one created attempt; opening another supersedes
+// it. Captures for a different amount or currency than the booking's
+// price move to amount_mismatch and are left for review.
+const (
+	PaymentStatusCreated        = "created"
+	PaymentStatusPaid           = "paid"
+	PaymentStatusFailed         = "failed"
+	PaymentStatusSuperseded     = "superseded"
+	PaymentStatusAmountMismatch = "amount_mismatch"
+	PaymentStatusRefundPending  = "refund_pending"
+	PaymentStatusRefunded       = "refunded"
+)
+
 type Payment struct {
 	ID uuid.UUID `db:"id"`
 
-	BookingID uuid.UUID `db:"booking_id"`
-	UserID    uuid.UUID `db:"user_id"`
+	// Exactly one of BookingID and PackagePurchaseID is set
+	BookingID         *uuid.UUID `db:"booking_id"`
+	PackagePurchaseID *uuid.UUID `db:"package_purchase_id"`
+	UserID            uuid.UUID  `db:"user_id"`
 
 	Gateway string `db:"gateway"`
 
@@ -21,7 +44,14 @@ type Payment struct {
 	Amount   int64  `db:"amount"`
 	Currency string `db:"currency"`
 
-	Status string `db:"status"` // created | paid | failed
+	// BillingState is the mentee's GST state code, used for the invoice
+	BillingState *string `db:"billing_state"`
+
+	Status string `db:"status"` // see PaymentStatus*
+
+	// ClientSecret lets the browser confirm a Stripe payment. It is only
+	// set on a newly created payment and is not stored.
+	ClientSecret string `db:"-"`
 
 	CreatedAt time.Time `db:"created_at"`
 	UpdatedAt time.Time `db:"updated_at"`
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PayoutMethodBankAccount = "bank_account"
+	PayoutMethodVPA         = "vpa"
+)
+
+const (
+	// PayoutStatusPending is a payout recorded locally that the provider
+	// has not accepted yet
+	PayoutStatusPending    = "pending"
+	PayoutStatusProcessing = "processing"
+	PayoutStatusPaid       = "paid"
+	PayoutStatusFailed     = "failed"
+)
+
+// MentorPayoutAccount is where a mentor is paid. DetailsCiphertext is the
+// encrypted PayoutDetails; DisplayHint is a masked form safe to show.
+type MentorPayoutAccount struct {
+	MentorID uuid.UUID `db:"mentor_id"`
+
+	Method            string `db:"method"` // bank_account | vpa
+	DetailsCiphertext []byte `db:"details_ciphertext"`
+	DisplayHint       string `db:"display_hint"`
+
+	// Set once the account is registered with the payout provider
+	Provider              *string `db:"provider"`
+	ProviderDestinationID *string `db:"provider_destination_id"`
+
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// PayoutDetails is the plaintext of MentorPayoutAccount.DetailsCiphertext.
+type PayoutDetails struct {
+	AccountHolderName string `json:"account_holder_name"`
+	AccountNumber     string `json:"account_number,omitempty"`
+	IFSC              string `json:"ifsc,omitempty"`
+	VPA               string `json:"vpa,omitempty"`
+}
+
+type PayoutBatch struct {
+	ID          uuid.UUID `db:"id"`
+	PayoutCount int       `db:"payout_count"`
+	TotalCents  int64     `db:"total_cents"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+type Payout struct {
+	ID       uuid.UUID `db:"id"`
+	BatchID  uuid.UUID `db:"batch_id"`
+	MentorID uuid.UUID `db:"mentor_id"`
+
+	AmountCents int64  `db:"amount_cents"`
+	Currency    string `db:"currency"`
+
+	Status           string  `db:"status"` // pending | processing | paid | failed
+	Provider         string  `db:"provider"`
+	ProviderPayoutID *string `db:"provider_payout_id"`
+	FailureReason    *string `db:"failure_reason"`
+
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusProcessed = "processed"
+	RefundStatusFailed    = "failed"
+)
+
+type Refund struct {
+	ID uuid.UUID `db:"id"`
+
+	// A refund is for either a booking or a package purchase
+	PaymentID         uuid.UUID  `db:"payment_id"`
+	BookingID         *uuid.UUID `db:"booking_id"`
+	PackagePurchaseID *uuid.UUID `db:"package_purchase_id"`
+
+	Gateway         string  `db:"gateway"`
+	GatewayRefundID *string `db:"gateway_refund_id"`
+
+	Amount   int64  `db:"amount"`
+	Currency string `db:"currency"`
+	Reason   string `db:"reason"`
+
+	Status string `db:"status"` // pending | processed | failed
+
+	// Unfulfilled refunds return a capture that never confirmed its
+	// booking or purchase, so processing them changes nothing else
+	Unfulfilled bool `db:"unfulfilled"`
+
+	// Submission to the gateway, retried until it returns a refund ID
+	Attempts      int        `db:"attempts"`
+	NextAttemptAt *time.Time `db:"next_attempt_at"`
+	LastError     *string    `db:"last_error"`
+
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
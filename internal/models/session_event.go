@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SessionEventTokenIssued = "token_issued"
+	SessionEventJoined      = "joined"
+	SessionEventLeft        = "left"
+)
+
+const (
+	SessionEventSourceClient = "client"
+	SessionEventSourceZego   = "zego"
+)
+
+// SessionEvent records one participant's activity around a booking's
+// video session.
+type SessionEvent struct {
+	ID uuid.UUID `db:"id"`
+
+	BookingID uuid.UUID `db:"booking_id"`
+	UserID    uuid.UUID `db:"user_id"`
+	Role      string    `db:"role"` // mentee | mentor
+
+	EventType  string    `db:"event_type"` // token_issued | joined | left
+	OccurredAt time.Time `db:"occurred_at"`
+	Source     string    `db:"source"` // client | zego
+
+	CreatedAt time.Time `db:"created_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// VideoSession is the video room of a booking. StartedAt, EndedAt and
+// DurationSeconds come from ZEGOCLOUD callbacks; DurationSeconds only
+// counts the time the room was open.
+type VideoSession struct {
+	BookingID uuid.UUID `db:"booking_id"`
+	RoomID    string    `db:"room_id"`
+
+	StartedAt *time.Time `db:"started_at"`
+	EndedAt   *time.Time `db:"ended_at"`
+	// OpenedAt is set while the room is open
+	OpenedAt        *time.Time `db:"opened_at"`
+	DurationSeconds int        `db:"duration_seconds"`
+
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
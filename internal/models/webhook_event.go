@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	WebhookEventStatusPending    = "pending"
+	WebhookEventStatusProcessing = "processing"
+	WebhookEventStatusProcessed  = "processed"
+	WebhookEventStatusFailed     = "failed"
+	// Rejected events failed signature verification and are never
+	// processed. Receive logs such deliveries instead of storing them.
+	WebhookEventStatusRejected = "rejected"
+)
+
+// WebhookEvent is a payment gateway webhook kept in the inbox until it has
+// been processed. Payload and Signature are stored exactly as received.
+type WebhookEvent struct {
+	ID        uuid.UUID `db:"id"`
+	Gateway   string    `db:"gateway"`
+	EventID   string    `db:"event_id"`
+	EventType string    `db:"event_type"`
+
+	Payload        string `db:"payload"`
+	Signature      string `db:"signature"`
+	SignatureValid bool   `db:"signature_valid"`
+	// SecretID identifies the webhook secret that signed a valid event
+	SecretID *string `db:"secret_id"`
+
+	Status        string     `db:"status"` // pending | processing | processed | failed | rejected
+	Attempts      int        `db:"attempts"`
+	NextAttemptAt *time.Time `db:"next_attempt_at"`
+	LastError     *string    `db:"last_error"`
+
+	ReceivedAt  time.Time  `db:"received_at"`
+	ProcessedAt *time.Time `db:"processed_at"`
+	UpdatedAt   time.Time  `db:"updated_at"`
+}
@@ -11,31 +11,53 @@ import (
 	"github.com/preetsinghmakkar/OpenCall/internal/models"
 )
 
+var ErrBookingNotFound = errors.New("booking not found")
+
 type BookingRepository struct {
 	db *sql.DB
+
+	// reader serves read-only queries that tolerate replica lag
+	reader *sql.DB
 }
 
 func NewBookingRepository(db *sql.DB) *BookingRepository {
-	return &BookingRepository{db: db}
+	return &BookingRepository{db: db, reader: db}
+}
+
+// WithReader routes lag-tolerant read-only queries to reader, typically a
+// read replica. Writes and transactional reads stay on the primary.
+func (r *BookingRepository) WithReader(reader *sql.DB) *BookingRepository {
+	r.reader = reader
+	return r
 }
 
-func (r *BookingRepository) FindForMentorOnDate(
+// FindForMentorBetween returns the mentor's blocking bookings whose
+// blocked time (buffers included) overlaps the half-open interval
+// [from, to).
+func (r *BookingRepository) FindForMentorBetween(
 	mentorID uuid.UUID,
-	date time.Time,
+	from time.Time,
+	to time.Time,
 ) ([]*models.Booking, error) {
 
 	const query = `
 	SELECT
 		id,
+		service_id,
+		booking_date,
 		start_time,
-		end_time
+		end_time,
+		blocked_from,
+		blocked_until,
+		min_gap_minutes
 	FROM bookings
 	WHERE mentor_id = $1
-	  AND booking_date = $2
 	  AND status IN ('pending', 'confirmed')
+	  AND blocked_from < $3
+	  AND blocked_until > $2
 	`
 
-	rows, err := r.db.Query(query, mentorID, date)
+	rows, err := r.reader.Query(query, mentorID, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -48,8 +70,13 @@ func (r *BookingRepository) FindForMentorOnDate(
 
 		if err := rows.Scan(
 			&b.ID,
+			&b.ServiceID,
+			&b.BookingDate,
 			&b.StartTime,
 			&b.EndTime,
+			&b.BlockedFrom,
+			&b.BlockedUntil,
+			&b.MinGapMinutes,
 		); err != nil {
 			return nil, err
 		}
@@ -57,26 +84,32 @@ func (r *BookingRepository) FindForMentorOnDate(
 		bookings = append(bookings, &b)
 	}
 
-	return bookings, nil
+	return bookings, rows.Err()
 }
 
+// HasConflictTx reports whether the mentor has a blocking booking too
+// close to [blockedFrom, blockedUntil). Both sides' blocked time includes
+// their buffers, and they must be at least the larger of the two minimum
+// gaps apart. excludeBookingID lets a booking being rescheduled ignore
+// itself; pass uuid.Nil when creating a new booking.
 func (r *BookingRepository) HasConflictTx(
 	ctx context.Context,
 	tx *sql.Tx,
 	mentorID uuid.UUID,
-	bookingDate time.Time,
-	start time.Time,
-	end time.Time,
+	blockedFrom time.Time,
+	blockedUntil time.Time,
+	minGapMinutes int,
+	excludeBookingID uuid.UUID,
 ) (bool, error) {
 
 	const query = `
 	SELECT 1
 	FROM bookings
 	WHERE mentor_id = $1
-	  AND booking_date = $2
 	  AND status IN ('pending','confirmed')
-	  AND start_time < $4
-	  AND end_time > $3
+	  AND blocked_from < $3::timestamptz + make_interval(mins => GREATEST($4::int, min_gap_minutes))
+	  AND blocked_until + make_interval(mins => GREATEST($4::int, min_gap_minutes)) > $2::timestamptz
+	  AND id <> $5
 	FOR UPDATE
 	LIMIT 1
 	`
@@ -85,9 +118,10 @@ func (r *BookingRepository) HasConflictTx(
 		ctx,
 		query,
 		mentorID,
-		bookingDate,
-		start,
-		end,
+		blockedFrom,
+		blockedUntil,
+		minGapMinutes,
+		excludeBookingID,
 	)
 
 	var dummy int
@@ -104,6 +138,59 @@ func (r *BookingRepository) HasConflictTx(
 	return true, nil
 }
 
+// CountForServiceDayTx counts the blocking bookings of a service on a
+// mentor-local date, ignoring excludeBookingID.
+func (r *BookingRepository) CountForServiceDayTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	serviceID uuid.UUID,
+	bookingDate time.Time,
+	excludeBookingID uuid.UUID,
+) (int, error) {
+
+	const query = `
+	SELECT COUNT(*)
+	FROM bookings
+	WHERE service_id = $1
+	  AND booking_date = $2
+	  AND status IN ('pending','confirmed')
+	  AND id <> $3
+	`
+
+	var n int
+	err := tx.QueryRowContext(ctx, query, serviceID, bookingDate, excludeBookingID).Scan(&n)
+
+	return n, err
+}
+
+// CountFreeForUserTx counts a mentee's free bookings of a service that
+// are upcoming or were held. Cancelled and expired bookings, sessions the
+// mentor missed and bookings paid with a package credit or coupon don't
+// count.
+func (r *BookingRepository) CountFreeForUserTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	serviceID uuid.UUID,
+	userID uuid.UUID,
+) (int, error) {
+
+	const query = `
+	SELECT COUNT(*)
+	FROM bookings b
+	WHERE b.service_id = $1
+	  AND b.user_id = $2
+	  AND b.price_cents = 0
+	  AND b.status IN ('pending','confirmed','completed','no_show_user')
+	  AND NOT EXISTS (SELECT 1 FROM session_credits c WHERE c.booking_id = b.id)
+	  AND NOT EXISTS (SELECT 1 FROM coupon_redemptions r WHERE r.booking_id = b.id)
+	`
+
+	var n int
+	err := tx.QueryRowContext(ctx, query, serviceID, userID).Scan(&n)
+
+	return n, err
+}
+
 func (r *BookingRepository) CreateTx(
 	ctx context.Context,
 	tx *sql.Tx,
@@ -122,10 +209,13 @@ func (r *BookingRepository) CreateTx(
 		status,
 		price_cents,
 		currency,
+		blocked_from,
+		blocked_until,
+		min_gap_minutes,
 		created_at,
 		updated_at
 	)
-	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,NOW(),NOW())
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,NOW(),NOW())
 	`
 
 	_, err := tx.ExecContext(
@@ -141,6 +231,9 @@ func (r *BookingRepository) CreateTx(
 		b.Status,
 		b.PriceCents,
 		b.Currency,
+		b.BlockedFrom,
+		b.BlockedUntil,
+		b.MinGapMinutes,
 	)
 
 	return err
@@ -170,23 +263,23 @@ func (r *BookingRepository) GetByUserID(
 	userID uuid.UUID,
 ) ([]*dtos.MyBookingResponse, error) {
 
-	const query = `
+	query := `
 	SELECT
 		b.id,
 		u.username AS mentor_username,
 		s.title AS service_title,
-		b.booking_date,
 		b.start_time,
 		b.end_time,
 		b.status,
 		b.price_cents,
-		b.currency
+		b.currency,
+		mp.timezone,` + attendanceColumns + `
 	FROM bookings b
 	JOIN mentor_profiles mp ON mp.id = b.mentor_id
 	JOIN users u ON u.id = mp.user_id
-	JOIN mentor_services s ON s.id = b.service_id
+	JOIN mentor_services s ON s.id = b.service_id` + attendanceJoin + `
 	WHERE b.user_id = $1
-	ORDER BY b.booking_date DESC, b.start_time DESC
+	ORDER BY b.start_time DESC
 	`
 
 	rows, err := r.db.Query(query, userID)
@@ -199,42 +292,39 @@ func (r *BookingRepository) GetByUserID(
 
 	for rows.Next() {
 		var r dtos.MyBookingResponse
-		var date time.Time
-		var start time.Time
-		var end time.Time
 
 		err := rows.Scan(
 			&r.ID,
 			&r.Mentor,
 			&r.Service,
-			&date,
-			&start,
-			&end,
+			&r.StartAt,
+			&r.EndAt,
 			&r.Status,
 			&r.Price,
 			&r.Currency,
+			&r.Timezone,
+			&r.Attendance.MenteeJoinedAt,
+			&r.Attendance.MenteeLeftAt,
+			&r.Attendance.MentorJoinedAt,
+			&r.Attendance.MentorLeftAt,
 		)
 		if err != nil {
 			return nil, err
 		}
 
-		r.Date = date.Format("2006-01-02")
-		r.StartTime = start.Format("15:04")
-		r.EndTime = end.Format("15:04")
+		// Date and times are shown on the mentor's wall clock
+		loc := mentorLocation(r.Timezone)
+		r.Date = r.StartAt.In(loc).Format("2006-01-02")
+		r.StartTime = r.StartAt.In(loc).Format("15:04")
+		r.EndTime = r.EndAt.In(loc).Format("15:04")
 
 		result = append(result, &r)
 	}
 
-	return result, nil
+	return result, rows.Err()
 }
 
-func (r *BookingRepository) GetByID(
-	ctx context.Context,
-	id uuid.UUID,
-) (*models.Booking, error) {
-
-	const query = `
-	SELECT
+const bookingColumns = `
 		id,
 		mentor_id,
 		user_id,
@@ -242,19 +332,24 @@ func (r *BookingRepository) GetByID(
 		booking_date,
 		start_time,
 		end_time,
+		blocked_from,
+		blocked_until,
+		min_gap_minutes,
 		status,
 		price_cents,
 		currency,
+		cancelled_at,
+		cancelled_by,
+		cancellation_reason,
+		refund_cents,
 		created_at,
 		updated_at
-	FROM bookings
-	WHERE id = $1
-	LIMIT 1
-	`
+`
 
+func scanBooking(row *sql.Row) (*models.Booking, error) {
 	var b models.Booking
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := row.Scan(
 		&b.ID,
 		&b.MentorID,
 		&b.UserID,
@@ -262,15 +357,22 @@ func (r *BookingRepository) GetByID(
 		&b.BookingDate,
 		&b.StartTime,
 		&b.EndTime,
+		&b.BlockedFrom,
+		&b.BlockedUntil,
+		&b.MinGapMinutes,
 		&b.Status,
 		&b.PriceCents,
 		&b.Currency,
+		&b.CancelledAt,
+		&b.CancelledBy,
+		&b.CancellationReason,
+		&b.RefundCents,
 		&b.CreatedAt,
 		&b.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, errors.New("booking not found")
+		return nil, ErrBookingNotFound
 	}
 
 	if err != nil {
@@ -280,6 +382,37 @@ func (r *BookingRepository) GetByID(
 	return &b, nil
 }
 
+func (r *BookingRepository) GetByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (*models.Booking, error) {
+
+	query := `SELECT` + bookingColumns + `
+	FROM bookings
+	WHERE id = $1
+	LIMIT 1
+	`
+
+	return scanBooking(r.db.QueryRowContext(ctx, query, id))
+}
+
+// GetByIDForUpdateTx loads a booking and locks its row until the
+// surrounding transaction finishes.
+func (r *BookingRepository) GetByIDForUpdateTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	id uuid.UUID,
+) (*models.Booking, error) {
+
+	query := `SELECT` + bookingColumns + `
+	FROM bookings
+	WHERE id = $1
+	FOR UPDATE
+	`
+
+	return scanBooking(tx.QueryRowContext(ctx, query, id))
+}
+
 func (r *BookingRepository) MarkConfirmed(
 	ctx context.Context,
 	tx *sql.Tx,
@@ -318,28 +451,51 @@ func (r *BookingRepository) MarkConfirmed(
 	return nil
 }
 
+// MarkPaymentFailed fails a booking that is still awaiting payment. A
+// booking another attempt already paid for is left alone.
+func (r *BookingRepository) MarkPaymentFailed(
+	ctx context.Context,
+	tx *sql.Tx,
+	bookingID uuid.UUID,
+) error {
+
+	const query = `
+		UPDATE bookings
+		SET status = 'payment_failed',
+		    updated_at = now()
+		WHERE id = $1
+		  AND status = $2
+	`
+
+	_, err := tx.ExecContext(ctx, query, bookingID, models.BookingStatusPending)
+	return err
+}
+
 func (r *BookingRepository) GetByMentorIDConfirmed(
 	mentorID uuid.UUID,
 ) ([]*dtos.MentorBookedSessionResponse, error) {
 
 	// Return bookings for the mentor. Include both pending and confirmed
-	// bookings so mentors see newly created bookings immediately.
-	const query = `
+	// bookings so mentors see newly created bookings immediately, and
+	// settled sessions so they can see attendance.
+	query := `
 	SELECT
 		b.id,
 		u.username AS user_username,
 		s.title AS service_title,
-		b.booking_date,
 		b.start_time,
 		b.end_time,
+		b.status,
 		b.price_cents,
-		b.currency
+		b.currency,
+		mp.timezone,` + attendanceColumns + `
 	FROM bookings b
+	JOIN mentor_profiles mp ON mp.id = b.mentor_id
 	JOIN users u ON u.id = b.user_id
-	JOIN mentor_services s ON s.id = b.service_id
+	JOIN mentor_services s ON s.id = b.service_id` + attendanceJoin + `
 	WHERE b.mentor_id = $1
-	  AND b.status IN ('pending','confirmed')
-	ORDER BY b.booking_date DESC, b.start_time DESC
+	  AND b.status IN ('pending','confirmed','completed','no_show_mentor','no_show_user')
+	ORDER BY b.start_time DESC
 	`
 
 	rows, err := r.db.Query(query, mentorID)
@@ -352,32 +508,70 @@ func (r *BookingRepository) GetByMentorIDConfirmed(
 
 	for rows.Next() {
 		var resp dtos.MentorBookedSessionResponse
-		var date time.Time
-		var start time.Time
-		var end time.Time
 
 		err := rows.Scan(
 			&resp.ID,
 			&resp.UserUsername,
 			&resp.ServiceTitle,
-			&date,
-			&start,
-			&end,
+			&resp.StartAt,
+			&resp.EndAt,
+			&resp.Status,
 			&resp.PriceCents,
 			&resp.Currency,
+			&resp.Timezone,
+			&resp.Attendance.MenteeJoinedAt,
+			&resp.Attendance.MenteeLeftAt,
+			&resp.Attendance.MentorJoinedAt,
+			&resp.Attendance.MentorLeftAt,
 		)
 		if err != nil {
 			return nil, err
 		}
 
-		resp.BookingDate = date.Format("2006-01-02")
-		resp.StartTime = start.Format("15:04")
-		resp.EndTime = end.Format("15:04")
+		formatMentorSession(&resp)
 
 		result = append(result, &resp)
 	}
 
-	return result, nil
+	return result, rows.Err()
+}
+
+// attendanceJoin aggregates the session events of booking b into the
+// columns listed by attendanceColumns.
+const attendanceJoin = `
+	LEFT JOIN LATERAL (
+		SELECT
+			MIN(e.occurred_at) FILTER (WHERE e.role = 'mentee' AND e.event_type = 'joined') AS mentee_joined_at,
+			MAX(e.occurred_at) FILTER (WHERE e.role = 'mentee' AND e.event_type = 'left') AS mentee_left_at,
+			MIN(e.occurred_at) FILTER (WHERE e.role = 'mentor' AND e.event_type = 'joined') AS mentor_joined_at,
+			MAX(e.occurred_at) FILTER (WHERE e.role = 'mentor' AND e.event_type = 'left') AS mentor_left_at
+		FROM session_events e
+		WHERE e.booking_id = b.id
+	) att ON true`
+
+const attendanceColumns = `
+		att.mentee_joined_at,
+		att.mentee_left_at,
+		att.mentor_joined_at,
+		att.mentor_left_at`
+
+// mentorLocation resolves a mentor's stored timezone, falling back to UTC
+// for profiles created before timezones were validated.
+func mentorLocation(timezone string) *time.Location {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// formatMentorSession fills the display fields of a session from its
+// absolute start and end in the mentor's timezone.
+func formatMentorSession(resp *dtos.MentorBookedSessionResponse) {
+	loc := mentorLocation(resp.Timezone)
+	resp.BookingDate = resp.StartAt.In(loc).Format("2006-01-02")
+	resp.StartTime = resp.StartAt.In(loc).Format("15:04")
+	resp.EndTime = resp.EndAt.In(loc).Format("15:04")
 }
 
 // GetByMentorByUserID finds bookings for a mentor using the mentor's user_id
@@ -392,18 +586,19 @@ func (r *BookingRepository) GetByMentorByUserID(
 		b.id,
 		u.username AS user_username,
 		s.title AS service_title,
-		b.booking_date,
 		b.start_time,
 		b.end_time,
+		b.status,
 		b.price_cents,
-		b.currency
+		b.currency,
+		mp.timezone,` + attendanceColumns + `
 	FROM bookings b
 	JOIN mentor_profiles mp ON mp.id = b.mentor_id
 	JOIN users u ON u.id = b.user_id
-	JOIN mentor_services s ON s.id = b.service_id
+	JOIN mentor_services s ON s.id = b.service_id` + attendanceJoin + `
 	WHERE mp.user_id = $1
-	  AND b.status IN ('pending','confirmed')
-	ORDER BY b.booking_date DESC, b.start_time DESC
+	  AND b.status IN ('pending','confirmed','completed','no_show_mentor','no_show_user')
+	ORDER BY b.start_time DESC
 	`
 
 	rows, err := r.db.Query(query, userID)
@@ -416,26 +611,26 @@ func (r *BookingRepository) GetByMentorByUserID(
 
 	for rows.Next() {
 		var resp dtos.MentorBookedSessionResponse
-		var date time.Time
-		var start time.Time
-		var end time.Time
 
 		if err := rows.Scan(
 			&resp.ID,
 			&resp.UserUsername,
 			&resp.ServiceTitle,
-			&date,
-			&start,
-			&end,
+			&resp.StartAt,
+			&resp.EndAt,
+			&resp.Status,
 			&resp.PriceCents,
 			&resp.Currency,
+			&resp.Timezone,
+			&resp.Attendance.MenteeJoinedAt,
+			&resp.Attendance.MenteeLeftAt,
+			&resp.Attendance.MentorJoinedAt,
+			&resp.Attendance.MentorLeftAt,
 		); err != nil {
 			return nil, err
 		}
 
-		resp.BookingDate = date.Format("2006-01-02")
-		resp.StartTime = start.Format("15:04")
-		resp.EndTime = end.Format("15:04")
+		formatMentorSession(&resp)
 
 		result = append(result, &resp)
 	}
@@ -457,3 +652,291 @@ func (r *BookingRepository) UpdateStatus(
 	_, err := r.db.ExecContext(ctx, query, status, bookingID)
 	return err
 }
+
+// MarkCancelledTx moves a pending or confirmed booking to cancelled and
+// records who cancelled it and the refund decided by the mentor's policy.
+func (r *BookingRepository) MarkCancelledTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	bookingID uuid.UUID,
+	cancelledBy uuid.UUID,
+	reason string,
+	refundCents int,
+) error {
+
+	const query = `
+	UPDATE bookings
+	SET
+		status = $2,
+		cancelled_at = NOW(),
+		cancelled_by = $3,
+		cancellation_reason = NULLIF($4, ''),
+		refund_cents = $5,
+		updated_at = NOW()
+	WHERE id = $1
+	  AND status IN ('pending','confirmed')
+	`
+
+	result, err := tx.ExecContext(
+		ctx,
+		query,
+		bookingID,
+		models.BookingStatusCancelled,
+		cancelledBy,
+		reason,
+		refundCents,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("booking cannot be cancelled in its current state")
+	}
+
+	return nil
+}
+
+// RescheduleTx moves a pending or confirmed booking to a new slot. The
+// price and status are left untouched.
+func (r *BookingRepository) RescheduleTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	bookingID uuid.UUID,
+	bookingDate time.Time,
+	start time.Time,
+	end time.Time,
+	blockedFrom time.Time,
+	blockedUntil time.Time,
+) error {
+
+	const query = `
+	UPDATE bookings
+	SET
+		booking_date = $2,
+		start_time = $3,
+		end_time = $4,
+		blocked_from = $5,
+		blocked_until = $6,
+		updated_at = NOW()
+	WHERE id = $1
+	  AND status IN ('pending','confirmed')
+	`
+
+	result, err := tx.ExecContext(
+		ctx,
+		query,
+		bookingID,
+		bookingDate,
+		start,
+		end,
+		blockedFrom,
+		blockedUntil,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("booking cannot be rescheduled in its current state")
+	}
+
+	return nil
+}
+
+// MarkRefundedTx records the amount actually returned to the mentee once
+// the gateway confirms a refund for a cancelled booking.
+func (r *BookingRepository) MarkRefundedTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	bookingID uuid.UUID,
+	refundCents int,
+) error {
+
+	const query = `
+	UPDATE bookings
+	SET
+		status = $2,
+		refund_cents = $3,
+		updated_at = NOW()
+	WHERE id = $1
+	  AND status IN ('cancelled','refunded')
+	`
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		bookingID,
+		models.BookingStatusRefunded,
+		refundCents,
+	)
+
+	return err
+}
+
+// ExpireUnpaidPending marks pending bookings created before cutoff as
+// expired when no payment for them has been captured, which releases
+// their slot. Bookings with a payment attempt opened since orderCutoff
+// are skipped while the mentee may still be paying it. The expired
+// bookings' open attempts are superseded in the same statement, so a
+// capture that arrives later is refunded instead of confirming them. It
+// returns the IDs of the expired bookings.
+func (r *BookingRepository) ExpireUnpaidPending(
+	ctx context.Context,
+	cutoff time.Time,
+	orderCutoff time.Time,
+) ([]uuid.UUID, error) {
+
+	const query = `
+	WITH expired AS (
+		UPDATE bookings b
+		SET
+			status = $1,
+			updated_at = NOW()
+		WHERE b.status = $2
+		  AND b.created_at < $3
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM payments p
+			WHERE p.booking_id = b.id
+			  AND (
+				p.status = 'paid'
+				OR (p.status = 'created' AND p.created_at >= $4)
+			  )
+		  )
+		RETURNING b.id
+	), superseded AS (
+		UPDATE payments p
+		SET
+			status = 'superseded',
+			updated_at = NOW()
+		FROM expired e
+		WHERE p.booking_id = e.id
+		  AND p.status = 'created'
+	)
+	SELECT id FROM expired
+	`
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		query,
+		models.BookingStatusExpired,
+		models.BookingStatusPending,
+		cutoff,
+		orderCutoff,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// SettledSession is a booking whose status was decided by
+// SettleEndedSessions.
+type SettledSession struct {
+	BookingID uuid.UUID
+	Status    models.BookingStatus
+	// AttendanceSource is the models.SessionEventSource* the outcome was
+	// decided from
+	AttendanceSource string
+}
+
+// SettleEndedSessions settles confirmed bookings that ended before
+// cutoff from their recorded attendance: completed when both
+// participants joined, no_show_mentor when the mentor never joined and
+// no_show_user when only the mentee stayed away. Attendance comes from
+// the ZEGOCLOUD callbacks; events the clients posted themselves only
+// count for bookings without any, and such bookings are labelled with
+// attendance_source 'client'.
+func (r *BookingRepository) SettleEndedSessions(
+	ctx context.Context,
+	cutoff time.Time,
+) ([]SettledSession, error) {
+
+	const query = `
+	UPDATE bookings b
+	SET
+		status = CASE
+			WHEN NOT EXISTS (
+				SELECT 1 FROM session_events e
+				WHERE e.booking_id = b.id AND e.source = src.source
+				  AND e.role = 'mentor' AND e.event_type = 'joined'
+			) THEN $1
+			WHEN NOT EXISTS (
+				SELECT 1 FROM session_events e
+				WHERE e.booking_id = b.id AND e.source = src.source
+				  AND e.role = 'mentee' AND e.event_type = 'joined'
+			) THEN $2
+			ELSE $3
+		END,
+		attendance_source = src.source,
+		updated_at = NOW()
+	FROM (
+		SELECT
+			eb.id,
+			CASE
+				WHEN EXISTS (
+					SELECT 1 FROM session_events z
+					WHERE z.booking_id = eb.id AND z.source = $6
+				) THEN $6
+				ELSE $7
+			END AS source
+		FROM bookings eb
+		WHERE eb.status = $4
+		  AND eb.end_time < $5
+	) src
+	WHERE b.id = src.id
+	  AND b.status = $4
+	RETURNING b.id, b.status, b.attendance_source
+	`
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		query,
+		models.BookingStatusNoShowMentor,
+		models.BookingStatusNoShowUser,
+		models.BookingStatusCompleted,
+		models.BookingStatusConfirmed,
+		cutoff,
+		models.SessionEventSourceZego,
+		models.SessionEventSourceClient,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var settled []SettledSession
+
+	for rows.Next() {
+		var s SettledSession
+		if err := rows.Scan(&s.BookingID, &s.Status, &s.AttendanceSource); err != nil {
+			return nil, err
+		}
+		settled = append(settled, s)
+	}
+
+	return settled, rows.Err()
+}
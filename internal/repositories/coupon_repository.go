@@ -0,0 +1,293 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+)
+
+var (
+	ErrCouponNotFound  = errors.New("coupon not found")
+	ErrCouponCodeTaken = errors.New("coupon code already exists")
+)
+
+type CouponRepository struct {
+	db *sql.DB
+}
+
+func NewCouponRepository(db *sql.DB) *CouponRepository {
+	return &CouponRepository{db: db}
+}
+
+const couponColumns = `
+	c.id,
+	c.code,
+	c.mentor_id,
+	c.service_id,
+	c.discount_type,
+	c.percent_off,
+	c.amount_off_cents,
+	c.currency,
+	c.max_redemptions,
+	c.max_redemptions_per_user,
+	c.starts_at,
+	c.expires_at,
+	c.is_active,
+	c.created_by,
+	c.created_at,
+	c.updated_at
+`
+
+// heldRedemptions is the join condition for redemptions that count
+// towards a coupon's limits: those whose booking was not cancelled,
+// refunded, expired or left unpaid.
+const heldRedemptions = `
+	JOIN bookings b ON b.id = r.booking_id
+	WHERE b.status NOT IN ('cancelled','refunded','expired','payment_failed')
+`
+
+// couponRedemptionCount selects the held redemptions of coupon c
+const couponRedemptionCount = `
+	(SELECT COUNT(*) FROM coupon_redemptions r ` + heldRedemptions + `
+	   AND r.coupon_id = c.id)
+`
+
+func couponScanDest(c *models.Coupon) []any {
+	return []any{
+		&c.ID,
+		&c.Code,
+		&c.MentorID,
+		&c.ServiceID,
+		&c.DiscountType,
+		&c.PercentOff,
+		&c.AmountOffCents,
+		&c.Currency,
+		&c.MaxRedemptions,
+		&c.MaxRedemptionsPerUser,
+		&c.StartsAt,
+		&c.ExpiresAt,
+		&c.IsActive,
+		&c.CreatedBy,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	}
+}
+
+// Create stores a coupon. Returns ErrCouponCodeTaken when the code is in
+// use by any mentor or the platform.
+func (r *CouponRepository) Create(
+	ctx context.Context,
+	c *models.Coupon,
+) error {
+
+	const query = `
+	INSERT INTO coupons (
+		id,
+		code,
+		mentor_id,
+		service_id,
+		discount_type,
+		percent_off,
+		amount_off_cents,
+		currency,
+		max_redemptions,
+		max_redemptions_per_user,
+		starts_at,
+		expires_at,
+		is_active,
+		created_by,
+		created_at,
+		updated_at
+	)
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,true,$13,NOW(),NOW())
+	ON CONFLICT (code) DO NOTHING
+	RETURNING created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		c.ID,
+		c.Code,
+		c.MentorID,
+		c.ServiceID,
+		c.DiscountType,
+		c.PercentOff,
+		c.AmountOffCents,
+		c.Currency,
+		c.MaxRedemptions,
+		c.MaxRedemptionsPerUser,
+		c.StartsAt,
+		c.ExpiresAt,
+		c.CreatedBy,
+	).Scan(&c.CreatedAt, &c.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCouponCodeTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	c.IsActive = true
+	return nil
+}
+
+// FindByMentorID lists a mentor's coupons, or the platform's when
+// mentorID is nil, newest first with their held redemptions.
+func (r *CouponRepository) FindByMentorID(
+	ctx context.Context,
+	mentorID *uuid.UUID,
+) ([]*models.Coupon, error) {
+
+	query := `
+	SELECT ` + couponColumns + `, ` + couponRedemptionCount + `
+	FROM coupons c
+	WHERE c.mentor_id IS NOT DISTINCT FROM $1
+	ORDER BY c.created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, mentorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var coupons []*models.Coupon
+	for rows.Next() {
+		var c models.Coupon
+		if err := rows.Scan(append(couponScanDest(&c), &c.Redemptions)...); err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, &c)
+	}
+
+	return coupons, rows.Err()
+}
+
+// GetByID loads a coupon with its held redemptions.
+func (r *CouponRepository) GetByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (*models.Coupon, error) {
+
+	query := `
+	SELECT ` + couponColumns + `, ` + couponRedemptionCount + `
+	FROM coupons c
+	WHERE c.id = $1
+	`
+
+	var c models.Coupon
+	err := r.db.QueryRowContext(ctx, query, id).Scan(append(couponScanDest(&c), &c.Redemptions)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCouponNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// GetByCodeForUpdateTx loads a coupon by its upper-case code and locks it,
+// so concurrent bookings check its limits one at a time.
+func (r *CouponRepository) GetByCodeForUpdateTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	code string,
+) (*models.Coupon, error) {
+
+	query := `SELECT ` + couponColumns + ` FROM coupons c WHERE c.code = $1 FOR UPDATE`
+
+	var c models.Coupon
+	err := tx.QueryRowContext(ctx, query, code).Scan(couponScanDest(&c)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCouponNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (r *CouponRepository) SetActive(
+	ctx context.Context,
+	id uuid.UUID,
+	active bool,
+) error {
+
+	const query = `
+	UPDATE coupons
+	SET is_active = $2,
+		updated_at = NOW()
+	WHERE id = $1
+	`
+
+	res, err := r.db.ExecContext(ctx, query, id, active)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(res, ErrCouponNotFound)
+}
+
+// CountRedemptionsTx counts the coupon's held redemptions, in total and
+// by one mentee.
+func (r *CouponRepository) CountRedemptionsTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	couponID uuid.UUID,
+	userID uuid.UUID,
+) (int, int, error) {
+
+	query := `
+	SELECT
+		COUNT(*),
+		COUNT(*) FILTER (WHERE r.user_id = $2)
+	FROM coupon_redemptions r
+	` + heldRedemptions + `
+	  AND r.coupon_id = $1
+	`
+
+	var total, byUser int
+	err := tx.QueryRowContext(ctx, query, couponID, userID).Scan(&total, &byUser)
+
+	return total, byUser, err
+}
+
+func (r *CouponRepository) CreateRedemptionTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	red *models.CouponRedemption,
+) error {
+
+	const query = `
+	INSERT INTO coupon_redemptions (
+		id,
+		coupon_id,
+		booking_id,
+		user_id,
+		list_price_cents,
+		discount_cents,
+		currency,
+		created_at
+	)
+	VALUES ($1,$2,$3,$4,$5,$6,$7,NOW())
+	RETURNING created_at
+	`
+
+	return tx.QueryRowContext(
+		ctx,
+		query,
+		red.ID,
+		red.CouponID,
+		red.BookingID,
+		red.UserID,
+		red.ListPriceCents,
+		red.DiscountCents,
+		red.Currency,
+	).Scan(&red.CreatedAt)
+}
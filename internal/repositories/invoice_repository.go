@@ -0,0 +1,265 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+)
+
+var ErrInvoiceNotFound = errors.New("invoice not found")
+
+type InvoiceRepository struct {
+	db *sql.DB
+}
+
+func NewInvoiceRepository(db *sql.DB) *InvoiceRepository {
+	return &InvoiceRepository{db: db}
+}
+
+// NextNumberTx takes the next number of a series in a financial year.
+// The sequence row stays locked until tx ends, so concurrent invoices
+// are numbered one after another and a rollback gives the number back.
+func (r *InvoiceRepository) NextNumberTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	series string,
+	financialYear string,
+) (int, error) {
+
+	const query = `
+	INSERT INTO invoice_sequences (series, financial_year, last_number)
+	VALUES ($1, $2, 1)
+	ON CONFLICT (series, financial_year)
+	DO UPDATE SET last_number = invoice_sequences.last_number + 1
+	RETURNING last_number
+	`
+
+	var n int
+	err := tx.QueryRowContext(ctx, query, series, financialYear).Scan(&n)
+
+	return n, err
+}
+
+// GetPartyTx loads who booked which service from whom, for the invoice
+// of bookingID.
+func (r *InvoiceRepository) GetPartyTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	bookingID uuid.UUID,
+) (*models.InvoiceParty, error) {
+
+	const query = `
+	SELECT
+		b.id,
+		b.user_id,
+		b.mentor_id,
+		m.user_id,
+		u.first_name || ' ' || u.last_name,
+		u.email,
+		mu.first_name || ' ' || mu.last_name,
+		s.title,
+		b.booking_date
+	FROM bookings b
+	JOIN users u ON u.id = b.user_id
+	JOIN mentor_profiles m ON m.id = b.mentor_id
+	JOIN users mu ON mu.id = m.user_id
+	JOIN mentor_services s ON s.id = b.service_id
+	WHERE b.id = $1
+	`
+
+	var p models.InvoiceParty
+	err := tx.QueryRowContext(ctx, query, bookingID).Scan(
+		&p.BookingID,
+		&p.UserID,
+		&p.MentorID,
+		&p.MentorUserID,
+		&p.BuyerName,
+		&p.BuyerEmail,
+		&p.MentorName,
+		&p.ServiceTitle,
+		&p.BookingDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func (r *InvoiceRepository) CreateTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	inv *models.Invoice,
+) error {
+
+	const query = `
+	INSERT INTO invoices (
+		id,
+		number,
+		kind,
+		financial_year,
+		sequence,
+		booking_id,
+		payment_id,
+		user_id,
+		mentor_id,
+		supplier_name,
+		supplier_address,
+		supplier_gstin,
+		supplier_state,
+		buyer_name,
+		buyer_email,
+		billing_state,
+		place_of_supply,
+		description,
+		sac,
+		payment_ref,
+		currency,
+		taxable_cents,
+		gst_rate_percent,
+		cgst_cents,
+		sgst_cents,
+		igst_cents,
+		total_cents,
+		issued_at
+	)
+	VALUES (
+		$1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,
+		$15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25,$26,$27,$28
+	)
+	`
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		inv.ID,
+		inv.Number,
+		inv.Kind,
+		inv.FinancialYear,
+		inv.Sequence,
+		inv.BookingID,
+		inv.PaymentID,
+		inv.UserID,
+		inv.MentorID,
+		inv.SupplierName,
+		inv.SupplierAddress,
+		inv.SupplierGSTIN,
+		inv.SupplierState,
+		inv.BuyerName,
+		inv.BuyerEmail,
+		inv.BillingState,
+		inv.PlaceOfSupply,
+		inv.Description,
+		inv.SAC,
+		inv.PaymentRef,
+		inv.Currency,
+		inv.TaxableCents,
+		inv.GSTRatePercent,
+		inv.CGSTCents,
+		inv.SGSTCents,
+		inv.IGSTCents,
+		inv.TotalCents,
+		inv.IssuedAt,
+	)
+
+	return err
+}
+
+// ExistsForBookingTx reports whether the booking was already invoiced,
+// e.g. because an earlier payment attempt was captured too.
+func (r *InvoiceRepository) ExistsForBookingTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	bookingID uuid.UUID,
+) (bool, error) {
+
+	const query = `SELECT EXISTS (SELECT 1 FROM invoices WHERE booking_id = $1)`
+
+	var exists bool
+	err := tx.QueryRowContext(ctx, query, bookingID).Scan(&exists)
+
+	return exists, err
+}
+
+func (r *InvoiceRepository) GetByBookingID(
+	ctx context.Context,
+	bookingID uuid.UUID,
+) (*models.Invoice, error) {
+
+	const query = `
+	SELECT
+		id,
+		number,
+		kind,
+		financial_year,
+		sequence,
+		booking_id,
+		payment_id,
+		user_id,
+		mentor_id,
+		supplier_name,
+		supplier_address,
+		supplier_gstin,
+		supplier_state,
+		buyer_name,
+		buyer_email,
+		billing_state,
+		place_of_supply,
+		description,
+		sac,
+		payment_ref,
+		currency,
+		taxable_cents,
+		gst_rate_percent,
+		cgst_cents,
+		sgst_cents,
+		igst_cents,
+		total_cents,
+		issued_at
+	FROM invoices
+	WHERE booking_id = $1
+	`
+
+	var inv models.Invoice
+	err := r.db.QueryRowContext(ctx, query, bookingID).Scan(
+		&inv.ID,
+		&inv.Number,
+		&inv.Kind,
+		&inv.FinancialYear,
+		&inv.Sequence,
+		&inv.BookingID,
+		&inv.PaymentID,
+		&inv.UserID,
+		&inv.MentorID,
+		&inv.SupplierName,
+		&inv.SupplierAddress,
+		&inv.SupplierGSTIN,
+		&inv.SupplierState,
+		&inv.BuyerName,
+		&inv.BuyerEmail,
+		&inv.BillingState,
+		&inv.PlaceOfSupply,
+		&inv.Description,
+		&inv.SAC,
+		&inv.PaymentRef,
+		&inv.Currency,
+		&inv.TaxableCents,
+		&inv.GSTRatePercent,
+		&inv.CGSTCents,
+		&inv.SGSTCents,
+		&inv.IGSTCents,
+		&inv.TotalCents,
+		&inv.IssuedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvoiceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &inv, nil
+}
@@ -0,0 +1,442 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+)
+
+type LedgerRepository struct {
+	db *sql.DB
+}
+
+func NewLedgerRepository(db *sql.DB) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+func (r *LedgerRepository) WithTx(
+	ctx context.Context,
+	fn func(tx *sql.Tx) error,
+) error {
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateTransactionTx stores a transaction and its entries. It reports
+// false without writing anything when a transaction with the same
+// idempotency key already exists.
+func (r *LedgerRepository) CreateTransactionTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	t *models.LedgerTransaction,
+	entries []*models.LedgerEntry,
+) (bool, error) {
+
+	const txQuery = `
+	INSERT INTO ledger_transactions (
+		id,
+		kind,
+		idempotency_key,
+		mentor_id,
+		booking_id,
+		refund_id,
+		payout_id,
+		memo
+	)
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+	ON CONFLICT (idempotency_key) DO NOTHING
+	`
+
+	result, err := tx.ExecContext(
+		ctx,
+		txQuery,
+		t.ID,
+		t.Kind,
+		t.IdempotencyKey,
+		t.MentorID,
+		t.BookingID,
+		t.RefundID,
+		t.PayoutID,
+		t.Memo,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if rows == 0 {
+		return false, nil
+	}
+
+	const entryQuery = `
+	INSERT INTO ledger_entries (
+		id,
+		transaction_id,
+		account,
+		mentor_id,
+		direction,
+		amount_cents,
+		currency,
+		available_at
+	)
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+	`
+
+	for _, e := range entries {
+		if _, err := tx.ExecContext(
+			ctx,
+			entryQuery,
+			e.ID,
+			t.ID,
+			e.Account,
+			e.MentorID,
+			e.Direction,
+			e.AmountCents,
+			e.Currency,
+			e.AvailableAt,
+		); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// UnpostedEarning is a completed booking whose earnings are not in the
+// ledger yet. GrossCents is what the mentee paid for the session, net of
+// refunds; for package sessions it is the purchase price per session.
+// PromotionCents is the discount of a platform coupon, which the platform
+// pays on the mentee's behalf.
+type UnpostedEarning struct {
+	BookingID      uuid.UUID
+	MentorID       uuid.UUID
+	EndTime        time.Time
+	GrossCents     int64
+	PromotionCents int64
+	Currency       string
+}
+
+func (r *LedgerRepository) FindUnpostedEarnings(
+	ctx context.Context,
+	limit int,
+) ([]UnpostedEarning, error) {
+
+	const query = `
+	SELECT id, mentor_id, end_time, gross, promotion, currency
+	FROM (
+		SELECT
+			b.id,
+			b.mentor_id,
+			b.end_time,
+			COALESCE(
+				(SELECT p.amount FROM payments p
+				 WHERE p.booking_id = b.id
+				   AND p.status IN ('paid', 'refund_pending', 'refunded')
+				   AND NOT EXISTS (
+					SELECT 1 FROM refunds urf
+					WHERE urf.payment_id = p.id
+					  AND urf.unfulfilled
+				   )
+				 ORDER BY p.created_at, p.id
+				 LIMIT 1),
+				(SELECT pp.price_cents / pp.session_count
+				 FROM session_credits sc
+				 JOIN package_purchases pp ON pp.id = sc.purchase_id
+				 WHERE sc.booking_id = b.id),
+				0
+			) - COALESCE(
+				(SELECT SUM(rf.amount) FROM refunds rf
+				 WHERE rf.booking_id = b.id
+				   AND rf.status = 'processed'
+				   AND NOT rf.unfulfilled),
+				0
+			) AS gross,
+			COALESCE(
+				(SELECT cr.discount_cents
+				 FROM coupon_redemptions cr
+				 JOIN coupons c ON c.id = cr.coupon_id
+				 WHERE cr.booking_id = b.id
+				   AND c.mentor_id IS NULL),
+				0
+			) AS promotion,
+			b.currency
+		FROM bookings b
+		WHERE b.status = $1
+		  AND NOT EXISTS (
+			SELECT 1 FROM ledger_transactions t
+			WHERE t.booking_id = b.id
+			  AND t.kind = $2
+		  )
+	) pending
+	WHERE gross + promotion > 0
+	LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		query,
+		models.BookingStatusCompleted,
+		models.LedgerKindSessionEarning,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []UnpostedEarning
+
+	for rows.Next() {
+		var e UnpostedEarning
+		if err := rows.Scan(
+			&e.BookingID,
+			&e.MentorID,
+			&e.EndTime,
+			&e.GrossCents,
+			&e.PromotionCents,
+			&e.Currency,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+
+	return result, rows.Err()
+}
+
+// GetEarningEntriesTx returns the entries of a booking's earnings
+// transaction, or none when its earnings were never posted.
+func (r *LedgerRepository) GetEarningEntriesTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	bookingID uuid.UUID,
+) ([]*models.LedgerEntry, error) {
+
+	const query = `
+	SELECT
+		e.id,
+		e.transaction_id,
+		e.account,
+		e.mentor_id,
+		e.direction,
+		e.amount_cents,
+		e.currency,
+		e.available_at,
+		e.created_at
+	FROM ledger_entries e
+	JOIN ledger_transactions t ON t.id = e.transaction_id
+	WHERE t.booking_id = $1
+	  AND t.kind = $2
+	`
+
+	rows, err := tx.QueryContext(ctx, query, bookingID, models.LedgerKindSessionEarning)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.LedgerEntry
+
+	for rows.Next() {
+		var e models.LedgerEntry
+		if err := rows.Scan(
+			&e.ID,
+			&e.TransactionID,
+			&e.Account,
+			&e.MentorID,
+			&e.Direction,
+			&e.AmountCents,
+			&e.Currency,
+			&e.AvailableAt,
+			&e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &e)
+	}
+
+	return entries, rows.Err()
+}
+
+// MentorBalances sums a mentor's payable account per currency, split by
+// whether the money has left its hold at now.
+func (r *LedgerRepository) MentorBalances(
+	ctx context.Context,
+	mentorID uuid.UUID,
+	now time.Time,
+) ([]dtos.EarningsBalance, error) {
+
+	const query = `
+	SELECT
+		e.currency,
+		COALESCE(SUM(CASE WHEN e.direction = 'credit' THEN e.amount_cents ELSE -e.amount_cents END)
+			FILTER (WHERE e.available_at > $3), 0) AS pending,
+		COALESCE(SUM(CASE WHEN e.direction = 'credit' THEN e.amount_cents ELSE -e.amount_cents END)
+			FILTER (WHERE e.available_at <= $3), 0) AS available,
+		COALESCE(SUM(e.amount_cents) FILTER (
+			WHERE e.direction = 'credit' AND t.kind = 'session_earning'
+		), 0) AS earned,
+		COALESCE(SUM(CASE WHEN e.direction = 'debit' THEN e.amount_cents ELSE -e.amount_cents END) FILTER (
+			WHERE t.kind IN ('payout', 'payout_failed')
+		), 0) AS paid_out
+	FROM ledger_entries e
+	JOIN ledger_transactions t ON t.id = e.transaction_id
+	WHERE e.mentor_id = $1
+	  AND e.account = $2
+	GROUP BY e.currency
+	ORDER BY e.currency
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, mentorID, models.LedgerAccountMentorPayable, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := []dtos.EarningsBalance{}
+
+	for rows.Next() {
+		var b dtos.EarningsBalance
+		if err := rows.Scan(
+			&b.Currency,
+			&b.PendingCents,
+			&b.AvailableCents,
+			&b.LifetimeEarnedCents,
+			&b.PaidOutCents,
+		); err != nil {
+			return nil, err
+		}
+		balances = append(balances, b)
+	}
+
+	return balances, rows.Err()
+}
+
+// MentorStatements sums a mentor's ledger per calendar month of timezone
+// and currency, newest month first.
+func (r *LedgerRepository) MentorStatements(
+	ctx context.Context,
+	mentorID uuid.UUID,
+	timezone string,
+) ([]dtos.EarningsStatement, error) {
+
+	const query = `
+	SELECT
+		to_char(date_trunc('month', e.created_at AT TIME ZONE $2), 'YYYY-MM') AS month,
+		e.currency,
+		COALESCE(SUM(e.amount_cents) FILTER (
+			WHERE e.account IN ('gateway_clearing', 'platform_promotions') AND e.direction = 'debit'
+		), 0) AS gross,
+		COALESCE(SUM(CASE WHEN e.direction = 'credit' THEN e.amount_cents ELSE -e.amount_cents END) FILTER (
+			WHERE e.account = 'platform_revenue'
+		), 0) AS commission,
+		COALESCE(SUM(e.amount_cents) FILTER (
+			WHERE e.account = 'mentor_payable' AND e.direction = 'debit' AND t.kind = 'refund'
+		), 0) AS refunds,
+		COALESCE(SUM(CASE WHEN e.direction = 'credit' THEN e.amount_cents ELSE -e.amount_cents END) FILTER (
+			WHERE e.account = 'mentor_payable' AND t.kind IN ('session_earning', 'refund')
+		), 0) AS net,
+		COALESCE(SUM(CASE WHEN e.direction = 'debit' THEN e.amount_cents ELSE -e.amount_cents END) FILTER (
+			WHERE e.account = 'mentor_payable' AND t.kind IN ('payout', 'payout_failed')
+		), 0) AS paid_out
+	FROM ledger_entries e
+	JOIN ledger_transactions t ON t.id = e.transaction_id
+	WHERE e.mentor_id = $1
+	GROUP BY month, e.currency
+	ORDER BY month DESC, e.currency
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, mentorID, timezone)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statements := []dtos.EarningsStatement{}
+
+	for rows.Next() {
+		var s dtos.EarningsStatement
+		if err := rows.Scan(
+			&s.Month,
+			&s.Currency,
+			&s.GrossCents,
+			&s.CommissionCents,
+			&s.RefundCents,
+			&s.NetCents,
+			&s.PaidOutCents,
+		); err != nil {
+			return nil, err
+		}
+		statements = append(statements, s)
+	}
+
+	return statements, rows.Err()
+}
+
+// MentorEntries lists every movement on a mentor's payable account,
+// oldest first.
+func (r *LedgerRepository) MentorEntries(
+	ctx context.Context,
+	mentorID uuid.UUID,
+) ([]dtos.EarningsEntry, error) {
+
+	const query = `
+	SELECT
+		e.created_at,
+		t.kind,
+		t.booking_id,
+		e.direction,
+		e.amount_cents,
+		e.currency,
+		e.available_at
+	FROM ledger_entries e
+	JOIN ledger_transactions t ON t.id = e.transaction_id
+	WHERE e.mentor_id = $1
+	  AND e.account = $2
+	ORDER BY e.created_at, e.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, mentorID, models.LedgerAccountMentorPayable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []dtos.EarningsEntry
+
+	for rows.Next() {
+		var e dtos.EarningsEntry
+		if err := rows.Scan(
+			&e.CreatedAt,
+			&e.Kind,
+			&e.BookingID,
+			&e.Direction,
+			&e.AmountCents,
+			&e.Currency,
+			&e.AvailableAt,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+)
+
+var ErrAvailabilityOverrideNotFound = errors.New("availability override not found")
+
+type MentorAvailabilityOverrideRepository struct {
+	db *sql.DB
+}
+
+func NewMentorAvailabilityOverrideRepository(db *sql.DB) *MentorAvailabilityOverrideRepository {
+	return &MentorAvailabilityOverrideRepository{db: db}
+}
+
+const availabilityOverrideColumns = `
+	id,
+	mentor_id,
+	override_date,
+	kind,
+	start_time,
+	end_time,
+	reason,
+	created_at,
+	updated_at
+`
+
+func scanAvailabilityOverride(row rowScanner) (*models.MentorAvailabilityOverride, error) {
+	var o models.MentorAvailabilityOverride
+
+	err := row.Scan(
+		&o.ID,
+		&o.MentorID,
+		&o.Date,
+		&o.Kind,
+		&o.StartTime,
+		&o.EndTime,
+		&o.Reason,
+		&o.CreatedAt,
+		&o.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &o, nil
+}
+
+func (r *MentorAvailabilityOverrideRepository) Create(
+	ctx context.Context,
+	o *models.MentorAvailabilityOverride,
+) error {
+
+	const query = `
+	INSERT INTO mentor_availability_overrides (
+		id,
+		mentor_id,
+		override_date,
+		kind,
+		start_time,
+		end_time,
+		reason,
+		created_at,
+		updated_at
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+	RETURNING created_at, updated_at
+	`
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		o.ID,
+		o.MentorID,
+		o.Date,
+		o.Kind,
+		o.StartTime,
+		o.EndTime,
+		o.Reason,
+	).Scan(&o.CreatedAt, &o.UpdatedAt)
+}
+
+// GetByID returns ErrAvailabilityOverrideNotFound when it does not exist.
+func (r *MentorAvailabilityOverrideRepository) GetByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (*models.MentorAvailabilityOverride, error) {
+
+	const query = `
+	SELECT` + availabilityOverrideColumns + `
+	FROM mentor_availability_overrides
+	WHERE id = $1
+	`
+
+	o, err := scanAvailabilityOverride(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAvailabilityOverrideNotFound
+	}
+
+	return o, err
+}
+
+func (r *MentorAvailabilityOverrideRepository) Delete(
+	ctx context.Context,
+	id uuid.UUID,
+) error {
+
+	res, err := r.db.ExecContext(
+		ctx,
+		`DELETE FROM mentor_availability_overrides WHERE id = $1`,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(res, ErrAvailabilityOverrideNotFound)
+}
+
+// FindByMentorBetween returns the mentor's overrides dated from..to,
+// both inclusive. The dates are mentor-local calendar days.
+func (r *MentorAvailabilityOverrideRepository) FindByMentorBetween(
+	mentorID uuid.UUID,
+	from time.Time,
+	to time.Time,
+) ([]*models.MentorAvailabilityOverride, error) {
+
+	const query = `
+	SELECT` + availabilityOverrideColumns + `
+	FROM mentor_availability_overrides
+	WHERE mentor_id = $1
+	  AND override_date BETWEEN $2::date AND $3::date
+	ORDER BY override_date, start_time NULLS FIRST
+	`
+
+	rows, err := r.db.Query(
+		query,
+		mentorID,
+		from.Format("2006-01-02"),
+		to.Format("2006-01-02"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var overrides []*models.MentorAvailabilityOverride
+
+	for rows.Next() {
+		o, err := scanAvailabilityOverride(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		overrides = append(overrides, o)
+	}
+
+	return overrides, rows.Err()
+}
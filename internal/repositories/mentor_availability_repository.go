@@ -3,23 +3,67 @@ package repositories
 import (
 	"context"
 	"database/sql"
-	"time"
+	"errors"
 
 	"github.com/google/uuid"
 	"github.com/preetsinghmakkar/OpenCall/internal/models"
 )
 
+var ErrAvailabilityRuleNotFound = errors.New("availability rule not found")
+
 type MentorAvailabilityRepository struct {
 	db *sql.DB
+
+	// reader serves read-only queries that tolerate replica lag
+	reader *sql.DB
 }
 
 func NewMentorAvailabilityRepository(db *sql.DB) *MentorAvailabilityRepository {
-	return &MentorAvailabilityRepository{db: db}
+	return &MentorAvailabilityRepository{db: db, reader: db}
+}
+
+// WithReader routes lag-tolerant read-only queries to reader, typically a
+// read replica. Writes and transactional reads stay on the primary.
+func (r *MentorAvailabilityRepository) WithReader(reader *sql.DB) *MentorAvailabilityRepository {
+	r.reader = reader
+	return r
+}
+
+// WithMentorLock runs fn in a transaction holding a row lock on the
+// mentor's profile, so concurrent schedule edits for one mentor are
+// applied one after another and overlap checks see each other's writes.
+func (r *MentorAvailabilityRepository) WithMentorLock(
+	ctx context.Context,
+	mentorID uuid.UUID,
+	fn func(tx *sql.Tx) error,
+) error {
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`SELECT 1 FROM mentor_profiles WHERE id = $1 FOR UPDATE`,
+		mentorID,
+	); err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (r *MentorAvailabilityRepository) Create(
+func (r *MentorAvailabilityRepository) CreateTx(
+	ctx context.Context,
+	tx *sql.Tx,
 	rule *models.MentorAvailabilityRule,
-) (*models.MentorAvailabilityRule, error) {
+) error {
 
 	const query = `
 	INSERT INTO mentor_availability_rules (
@@ -34,10 +78,7 @@ func (r *MentorAvailabilityRepository) Create(
 	VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
 	`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	_, err := r.db.ExecContext(
+	_, err := tx.ExecContext(
 		ctx,
 		query,
 		rule.ID,
@@ -46,11 +87,184 @@ func (r *MentorAvailabilityRepository) Create(
 		rule.StartTime,
 		rule.EndTime,
 	)
+
+	return err
+}
+
+func (r *MentorAvailabilityRepository) UpdateTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	rule *models.MentorAvailabilityRule,
+) error {
+
+	const query = `
+	UPDATE mentor_availability_rules
+	SET day_of_week = $2,
+		start_time = $3,
+		end_time = $4,
+		updated_at = NOW()
+	WHERE id = $1
+	`
+
+	res, err := tx.ExecContext(
+		ctx,
+		query,
+		rule.ID,
+		rule.DayOfWeek,
+		rule.StartTime,
+		rule.EndTime,
+	)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(res, ErrAvailabilityRuleNotFound)
+}
+
+func (r *MentorAvailabilityRepository) DeleteTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	ruleID uuid.UUID,
+) error {
+
+	res, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM mentor_availability_rules WHERE id = $1`,
+		ruleID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(res, ErrAvailabilityRuleNotFound)
+}
+
+// DeleteByMentorTx removes the mentor's whole weekly schedule.
+func (r *MentorAvailabilityRepository) DeleteByMentorTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	mentorID uuid.UUID,
+) error {
+
+	_, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM mentor_availability_rules WHERE mentor_id = $1`,
+		mentorID,
+	)
+
+	return err
+}
+
+// GetByID returns ErrAvailabilityRuleNotFound when the rule does not exist.
+func (r *MentorAvailabilityRepository) GetByID(
+	ctx context.Context,
+	ruleID uuid.UUID,
+) (*models.MentorAvailabilityRule, error) {
+
+	const query = `
+	SELECT` + availabilityRuleColumns + `
+	FROM mentor_availability_rules
+	WHERE id = $1
+	`
+
+	var rule models.MentorAvailabilityRule
+
+	err := r.db.QueryRowContext(ctx, query, ruleID).Scan(
+		&rule.ID,
+		&rule.MentorID,
+		&rule.DayOfWeek,
+		&rule.StartTime,
+		&rule.EndTime,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAvailabilityRuleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+const availabilityRuleColumns = `
+	id,
+	mentor_id,
+	day_of_week,
+	start_time,
+	end_time,
+	created_at,
+	updated_at
+`
+
+func scanAvailabilityRules(rows *sql.Rows) ([]*models.MentorAvailabilityRule, error) {
+	defer rows.Close()
+
+	var rules []*models.MentorAvailabilityRule
+
+	for rows.Next() {
+		var rule models.MentorAvailabilityRule
+
+		if err := rows.Scan(
+			&rule.ID,
+			&rule.MentorID,
+			&rule.DayOfWeek,
+			&rule.StartTime,
+			&rule.EndTime,
+			&rule.CreatedAt,
+			&rule.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, &rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// FindByMentorID lists the mentor's weekly schedule.
+func (r *MentorAvailabilityRepository) FindByMentorID(
+	ctx context.Context,
+	mentorID uuid.UUID,
+) ([]*models.MentorAvailabilityRule, error) {
+
+	const query = `
+	SELECT` + availabilityRuleColumns + `
+	FROM mentor_availability_rules
+	WHERE mentor_id = $1
+	ORDER BY day_of_week, start_time
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, mentorID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAvailabilityRules(rows)
+}
+
+// FindByMentorTx is FindByMentorID inside tx.
+func (r *MentorAvailabilityRepository) FindByMentorTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	mentorID uuid.UUID,
+) ([]*models.MentorAvailabilityRule, error) {
+
+	const query = `
+	SELECT` + availabilityRuleColumns + `
+	FROM mentor_availability_rules
+	WHERE mentor_id = $1
+	ORDER BY day_of_week, start_time
+	`
+
+	rows, err := tx.QueryContext(ctx, query, mentorID)
 	if err != nil {
 		return nil, err
 	}
 
-	return rule, nil
+	return scanAvailabilityRules(rows)
 }
 
 func (r *MentorAvailabilityRepository) FindByUsername(
@@ -71,7 +285,7 @@ func (r *MentorAvailabilityRepository) FindByUsername(
 	ORDER BY ar.day_of_week, ar.start_time
 	`
 
-	rows, err := r.db.Query(query, username)
+	rows, err := r.reader.Query(query, username)
 	if err != nil {
 		return nil, err
 	}
@@ -12,10 +12,20 @@ import (
 
 type MentorRepository struct {
 	db *sql.DB
+
+	// reader serves read-only queries that tolerate replica lag
+	reader *sql.DB
 }
 
 func NewMentorRepository(db *sql.DB) *MentorRepository {
-	return &MentorRepository{db: db}
+	return &MentorRepository{db: db, reader: db}
+}
+
+// WithReader routes lag-tolerant read-only queries to reader, typically a
+// read replica. Writes and transactional reads stay on the primary.
+func (r *MentorRepository) WithReader(reader *sql.DB) *MentorRepository {
+	r.reader = reader
+	return r
 }
 
 func (r *MentorRepository) CreateProfile(
@@ -30,10 +40,12 @@ func (r *MentorRepository) CreateProfile(
 		bio,
 		timezone,
 		is_active,
+		cancellation_window_hours,
+		late_cancellation_refund_percent,
 		created_at,
 		updated_at
 	)
-	VALUES ($1,$2,$3,$4,$5,true,NOW(),NOW())
+	VALUES ($1,$2,$3,$4,$5,true,$6,$7,NOW(),NOW())
 	RETURNING created_at, updated_at
 	`
 
@@ -48,6 +60,8 @@ func (r *MentorRepository) CreateProfile(
 		profile.Title,
 		profile.Bio,
 		profile.Timezone,
+		profile.CancellationWindowHours,
+		profile.LateCancellationRefundPercent,
 	).Scan(&profile.CreatedAt, &profile.UpdatedAt)
 }
 
@@ -63,6 +77,8 @@ func (r *MentorRepository) FindByUserID(
 		bio,
 		timezone,
 		is_active,
+		cancellation_window_hours,
+		late_cancellation_refund_percent,
 		created_at,
 		updated_at
 	FROM mentor_profiles
@@ -80,6 +96,8 @@ func (r *MentorRepository) FindByUserID(
 		&mentor.Bio,
 		&mentor.Timezone,
 		&mentor.IsActive,
+		&mentor.CancellationWindowHours,
+		&mentor.LateCancellationRefundPercent,
 		&mentor.CreatedAt,
 		&mentor.UpdatedAt,
 	)
@@ -107,7 +125,9 @@ func (r *MentorRepository) FindByUsername(
 		m.title,
 		m.bio,
 		m.timezone,
-		m.is_active
+		m.is_active,
+		m.cancellation_window_hours,
+		m.late_cancellation_refund_percent
 	FROM users u
 	JOIN mentor_profiles m ON m.user_id = u.id
 	WHERE u.username = $1
@@ -118,7 +138,7 @@ func (r *MentorRepository) FindByUsername(
 
 	var resp dtos.MentorProfileResponse
 
-	err := r.db.QueryRow(query, username).Scan(
+	err := r.reader.QueryRow(query, username).Scan(
 		&resp.User.ID,
 		&resp.User.Username,
 		&resp.User.FirstName,
@@ -130,6 +150,8 @@ func (r *MentorRepository) FindByUsername(
 		&resp.Mentor.Bio,
 		&resp.Mentor.Timezone,
 		&resp.Mentor.IsActive,
+		&resp.Mentor.CancellationWindowHours,
+		&resp.Mentor.LateCancellationRefundPercent,
 	)
 
 	if err != nil {
@@ -151,6 +173,8 @@ func (r *MentorRepository) FindByUsernameRaw(
 		mp.bio,
 		mp.timezone,
 		mp.is_active,
+		mp.cancellation_window_hours,
+		mp.late_cancellation_refund_percent,
 		mp.created_at,
 		mp.updated_at
 	FROM users u
@@ -171,6 +195,8 @@ func (r *MentorRepository) FindByUsernameRaw(
 		&mentor.Bio,
 		&mentor.Timezone,
 		&mentor.IsActive,
+		&mentor.CancellationWindowHours,
+		&mentor.LateCancellationRefundPercent,
 		&mentor.CreatedAt,
 		&mentor.UpdatedAt,
 	)
@@ -194,6 +220,8 @@ func (r *MentorRepository) FindByID(
 		bio,
 		timezone,
 		is_active,
+		cancellation_window_hours,
+		late_cancellation_refund_percent,
 		created_at,
 		updated_at
 	FROM mentor_profiles
@@ -210,6 +238,8 @@ func (r *MentorRepository) FindByID(
 		&mentor.Bio,
 		&mentor.Timezone,
 		&mentor.IsActive,
+		&mentor.CancellationWindowHours,
+		&mentor.LateCancellationRefundPercent,
 		&mentor.CreatedAt,
 		&mentor.UpdatedAt,
 	)
@@ -0,0 +1,245 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+)
+
+var ErrServicePackageNotFound = errors.New("service package not found")
+
+type MentorServicePackageRepository struct {
+	db *sql.DB
+
+	// reader serves read-only queries that tolerate replica lag
+	reader *sql.DB
+}
+
+func NewMentorServicePackageRepository(db *sql.DB) *MentorServicePackageRepository {
+	return &MentorServicePackageRepository{db: db, reader: db}
+}
+
+// WithReader routes lag-tolerant read-only queries to reader, typically a
+// read replica. Writes and transactional reads stay on the primary.
+func (r *MentorServicePackageRepository) WithReader(reader *sql.DB) *MentorServicePackageRepository {
+	r.reader = reader
+	return r
+}
+
+const servicePackageColumns = `
+	p.id,
+	p.mentor_id,
+	p.service_id,
+	p.title,
+	p.session_count,
+	p.price_cents,
+	p.currency,
+	p.is_active,
+	p.created_at,
+	p.updated_at,
+	p.deleted_at
+`
+
+func scanServicePackage(row rowScanner) (*models.MentorServicePackage, error) {
+	var p models.MentorServicePackage
+
+	err := row.Scan(
+		&p.ID,
+		&p.MentorID,
+		&p.ServiceID,
+		&p.Title,
+		&p.SessionCount,
+		&p.PriceCents,
+		&p.Currency,
+		&p.IsActive,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+		&p.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func scanServicePackages(rows *sql.Rows) ([]*models.MentorServicePackage, error) {
+	defer rows.Close()
+
+	var packages []*models.MentorServicePackage
+
+	for rows.Next() {
+		p, err := scanServicePackage(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		packages = append(packages, p)
+	}
+
+	return packages, rows.Err()
+}
+
+func (r *MentorServicePackageRepository) Create(
+	ctx context.Context,
+	p *models.MentorServicePackage,
+) error {
+
+	const query = `
+	INSERT INTO mentor_service_packages (
+		id,
+		mentor_id,
+		service_id,
+		title,
+		session_count,
+		price_cents,
+		currency,
+		is_active,
+		created_at,
+		updated_at
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, true, NOW(), NOW())
+	RETURNING created_at, updated_at
+	`
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		p.ID,
+		p.MentorID,
+		p.ServiceID,
+		p.Title,
+		p.SessionCount,
+		p.PriceCents,
+		p.Currency,
+	).Scan(&p.CreatedAt, &p.UpdatedAt)
+}
+
+// FindByID returns a package that can be bought: it and its service are
+// active and not deleted. Returns ErrServicePackageNotFound otherwise.
+func (r *MentorServicePackageRepository) FindByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (*models.MentorServicePackage, error) {
+
+	const query = `
+	SELECT` + servicePackageColumns + `
+	FROM mentor_service_packages p
+	JOIN mentor_services ms ON ms.id = p.service_id
+	WHERE p.id = $1
+	  AND p.is_active = true
+	  AND p.deleted_at IS NULL
+	  AND ms.is_active = true
+	  AND ms.deleted_at IS NULL
+	`
+
+	p, err := scanServicePackage(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrServicePackageNotFound
+	}
+
+	return p, err
+}
+
+// FindForManagement returns a package that is not deleted, whether active
+// or not. Returns ErrServicePackageNotFound when it does not exist.
+func (r *MentorServicePackageRepository) FindForManagement(
+	ctx context.Context,
+	id uuid.UUID,
+) (*models.MentorServicePackage, error) {
+
+	const query = `
+	SELECT` + servicePackageColumns + `
+	FROM mentor_service_packages p
+	WHERE p.id = $1
+	  AND p.deleted_at IS NULL
+	`
+
+	p, err := scanServicePackage(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrServicePackageNotFound
+	}
+
+	return p, err
+}
+
+// FindByMentorID lists all of a mentor's packages that are not deleted,
+// including inactive ones.
+func (r *MentorServicePackageRepository) FindByMentorID(
+	ctx context.Context,
+	mentorID uuid.UUID,
+) ([]*models.MentorServicePackage, error) {
+
+	const query = `
+	SELECT` + servicePackageColumns + `
+	FROM mentor_service_packages p
+	WHERE p.mentor_id = $1
+	  AND p.deleted_at IS NULL
+	ORDER BY p.created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, mentorID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanServicePackages(rows)
+}
+
+// FindByUsername lists the packages a mentor currently sells, in the
+// display order of their services.
+func (r *MentorServicePackageRepository) FindByUsername(
+	ctx context.Context,
+	username string,
+) ([]*models.MentorServicePackage, error) {
+
+	const query = `
+	SELECT` + servicePackageColumns + `
+	FROM users u
+	JOIN mentor_profiles mp ON mp.user_id = u.id
+	JOIN mentor_service_packages p ON p.mentor_id = mp.id
+	JOIN mentor_services ms ON ms.id = p.service_id
+	WHERE u.username = $1
+	  AND u.deleted_at IS NULL
+	  AND mp.is_active = true
+	  AND p.is_active = true
+	  AND p.deleted_at IS NULL
+	  AND ms.is_active = true
+	  AND ms.deleted_at IS NULL
+	ORDER BY ms.sort_order ASC, p.session_count ASC
+	`
+
+	rows, err := r.reader.QueryContext(ctx, query, username)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanServicePackages(rows)
+}
+
+// SoftDelete stops a package from being sold. Purchases and the credits
+// they issued are unaffected.
+func (r *MentorServicePackageRepository) SoftDelete(
+	ctx context.Context,
+	id uuid.UUID,
+) error {
+
+	const query = `
+	UPDATE mentor_service_packages
+	SET is_active = false,
+		deleted_at = NOW(),
+		updated_at = NOW()
+	WHERE id = $1
+	  AND deleted_at IS NULL
+	`
+
+	res, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(res, ErrServicePackageNotFound)
+}
@@ -3,20 +3,89 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/preetsinghmakkar/OpenCall/internal/models"
 )
 
+var ErrMentorServiceNotFound = errors.New("mentor service not found")
+
 type MentorServiceRepository struct {
 	db *sql.DB
+
+	// reader serves read-only queries that tolerate replica lag
+	reader *sql.DB
 }
 
 func NewMentorServiceRepository(db *sql.DB) *MentorServiceRepository {
-	return &MentorServiceRepository{db: db}
+	return &MentorServiceRepository{db: db, reader: db}
+}
+
+// WithReader routes lag-tolerant read-only queries to reader, typically a
+// read replica. Writes and transactional reads stay on the primary.
+func (r *MentorServiceRepository) WithReader(reader *sql.DB) *MentorServiceRepository {
+	r.reader = reader
+	return r
+}
+
+const mentorServiceColumns = `
+	ms.id,
+	ms.mentor_id,
+	ms.title,
+	ms.description,
+	ms.duration_minutes,
+	ms.price_cents,
+	ms.currency,
+	ms.is_active,
+	ms.sort_order,
+	ms.buffer_before_minutes,
+	ms.buffer_after_minutes,
+	ms.slot_step_minutes,
+	ms.max_sessions_per_day,
+	ms.min_gap_minutes,
+	ms.free_bookings_per_mentee,
+	ms.created_at,
+	ms.updated_at,
+	ms.deleted_at
+`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMentorService(row rowScanner) (*models.MentorService, error) {
+	var s models.MentorService
+
+	err := row.Scan(
+		&s.ID,
+		&s.MentorID,
+		&s.Title,
+		&s.Description,
+		&s.DurationMinutes,
+		&s.PriceCents,
+		&s.Currency,
+		&s.IsActive,
+		&s.SortOrder,
+		&s.BufferBeforeMinutes,
+		&s.BufferAfterMinutes,
+		&s.SlotStepMinutes,
+		&s.MaxSessionsPerDay,
+		&s.MinGapMinutes,
+		&s.FreeBookingsPerMentee,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+		&s.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
 }
 
+// Create inserts an active service at the end of the mentor's list.
 func (r *MentorServiceRepository) Create(
 	service *models.MentorService,
 ) error {
@@ -31,16 +100,29 @@ func (r *MentorServiceRepository) Create(
 		price_cents,
 		currency,
 		is_active,
+		sort_order,
+		buffer_before_minutes,
+		buffer_after_minutes,
+		slot_step_minutes,
+		max_sessions_per_day,
+		min_gap_minutes,
+		free_bookings_per_mentee,
 		created_at,
 		updated_at
 	)
-	VALUES ($1,$2,$3,$4,$5,$6,$7,true,NOW(),NOW())
+	VALUES (
+		$1,$2,$3,$4,$5,$6,$7,true,
+		(SELECT COALESCE(MAX(sort_order), 0) + 1 FROM mentor_services WHERE mentor_id = $2),
+		$8,$9,$10,$11,$12,$13,
+		NOW(),NOW()
+	)
+	RETURNING sort_order, created_at, updated_at
 	`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	_, err := r.db.ExecContext(
+	return r.db.QueryRowContext(
 		ctx,
 		query,
 		service.ID,
@@ -50,9 +132,17 @@ func (r *MentorServiceRepository) Create(
 		service.DurationMinutes,
 		service.PriceCents,
 		service.Currency,
+		service.BufferBeforeMinutes,
+		service.BufferAfterMinutes,
+		service.SlotStepMinutes,
+		service.MaxSessionsPerDay,
+		service.MinGapMinutes,
+		service.FreeBookingsPerMentee,
+	).Scan(
+		&service.SortOrder,
+		&service.CreatedAt,
+		&service.UpdatedAt,
 	)
-
-	return err
 }
 
 func (r *MentorServiceRepository) FindByUsername(
@@ -60,17 +150,7 @@ func (r *MentorServiceRepository) FindByUsername(
 ) ([]*models.MentorService, error) {
 
 	const query = `
-	SELECT
-		ms.id,
-		ms.mentor_id,
-		ms.title,
-		ms.description,
-		ms.duration_minutes,
-		ms.price_cents,
-		ms.currency,
-		ms.is_active,
-		ms.created_at,
-		ms.updated_at
+	SELECT` + mentorServiceColumns + `
 	FROM users u
 	JOIN mentor_profiles mp ON mp.user_id = u.id
 	JOIN mentor_services ms ON ms.mentor_id = mp.id
@@ -78,10 +158,45 @@ func (r *MentorServiceRepository) FindByUsername(
 	  AND u.deleted_at IS NULL
 	  AND mp.is_active = true
 	  AND ms.is_active = true
-	ORDER BY ms.created_at ASC
+	  AND ms.deleted_at IS NULL
+	ORDER BY ms.sort_order ASC, ms.created_at ASC
+	`
+
+	rows, err := r.reader.Query(query, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var services []*models.MentorService
+
+	for rows.Next() {
+		s, err := scanMentorService(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		services = append(services, s)
+	}
+
+	return services, rows.Err()
+}
+
+// FindByMentorID lists all of a mentor's services that are not deleted,
+// including inactive ones, in display order.
+func (r *MentorServiceRepository) FindByMentorID(
+	mentorID uuid.UUID,
+) ([]*models.MentorService, error) {
+
+	const query = `
+	SELECT` + mentorServiceColumns + `
+	FROM mentor_services ms
+	WHERE ms.mentor_id = $1
+	  AND ms.deleted_at IS NULL
+	ORDER BY ms.sort_order ASC, ms.created_at ASC
 	`
 
-	rows, err := r.db.Query(query, username)
+	rows, err := r.db.Query(query, mentorID)
 	if err != nil {
 		return nil, err
 	}
@@ -90,71 +205,196 @@ func (r *MentorServiceRepository) FindByUsername(
 	var services []*models.MentorService
 
 	for rows.Next() {
-		var s models.MentorService
-
-		if err := rows.Scan(
-			&s.ID,
-			&s.MentorID,
-			&s.Title,
-			&s.Description,
-			&s.DurationMinutes,
-			&s.PriceCents,
-			&s.Currency,
-			&s.IsActive,
-			&s.CreatedAt,
-			&s.UpdatedAt,
-		); err != nil {
+		s, err := scanMentorService(rows)
+		if err != nil {
 			return nil, err
 		}
 
-		services = append(services, &s)
+		services = append(services, s)
 	}
 
-	return services, nil
+	return services, rows.Err()
 }
 
+// FindByID returns a bookable service: active and not deleted.
 func (r *MentorServiceRepository) FindByID(
 	serviceID uuid.UUID,
 ) (*models.MentorService, error) {
 
 	const query = `
-	SELECT
-		id,
-		mentor_id,
-		title,
-		description,
-		duration_minutes,
-		price_cents,
-		currency,
-		is_active,
-		created_at,
-		updated_at
-	FROM mentor_services
-	WHERE id = $1
-	  AND is_active = true
+	SELECT` + mentorServiceColumns + `
+	FROM mentor_services ms
+	WHERE ms.id = $1
+	  AND ms.is_active = true
+	  AND ms.deleted_at IS NULL
+	`
+
+	return scanMentorService(r.db.QueryRow(query, serviceID))
+}
+
+// FindForManagement returns a service that is not deleted, whether active
+// or not. Returns ErrMentorServiceNotFound when it does not exist.
+func (r *MentorServiceRepository) FindForManagement(
+	ctx context.Context,
+	serviceID uuid.UUID,
+) (*models.MentorService, error) {
+
+	const query = `
+	SELECT` + mentorServiceColumns + `
+	FROM mentor_services ms
+	WHERE ms.id = $1
+	  AND ms.deleted_at IS NULL
 	`
 
-	var service models.MentorService
+	s, err := scanMentorService(r.db.QueryRowContext(ctx, query, serviceID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMentorServiceNotFound
+	}
+
+	return s, err
+}
 
-	err := r.db.QueryRow(
+// Update rewrites the editable fields of a service. Bookings keep the price
+// they were created with, so this never touches existing bookings.
+func (r *MentorServiceRepository) Update(
+	ctx context.Context,
+	service *models.MentorService,
+) error {
+
+	const query = `
+	UPDATE mentor_services
+	SET
+		title = $2,
+		description = $3,
+		duration_minutes = $4,
+		price_cents = $5,
+		currency = $6,
+		buffer_before_minutes = $7,
+		buffer_after_minutes = $8,
+		slot_step_minutes = $9,
+		max_sessions_per_day = $10,
+		min_gap_minutes = $11,
+		free_bookings_per_mentee = $12,
+		updated_at = NOW()
+	WHERE id = $1
+	  AND deleted_at IS NULL
+	RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
 		query,
-		serviceID,
-	).Scan(
-		&service.ID,
-		&service.MentorID,
-		&service.Title,
-		&service.Description,
-		&service.DurationMinutes,
-		&service.PriceCents,
-		&service.Currency,
-		&service.IsActive,
-		&service.CreatedAt,
-		&service.UpdatedAt,
-	)
+		service.ID,
+		service.Title,
+		service.Description,
+		service.DurationMinutes,
+		service.PriceCents,
+		service.Currency,
+		service.BufferBeforeMinutes,
+		service.BufferAfterMinutes,
+		service.SlotStepMinutes,
+		service.MaxSessionsPerDay,
+		service.MinGapMinutes,
+		service.FreeBookingsPerMentee,
+	).Scan(&service.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrMentorServiceNotFound
+	}
+
+	return err
+}
+
+func (r *MentorServiceRepository) SetActive(
+	ctx context.Context,
+	serviceID uuid.UUID,
+	active bool,
+) error {
+
+	const query = `
+	UPDATE mentor_services
+	SET is_active = $2,
+		updated_at = NOW()
+	WHERE id = $1
+	  AND deleted_at IS NULL
+	`
 
+	res, err := r.db.ExecContext(ctx, query, serviceID, active)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return &service, nil
+	return requireRow(res, ErrMentorServiceNotFound)
+}
+
+// SoftDelete hides a service for good. The row stays so that existing
+// bookings still reference it.
+func (r *MentorServiceRepository) SoftDelete(
+	ctx context.Context,
+	serviceID uuid.UUID,
+) error {
+
+	const query = `
+	UPDATE mentor_services
+	SET is_active = false,
+		deleted_at = NOW(),
+		updated_at = NOW()
+	WHERE id = $1
+	  AND deleted_at IS NULL
+	`
+
+	res, err := r.db.ExecContext(ctx, query, serviceID)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(res, ErrMentorServiceNotFound)
+}
+
+// Reorder sets the display order of a mentor's services to the order of
+// serviceIDs. Every id must belong to the mentor and not be deleted.
+func (r *MentorServiceRepository) Reorder(
+	ctx context.Context,
+	mentorID uuid.UUID,
+	serviceIDs []uuid.UUID,
+) error {
+
+	const query = `
+	UPDATE mentor_services
+	SET sort_order = $3,
+		updated_at = NOW()
+	WHERE id = $1
+	  AND mentor_id = $2
+	  AND deleted_at IS NULL
+	`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i, id := range serviceIDs {
+		res, err := tx.ExecContext(ctx, query, id, mentorID, i+1)
+		if err != nil {
+			return err
+		}
+
+		if err := requireRow(res, ErrMentorServiceNotFound); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// requireRow returns notFound when an UPDATE matched nothing.
+func requireRow(res sql.Result, notFound error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
 }
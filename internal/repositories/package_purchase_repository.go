@@ -0,0 +1,334 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+)
+
+var (
+	ErrPackagePurchaseNotFound = errors.New("package purchase not found")
+	ErrNoSessionCredit         = errors.New("no session credit available")
+)
+
+type PackagePurchaseRepository struct {
+	db *sql.DB
+}
+
+func NewPackagePurchaseRepository(db *sql.DB) *PackagePurchaseRepository {
+	return &PackagePurchaseRepository{db: db}
+}
+
+func (r *PackagePurchaseRepository) CreateTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	p *models.PackagePurchase,
+) error {
+
+	const query = `
+	INSERT INTO package_purchases (
+		id,
+		package_id,
+		user_id,
+		mentor_id,
+		service_id,
+		session_count,
+		price_cents,
+		currency,
+		status,
+		created_at,
+		updated_at
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW())
+	RETURNING created_at, updated_at
+	`
+
+	return tx.QueryRowContext(
+		ctx,
+		query,
+		p.ID,
+		p.PackageID,
+		p.UserID,
+		p.MentorID,
+		p.ServiceID,
+		p.SessionCount,
+		p.PriceCents,
+		p.Currency,
+		p.Status,
+	).Scan(&p.CreatedAt, &p.UpdatedAt)
+}
+
+// GetForUpdateTx loads a purchase and locks its row until the surrounding
+// transaction finishes.
+func (r *PackagePurchaseRepository) GetForUpdateTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	id uuid.UUID,
+) (*models.PackagePurchase, error) {
+
+	const query = `
+	SELECT
+		id,
+		package_id,
+		user_id,
+		mentor_id,
+		service_id,
+		session_count,
+		price_cents,
+		currency,
+		status,
+		created_at,
+		updated_at
+	FROM package_purchases
+	WHERE id = $1
+	FOR UPDATE
+	`
+
+	var p models.PackagePurchase
+
+	err := tx.QueryRowContext(ctx, query, id).Scan(
+		&p.ID,
+		&p.PackageID,
+		&p.UserID,
+		&p.MentorID,
+		&p.ServiceID,
+		&p.SessionCount,
+		&p.PriceCents,
+		&p.Currency,
+		&p.Status,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPackagePurchaseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// MarkPaidTx moves a pending purchase to paid and issues one session
+// credit per session in it. Already paid purchases are left alone, so a
+// repeated capture never issues credits twice.
+func (r *PackagePurchaseRepository) MarkPaidTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	purchaseID uuid.UUID,
+) error {
+
+	purchase, err := r.GetForUpdateTx(ctx, tx, purchaseID)
+	if err != nil {
+		return err
+	}
+
+	if purchase.Status == models.PackagePurchaseStatusPaid {
+		return nil // idempotent
+	}
+
+	const update = `
+	UPDATE package_purchases
+	SET status = $2,
+		updated_at = NOW()
+	WHERE id = $1
+	`
+
+	if _, err := tx.ExecContext(
+		ctx,
+		update,
+		purchase.ID,
+		models.PackagePurchaseStatusPaid,
+	); err != nil {
+		return err
+	}
+
+	const issue = `
+	INSERT INTO session_credits (id, purchase_id, user_id, service_id, created_at)
+	VALUES ($1, $2, $3, $4, NOW())
+	`
+
+	for i := 0; i < purchase.SessionCount; i++ {
+		if _, err := tx.ExecContext(
+			ctx,
+			issue,
+			uuid.New(),
+			purchase.ID,
+			purchase.UserID,
+			purchase.ServiceID,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarkFailedTx moves a pending purchase to failed.
+func (r *PackagePurchaseRepository) MarkFailedTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	purchaseID uuid.UUID,
+) error {
+
+	const query = `
+	UPDATE package_purchases
+	SET status = $2,
+		updated_at = NOW()
+	WHERE id = $1
+	  AND status = $3
+	`
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		purchaseID,
+		models.PackagePurchaseStatusFailed,
+		models.PackagePurchaseStatusPending,
+	)
+
+	return err
+}
+
+// FindAvailableCreditTx locks the mentee's oldest unused credit for a
+// service. Returns ErrNoSessionCredit when there is none.
+func (r *PackagePurchaseRepository) FindAvailableCreditTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	userID uuid.UUID,
+	serviceID uuid.UUID,
+) (uuid.UUID, error) {
+
+	const query = `
+	SELECT id
+	FROM session_credits
+	WHERE user_id = $1
+	  AND service_id = $2
+	  AND booking_id IS NULL
+	ORDER BY created_at ASC
+	LIMIT 1
+	FOR UPDATE
+	`
+
+	var id uuid.UUID
+	err := tx.QueryRowContext(ctx, query, userID, serviceID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, ErrNoSessionCredit
+	}
+
+	return id, err
+}
+
+// RedeemCreditTx attaches a credit found by FindAvailableCreditTx to the
+// booking it pays for.
+func (r *PackagePurchaseRepository) RedeemCreditTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	creditID uuid.UUID,
+	bookingID uuid.UUID,
+) error {
+
+	const query = `
+	UPDATE session_credits
+	SET booking_id = $2,
+		redeemed_at = NOW()
+	WHERE id = $1
+	  AND booking_id IS NULL
+	`
+
+	res, err := tx.ExecContext(ctx, query, creditID, bookingID)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(res, ErrNoSessionCredit)
+}
+
+// HasRedeemedCreditTx reports whether a booking was paid with a credit.
+func (r *PackagePurchaseRepository) HasRedeemedCreditTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	bookingID uuid.UUID,
+) (bool, error) {
+
+	var exists bool
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM session_credits WHERE booking_id = $1)`,
+		bookingID,
+	).Scan(&exists)
+
+	return exists, err
+}
+
+// ReleaseCreditTx makes the credit a booking was paid with available
+// again.
+func (r *PackagePurchaseRepository) ReleaseCreditTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	bookingID uuid.UUID,
+) error {
+
+	const query = `
+	UPDATE session_credits
+	SET booking_id = NULL,
+		redeemed_at = NULL
+	WHERE booking_id = $1
+	`
+
+	_, err := tx.ExecContext(ctx, query, bookingID)
+	return err
+}
+
+// GetCreditBalances sums a mentee's credits per service.
+func (r *PackagePurchaseRepository) GetCreditBalances(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]*dtos.SessionCreditBalance, error) {
+
+	const query = `
+	SELECT
+		ms.id,
+		ms.title,
+		u.username,
+		COUNT(*) FILTER (WHERE sc.booking_id IS NULL) AS available,
+		COUNT(*) FILTER (WHERE sc.booking_id IS NOT NULL) AS redeemed
+	FROM session_credits sc
+	JOIN mentor_services ms ON ms.id = sc.service_id
+	JOIN mentor_profiles mp ON mp.id = ms.mentor_id
+	JOIN users u ON u.id = mp.user_id
+	WHERE sc.user_id = $1
+	GROUP BY ms.id, ms.title, u.username
+	ORDER BY u.username, ms.title
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []*dtos.SessionCreditBalance
+
+	for rows.Next() {
+		var b dtos.SessionCreditBalance
+
+		if err := rows.Scan(
+			&b.ServiceID,
+			&b.ServiceTitle,
+			&b.Mentor,
+			&b.Available,
+			&b.Redeemed,
+		); err != nil {
+			return nil, err
+		}
+
+		balances = append(balances, &b)
+	}
+
+	return balances, rows.Err()
+}
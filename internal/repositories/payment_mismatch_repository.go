@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+)
+
+type PaymentMismatchRepository struct {
+	db *sql.DB
+}
+
+func NewPaymentMismatchRepository(db *sql.DB) *PaymentMismatchRepository {
+	return &PaymentMismatchRepository{db: db}
+}
+
+// CreateTx records a mismatch. A payment is only recorded once.
+func (r *PaymentMismatchRepository) CreateTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	m *models.PaymentMismatch,
+) (bool, error) {
+
+	query := `
+		INSERT INTO payment_mismatches (
+			id,
+			payment_id,
+			gateway,
+			gateway_payment_id,
+			expected_amount,
+			expected_currency,
+			actual_amount,
+			actual_currency
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (payment_id) DO NOTHING
+	`
+
+	res, err := tx.ExecContext(
+		ctx,
+		query,
+		m.ID,
+		m.PaymentID,
+		m.Gateway,
+		m.GatewayPaymentID,
+		m.ExpectedAmount,
+		m.ExpectedCurrency,
+		m.ActualAmount,
+		m.ActualCurrency,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// List returns the newest mismatches first
+func (r *PaymentMismatchRepository) List(
+	ctx context.Context,
+	limit int,
+) ([]*models.PaymentMismatch, error) {
+
+	query := `
+		SELECT
+			id,
+			payment_id,
+			gateway,
+			gateway_payment_id,
+			expected_amount,
+			expected_currency,
+			actual_amount,
+			actual_currency,
+			detected_at
+		FROM payment_mismatches
+		ORDER BY detected_at DESC
+		LIMIT $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mismatches []*models.PaymentMismatch
+	for rows.Next() {
+		var m models.PaymentMismatch
+		if err := rows.Scan(
+			&m.ID,
+			&m.PaymentID,
+			&m.Gateway,
+			&m.GatewayPaymentID,
+			&m.ExpectedAmount,
+			&m.ExpectedCurrency,
+			&m.ActualAmount,
+			&m.ActualCurrency,
+			&m.DetectedAt,
+		); err != nil {
+			return nil, err
+		}
+		mismatches = append(mismatches, &m)
+	}
+
+	return mismatches, rows.Err()
+}
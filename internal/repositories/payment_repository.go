@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/preetsinghmakkar/OpenCall/internal/models"
@@ -26,14 +27,16 @@ func (r *PaymentRepository) Create(
 		INSERT INTO payments (
 			id,
 			booking_id,
+			package_purchase_id,
 			user_id,
 			gateway,
 			gateway_order_id,
 			amount,
 			currency,
+			billing_state,
 			status
 		)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
 	`
 
 	_, err := tx.ExecContext(
@@ -41,11 +44,13 @@ func (r *PaymentRepository) Create(
 		query,
 		p.ID,
 		p.BookingID,
+		p.PackagePurchaseID,
 		p.UserID,
 		p.Gateway,
 		p.GatewayOrderID,
 		p.Amount,
 		p.Currency,
+		p.BillingState,
 		p.Status,
 	)
 
@@ -61,6 +66,7 @@ func (r *PaymentRepository) GetByID(
 		SELECT
 			id,
 			booking_id,
+			package_purchase_id,
 			user_id,
 			gateway,
 			gateway_order_id,
@@ -68,6 +74,7 @@ func (r *PaymentRepository) GetByID(
 			gateway_signature,
 			amount,
 			currency,
+			billing_state,
 			status,
 			created_at,
 			updated_at
@@ -80,6 +87,7 @@ func (r *PaymentRepository) GetByID(
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&p.ID,
 		&p.BookingID,
+		&p.PackagePurchaseID,
 		&p.UserID,
 		&p.Gateway,
 		&p.GatewayOrderID,
@@ -87,6 +95,7 @@ func (r *PaymentRepository) GetByID(
 		&p.GatewaySignature,
 		&p.Amount,
 		&p.Currency,
+		&p.BillingState,
 		&p.Status,
 		&p.CreatedAt,
 		&p.UpdatedAt,
@@ -99,11 +108,14 @@ func (r *PaymentRepository) GetByID(
 	return &p, nil
 }
 
+// MarkPaid records a capture. The signature is only set when the client
+// verified the payment. Payments that are already paid, refunded or
+// flagged are left alone.
 func (r *PaymentRepository) MarkPaid(
 	ctx context.Context,
 	tx *sql.Tx,
 	paymentID uuid.UUID,
-	razorpayPaymentID string,
+	gatewayPaymentID string,
 	signature string,
 ) error {
 
@@ -112,16 +124,17 @@ func (r *PaymentRepository) MarkPaid(
 		SET
 			status = 'paid',
 			gateway_payment_id = $2,
-			gateway_signature = $3,
+			gateway_signature = NULLIF($3, ''),
 			updated_at = now()
 		WHERE id = $1
+		  AND status IN ('created', 'superseded', 'failed')
 	`
 
 	_, err := tx.ExecContext(
 		ctx,
 		query,
 		paymentID,
-		razorpayPaymentID,
+		gatewayPaymentID,
 		signature,
 	)
 
@@ -137,6 +150,7 @@ func (r *PaymentRepository) GetByGatewayOrderID(
 		SELECT
 			id,
 			booking_id,
+			package_purchase_id,
 			user_id,
 			gateway,
 			gateway_order_id,
@@ -144,6 +158,7 @@ func (r *PaymentRepository) GetByGatewayOrderID(
 			gateway_signature,
 			amount,
 			currency,
+			billing_state,
 			status,
 			created_at,
 			updated_at
@@ -155,6 +170,7 @@ func (r *PaymentRepository) GetByGatewayOrderID(
 	err := r.db.QueryRowContext(ctx, query, orderID).Scan(
 		&p.ID,
 		&p.BookingID,
+		&p.PackagePurchaseID,
 		&p.UserID,
 		&p.Gateway,
 		&p.GatewayOrderID,
@@ -162,6 +178,7 @@ func (r *PaymentRepository) GetByGatewayOrderID(
 		&p.GatewaySignature,
 		&p.Amount,
 		&p.Currency,
+		&p.BillingState,
 		&p.Status,
 		&p.CreatedAt,
 		&p.UpdatedAt,
@@ -169,47 +186,103 @@ func (r *PaymentRepository) GetByGatewayOrderID(
 	return &p, err
 }
 
-func (r *PaymentRepository) MarkPaidByGateway(
+// MarkAmountMismatchTx flags a capture whose amount or currency differs
+// from the price, instead of marking it paid
+func (r *PaymentRepository) MarkAmountMismatchTx(
+	ctx context.Context,
 	tx *sql.Tx,
 	paymentID uuid.UUID,
-	razorpayPaymentID string,
+	gatewayPaymentID string,
 ) error {
 
 	query := `
 		UPDATE payments
 		SET
-			status = 'paid',
+			status = 'amount_mismatch',
 			gateway_payment_id = $2,
 			updated_at = now()
 		WHERE id = $1
 	`
 
-	_, err := tx.Exec(
-		query,
-		paymentID,
-		razorpayPaymentID,
-	)
-
+	_, err := tx.ExecContext(ctx, query, paymentID, gatewayPaymentID)
 	return err
 }
 
-func (r *BookingRepository) MarkPaymentFailed(
+// SupersedeOpenTx marks the booking's open payment attempt, if any, as
+// superseded so a new one can be opened
+func (r *PaymentRepository) SupersedeOpenTx(
 	ctx context.Context,
 	tx *sql.Tx,
 	bookingID uuid.UUID,
 ) error {
 
 	query := `
-		UPDATE bookings
-		SET status = 'payment_failed',
-		    updated_at = now()
-		WHERE id = $1
+		UPDATE payments
+		SET
+			status = 'superseded',
+			updated_at = now()
+		WHERE booking_id = $1
+		  AND status = 'created'
 	`
 
 	_, err := tx.ExecContext(ctx, query, bookingID)
 	return err
 }
 
+// GetForUpdateTx loads a payment and locks its row until the surrounding
+// transaction finishes.
+func (r *PaymentRepository) GetForUpdateTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	id uuid.UUID,
+) (*models.Payment, error) {
+
+	query := `
+		SELECT
+			id,
+			booking_id,
+			package_purchase_id,
+			user_id,
+			gateway,
+			gateway_order_id,
+			gateway_payment_id,
+			gateway_signature,
+			amount,
+			currency,
+			billing_state,
+			status,
+			created_at,
+			updated_at
+		FROM payments
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	var p models.Payment
+
+	err := tx.QueryRowContext(ctx, query, id).Scan(
+		&p.ID,
+		&p.BookingID,
+		&p.PackagePurchaseID,
+		&p.UserID,
+		&p.Gateway,
+		&p.GatewayOrderID,
+		&p.GatewayPaymentID,
+		&p.GatewaySignature,
+		&p.Amount,
+		&p.Currency,
+		&p.BillingState,
+		&p.Status,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
 func (r *PaymentRepository) MarkFailedByGateway(
 	tx *sql.Tx,
 	paymentID uuid.UUID,
@@ -226,3 +299,168 @@ func (r *PaymentRepository) MarkFailedByGateway(
 	_, err := tx.Exec(query, paymentID)
 	return err
 }
+
+// RecordAttemptFailure notes a declined try at paying an open attempt,
+// which stays open to be paid again
+func (r *PaymentRepository) RecordAttemptFailure(
+	ctx context.Context,
+	paymentID uuid.UUID,
+	reason string,
+) error {
+
+	query := `
+		UPDATE payments
+		SET
+			last_failure = $2,
+			last_failed_at = now(),
+			updated_at = now()
+		WHERE id = $1
+		  AND status IN ('created', 'superseded')
+	`
+
+	_, err := r.db.ExecContext(ctx, query, paymentID, reason)
+	return err
+}
+
+// GetPaidByBookingIDTx returns the captured payment for a booking, or
+// sql.ErrNoRows when the booking was never paid.
+func (r *PaymentRepository) GetPaidByBookingIDTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	bookingID uuid.UUID,
+) (*models.Payment, error) {
+
+	query := `
+		SELECT
+			id,
+			booking_id,
+			package_purchase_id,
+			user_id,
+			gateway,
+			gateway_order_id,
+			gateway_payment_id,
+			gateway_signature,
+			amount,
+			currency,
+			billing_state,
+			status,
+			created_at,
+			updated_at
+		FROM payments
+		WHERE booking_id = $1
+		  AND status = 'paid'
+		ORDER BY created_at DESC
+		LIMIT 1
+		FOR UPDATE
+	`
+
+	var p models.Payment
+
+	err := tx.QueryRowContext(ctx, query, bookingID).Scan(
+		&p.ID,
+		&p.BookingID,
+		&p.PackagePurchaseID,
+		&p.UserID,
+		&p.Gateway,
+		&p.GatewayOrderID,
+		&p.GatewayPaymentID,
+		&p.GatewaySignature,
+		&p.Amount,
+		&p.Currency,
+		&p.BillingState,
+		&p.Status,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func (r *PaymentRepository) UpdateStatusTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	paymentID uuid.UUID,
+	status string,
+) error {
+
+	query := `
+		UPDATE payments
+		SET
+			status = $2,
+			updated_at = now()
+		WHERE id = $1
+	`
+
+	_, err := tx.ExecContext(ctx, query, paymentID, status)
+	return err
+}
+
+// FindStaleUnpaid returns open and superseded payment attempts that were
+// opened between createdAfter and createdBefore, oldest first.
+func (r *PaymentRepository) FindStaleUnpaid(
+	ctx context.Context,
+	createdAfter time.Time,
+	createdBefore time.Time,
+	limit int,
+) ([]*models.Payment, error) {
+
+	query := `
+		SELECT
+			id,
+			booking_id,
+			package_purchase_id,
+			user_id,
+			gateway,
+			gateway_order_id,
+			gateway_payment_id,
+			gateway_signature,
+			amount,
+			currency,
+			billing_state,
+			status,
+			created_at,
+			updated_at
+		FROM payments
+		WHERE status IN ('created', 'superseded')
+		  AND created_at > $1
+		  AND created_at < $2
+		ORDER BY created_at
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, createdAfter, createdBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []*models.Payment
+	for rows.Next() {
+		var p models.Payment
+		if err := rows.Scan(
+			&p.ID,
+			&p.BookingID,
+			&p.PackagePurchaseID,
+			&p.UserID,
+			&p.Gateway,
+			&p.GatewayOrderID,
+			&p.GatewayPaymentID,
+			&p.GatewaySignature,
+			&p.Amount,
+			&p.Currency,
+			&p.BillingState,
+			&p.Status,
+			&p.CreatedAt,
+			&p.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		payments = append(payments, &p)
+	}
+
+	return payments, rows.Err()
+}
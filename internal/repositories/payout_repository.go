@@ -0,0 +1,416 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+)
+
+var ErrPayoutAccountNotFound = errors.New("payout account not found")
+
+type PayoutRepository struct {
+	db *sql.DB
+}
+
+func NewPayoutRepository(db *sql.DB) *PayoutRepository {
+	return &PayoutRepository{db: db}
+}
+
+func (r *PayoutRepository) WithTx(
+	ctx context.Context,
+	fn func(tx *sql.Tx) error,
+) error {
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpsertAccount stores a mentor's payout account. Changing the details
+// forgets the provider registration so it is made again with the new
+// details.
+func (r *PayoutRepository) UpsertAccount(
+	ctx context.Context,
+	a *models.MentorPayoutAccount,
+) error {
+
+	const query = `
+	INSERT INTO mentor_payout_accounts (
+		mentor_id,
+		method,
+		details_ciphertext,
+		display_hint
+	)
+	VALUES ($1,$2,$3,$4)
+	ON CONFLICT (mentor_id) DO UPDATE
+	SET
+		method = EXCLUDED.method,
+		details_ciphertext = EXCLUDED.details_ciphertext,
+		display_hint = EXCLUDED.display_hint,
+		provider = NULL,
+		provider_destination_id = NULL,
+		updated_at = NOW()
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		a.MentorID,
+		a.Method,
+		a.DetailsCiphertext,
+		a.DisplayHint,
+	)
+
+	return err
+}
+
+func (r *PayoutRepository) GetAccount(
+	ctx context.Context,
+	mentorID uuid.UUID,
+) (*models.MentorPayoutAccount, error) {
+
+	const query = `
+	SELECT
+		mentor_id,
+		method,
+		details_ciphertext,
+		display_hint,
+		provider,
+		provider_destination_id,
+		created_at,
+		updated_at
+	FROM mentor_payout_accounts
+	WHERE mentor_id = $1
+	`
+
+	var a models.MentorPayoutAccount
+
+	err := r.db.QueryRowContext(ctx, query, mentorID).Scan(
+		&a.MentorID,
+		&a.Method,
+		&a.DetailsCiphertext,
+		&a.DisplayHint,
+		&a.Provider,
+		&a.ProviderDestinationID,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrPayoutAccountNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// SetAccountDestination records the provider's ID for a mentor's payout
+// account.
+func (r *PayoutRepository) SetAccountDestination(
+	ctx context.Context,
+	mentorID uuid.UUID,
+	provider string,
+	destinationID string,
+) error {
+
+	const query = `
+	UPDATE mentor_payout_accounts
+	SET
+		provider = $2,
+		provider_destination_id = $3,
+		updated_at = NOW()
+	WHERE mentor_id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, mentorID, provider, destinationID)
+	return err
+}
+
+// PayableBalance is a mentor's balance in one currency that is out of
+// its hold and can be paid out.
+type PayableBalance struct {
+	MentorID       uuid.UUID
+	Currency       string
+	AvailableCents int64
+}
+
+// FindPayable returns the available balances of at least minCents of
+// mentors who have a payout account and no payout in flight.
+func (r *PayoutRepository) FindPayable(
+	ctx context.Context,
+	now time.Time,
+	minCents int64,
+) ([]PayableBalance, error) {
+
+	const query = `
+	SELECT
+		e.mentor_id,
+		e.currency,
+		SUM(CASE WHEN e.direction = 'credit' THEN e.amount_cents ELSE -e.amount_cents END) AS available
+	FROM ledger_entries e
+	JOIN mentor_payout_accounts a ON a.mentor_id = e.mentor_id
+	WHERE e.account = $1
+	  AND e.available_at <= $2
+	  AND NOT EXISTS (
+		SELECT 1 FROM payouts p
+		WHERE p.mentor_id = e.mentor_id
+		  AND p.status IN ('pending', 'processing')
+	  )
+	GROUP BY e.mentor_id, e.currency
+	HAVING SUM(CASE WHEN e.direction = 'credit' THEN e.amount_cents ELSE -e.amount_cents END) >= $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, models.LedgerAccountMentorPayable, now, minCents)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []PayableBalance
+
+	for rows.Next() {
+		var b PayableBalance
+		if err := rows.Scan(&b.MentorID, &b.Currency, &b.AvailableCents); err != nil {
+			return nil, err
+		}
+		result = append(result, b)
+	}
+
+	return result, rows.Err()
+}
+
+func (r *PayoutRepository) CreateBatch(
+	ctx context.Context,
+	b *models.PayoutBatch,
+) error {
+
+	const query = `
+	INSERT INTO payout_batches (id)
+	VALUES ($1)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, b.ID)
+	return err
+}
+
+// AddToBatch counts a created payout in its batch's totals.
+func (r *PayoutRepository) AddToBatch(
+	ctx context.Context,
+	batchID uuid.UUID,
+	amountCents int64,
+) error {
+
+	const query = `
+	UPDATE payout_batches
+	SET
+		payout_count = payout_count + 1,
+		total_cents = total_cents + $2
+	WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, batchID, amountCents)
+	return err
+}
+
+// CreatePayoutTx stores a pending payout. It reports false without
+// writing anything when the mentor already has a payout in flight.
+func (r *PayoutRepository) CreatePayoutTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	p *models.Payout,
+) (bool, error) {
+
+	const query = `
+	INSERT INTO payouts (
+		id,
+		batch_id,
+		mentor_id,
+		amount_cents,
+		currency,
+		status,
+		provider
+	)
+	VALUES ($1,$2,$3,$4,$5,$6,$7)
+	ON CONFLICT (mentor_id) WHERE status IN ('pending', 'processing') DO NOTHING
+	`
+
+	result, err := tx.ExecContext(
+		ctx,
+		query,
+		p.ID,
+		p.BatchID,
+		p.MentorID,
+		p.AmountCents,
+		p.Currency,
+		p.Status,
+		p.Provider,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// MarkSubmitted records that the provider accepted a pending payout.
+func (r *PayoutRepository) MarkSubmitted(
+	ctx context.Context,
+	payoutID uuid.UUID,
+	providerPayoutID string,
+) error {
+
+	const query = `
+	UPDATE payouts
+	SET
+		provider_payout_id = $2,
+		status = $3,
+		updated_at = NOW()
+	WHERE id = $1
+	  AND status = $4
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		payoutID,
+		providerPayoutID,
+		models.PayoutStatusProcessing,
+		models.PayoutStatusPending,
+	)
+
+	return err
+}
+
+// SettleTx moves an in-flight payout to paid or failed. It reports
+// false when the payout was already settled.
+func (r *PayoutRepository) SettleTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	payoutID uuid.UUID,
+	status string,
+	failureReason string,
+) (bool, error) {
+
+	const query = `
+	UPDATE payouts
+	SET
+		status = $2,
+		failure_reason = NULLIF($3, ''),
+		updated_at = NOW()
+	WHERE id = $1
+	  AND status IN ('pending', 'processing')
+	`
+
+	result, err := tx.ExecContext(ctx, query, payoutID, status, failureReason)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+const payoutColumns = `
+		id,
+		batch_id,
+		mentor_id,
+		amount_cents,
+		currency,
+		status,
+		provider,
+		provider_payout_id,
+		failure_reason,
+		created_at,
+		updated_at
+`
+
+func scanPayouts(rows *sql.Rows) ([]*models.Payout, error) {
+	defer rows.Close()
+
+	var payouts []*models.Payout
+
+	for rows.Next() {
+		var p models.Payout
+		if err := rows.Scan(
+			&p.ID,
+			&p.BatchID,
+			&p.MentorID,
+			&p.AmountCents,
+			&p.Currency,
+			&p.Status,
+			&p.Provider,
+			&p.ProviderPayoutID,
+			&p.FailureReason,
+			&p.CreatedAt,
+			&p.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		payouts = append(payouts, &p)
+	}
+
+	return payouts, rows.Err()
+}
+
+// FindInFlight returns the payouts that are not settled yet, oldest
+// first.
+func (r *PayoutRepository) FindInFlight(
+	ctx context.Context,
+) ([]*models.Payout, error) {
+
+	query := `SELECT` + payoutColumns + `
+	FROM payouts
+	WHERE status IN ('pending', 'processing')
+	ORDER BY created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanPayouts(rows)
+}
+
+func (r *PayoutRepository) ListByMentor(
+	ctx context.Context,
+	mentorID uuid.UUID,
+) ([]*models.Payout, error) {
+
+	query := `SELECT` + payoutColumns + `
+	FROM payouts
+	WHERE mentor_id = $1
+	ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, mentorID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanPayouts(rows)
+}
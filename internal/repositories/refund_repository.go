@@ -0,0 +1,266 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+)
+
+type RefundRepository struct {
+	db *sql.DB
+}
+
+func NewRefundRepository(db *sql.DB) *RefundRepository {
+	return &RefundRepository{db: db}
+}
+
+const refundColumns = `
+			id,
+			payment_id,
+			booking_id,
+			package_purchase_id,
+			gateway,
+			gateway_refund_id,
+			amount,
+			currency,
+			reason,
+			status,
+			unfulfilled,
+			attempts,
+			next_attempt_at,
+			last_error,
+			created_at,
+			updated_at
+`
+
+func scanRefund(row rowScanner) (*models.Refund, error) {
+	var rf models.Refund
+
+	err := row.Scan(
+		&rf.ID,
+		&rf.PaymentID,
+		&rf.BookingID,
+		&rf.PackagePurchaseID,
+		&rf.Gateway,
+		&rf.GatewayRefundID,
+		&rf.Amount,
+		&rf.Currency,
+		&rf.Reason,
+		&rf.Status,
+		&rf.Unfulfilled,
+		&rf.Attempts,
+		&rf.NextAttemptAt,
+		&rf.LastError,
+		&rf.CreatedAt,
+		&rf.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &rf, nil
+}
+
+func scanRefunds(rows *sql.Rows) ([]*models.Refund, error) {
+	defer rows.Close()
+
+	var refunds []*models.Refund
+	for rows.Next() {
+		rf, err := scanRefund(rows)
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, rf)
+	}
+
+	return refunds, rows.Err()
+}
+
+func (r *RefundRepository) CreateTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	rf *models.Refund,
+) error {
+
+	query := `
+		INSERT INTO refunds (
+			id,
+			payment_id,
+			booking_id,
+			package_purchase_id,
+			gateway,
+			amount,
+			currency,
+			reason,
+			status,
+			unfulfilled,
+			attempts,
+			next_attempt_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+	`
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		rf.ID,
+		rf.PaymentID,
+		rf.BookingID,
+		rf.PackagePurchaseID,
+		rf.Gateway,
+		rf.Amount,
+		rf.Currency,
+		rf.Reason,
+		rf.Status,
+		rf.Unfulfilled,
+		rf.Attempts,
+		rf.NextAttemptAt,
+	)
+
+	return err
+}
+
+func (r *RefundRepository) GetByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (*models.Refund, error) {
+
+	query := `SELECT` + refundColumns + `
+		FROM refunds
+		WHERE id = $1
+	`
+
+	return scanRefund(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r *RefundRepository) GetByGatewayRefundID(
+	ctx context.Context,
+	gatewayRefundID string,
+) (*models.Refund, error) {
+
+	query := `SELECT` + refundColumns + `
+		FROM refunds
+		WHERE gateway_refund_id = $1
+	`
+
+	return scanRefund(r.db.QueryRowContext(ctx, query, gatewayRefundID))
+}
+
+// GetForUpdateTx reloads a refund inside a transaction and locks it so
+// concurrent webhook deliveries apply their transition only once.
+func (r *RefundRepository) GetForUpdateTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	id uuid.UUID,
+) (*models.Refund, error) {
+
+	query := `SELECT` + refundColumns + `
+		FROM refunds
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	return scanRefund(tx.QueryRowContext(ctx, query, id))
+}
+
+func (r *RefundRepository) SetGatewayRefundID(
+	ctx context.Context,
+	id uuid.UUID,
+	gatewayRefundID string,
+) error {
+
+	query := `
+		UPDATE refunds
+		SET
+			gateway_refund_id = $2,
+			next_attempt_at = NULL,
+			last_error = NULL,
+			updated_at = now()
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, gatewayRefundID)
+	return err
+}
+
+func (r *RefundRepository) UpdateStatusTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	id uuid.UUID,
+	status string,
+) error {
+
+	query := `
+		UPDATE refunds
+		SET
+			status = $2,
+			updated_at = now()
+		WHERE id = $1
+	`
+
+	_, err := tx.ExecContext(ctx, query, id, status)
+	return err
+}
+
+// ClaimUnsubmitted returns up to limit pending refunds that are due for
+// submission to the gateway. Claiming counts an attempt and pushes the
+// next one out to leaseUntil, so a refund whose worker died is tried
+// again once the lease runs out and concurrent workers skip it.
+func (r *RefundRepository) ClaimUnsubmitted(
+	ctx context.Context,
+	now time.Time,
+	leaseUntil time.Time,
+	limit int,
+) ([]*models.Refund, error) {
+
+	query := `
+		UPDATE refunds
+		SET
+			attempts = attempts + 1,
+			next_attempt_at = $2,
+			updated_at = $1
+		WHERE id IN (
+			SELECT id
+			FROM refunds
+			WHERE status = 'pending'
+			  AND gateway_refund_id IS NULL
+			  AND next_attempt_at <= $1
+			ORDER BY created_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + refundColumns
+
+	rows, err := r.db.QueryContext(ctx, query, now, leaseUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanRefunds(rows)
+}
+
+// MarkSubmitRetry records a failed submission and schedules the next one
+func (r *RefundRepository) MarkSubmitRetry(
+	ctx context.Context,
+	id uuid.UUID,
+	nextAttemptAt time.Time,
+	lastError string,
+) error {
+
+	query := `
+		UPDATE refunds
+		SET
+			next_attempt_at = $2,
+			last_error = $3,
+			updated_at = now()
+		WHERE id = $1
+		  AND gateway_refund_id IS NULL
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, nextAttemptAt, lastError)
+	return err
+}
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+)
+
+type SessionEventRepository struct {
+	db *sql.DB
+}
+
+func NewSessionEventRepository(db *sql.DB) *SessionEventRepository {
+	return &SessionEventRepository{db: db}
+}
+
+func (r *SessionEventRepository) Create(
+	ctx context.Context,
+	e *models.SessionEvent,
+) error {
+
+	const query = `
+	INSERT INTO session_events (
+		id,
+		booking_id,
+		user_id,
+		role,
+		event_type,
+		occurred_at,
+		source
+	)
+	VALUES ($1,$2,$3,$4,$5,$6,$7)
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		e.ID,
+		e.BookingID,
+		e.UserID,
+		e.Role,
+		e.EventType,
+		e.OccurredAt,
+		e.Source,
+	)
+
+	return err
+}
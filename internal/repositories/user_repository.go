@@ -13,14 +13,25 @@ import (
 
 type UserRepository struct {
 	db *sql.DB
+
+	// reader serves read-only queries that tolerate replica lag
+	reader *sql.DB
 }
 
 func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{
-		db: db,
+		db:     db,
+		reader: db,
 	}
 }
 
+// WithReader routes lag-tolerant read-only queries to reader, typically a
+// read replica. Writes and transactional reads stay on the primary.
+func (r *UserRepository) WithReader(reader *sql.DB) *UserRepository {
+	r.reader = reader
+	return r
+}
+
 /*
 ExistsByEmail checks whether a user already exists with given email
 */
@@ -285,7 +296,7 @@ func (r *UserRepository) FindPublicProfileByUsername(
 	var resp dtos.UserProfileResponse
 	var mentorTitle string
 
-	err := r.db.QueryRowContext(ctx, query, username).Scan(
+	err := r.reader.QueryRowContext(ctx, query, username).Scan(
 		&resp.User.ID,
 		&resp.User.Username,
 		&resp.User.FirstName,
@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+)
+
+var ErrVideoSessionNotFound = errors.New("video session not found")
+
+type VideoSessionRepository struct {
+	db *sql.DB
+}
+
+func NewVideoSessionRepository(db *sql.DB) *VideoSessionRepository {
+	return &VideoSessionRepository{db: db}
+}
+
+const videoSessionColumns = `
+		booking_id,
+		room_id,
+		started_at,
+		ended_at,
+		opened_at,
+		duration_seconds,
+		created_at,
+		updated_at
+`
+
+func scanVideoSession(row *sql.Row) (*models.VideoSession, error) {
+	var v models.VideoSession
+
+	err := row.Scan(
+		&v.BookingID,
+		&v.RoomID,
+		&v.StartedAt,
+		&v.EndedAt,
+		&v.OpenedAt,
+		&v.DurationSeconds,
+		&v.CreatedAt,
+		&v.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrVideoSessionNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// EnsureRoom registers the room of a booking so callbacks for it can be
+// traced back. It is a no-op when the room is already known.
+func (r *VideoSessionRepository) EnsureRoom(
+	ctx context.Context,
+	bookingID uuid.UUID,
+	roomID string,
+) error {
+
+	const query = `
+	INSERT INTO video_sessions (booking_id, room_id)
+	VALUES ($1, $2)
+	ON CONFLICT (booking_id) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query, bookingID, roomID)
+	return err
+}
+
+func (r *VideoSessionRepository) GetByRoomID(
+	ctx context.Context,
+	roomID string,
+) (*models.VideoSession, error) {
+
+	query := `SELECT` + videoSessionColumns + `
+	FROM video_sessions
+	WHERE room_id = $1
+	`
+
+	return scanVideoSession(r.db.QueryRowContext(ctx, query, roomID))
+}
+
+// MarkOpened records that the room was created at. The first creation
+// is the session's start; a room that is already open is left as is.
+func (r *VideoSessionRepository) MarkOpened(
+	ctx context.Context,
+	bookingID uuid.UUID,
+	at time.Time,
+) error {
+
+	const query = `
+	UPDATE video_sessions
+	SET
+		started_at = LEAST(COALESCE(started_at, $2::timestamptz), $2::timestamptz),
+		opened_at = COALESCE(opened_at, $2::timestamptz),
+		ended_at = NULL,
+		updated_at = NOW()
+	WHERE booking_id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, bookingID, at)
+	return err
+}
+
+// MarkClosed records that the room was closed at and adds the time it
+// was open to the session's duration.
+func (r *VideoSessionRepository) MarkClosed(
+	ctx context.Context,
+	bookingID uuid.UUID,
+	at time.Time,
+) error {
+
+	const query = `
+	UPDATE video_sessions
+	SET
+		duration_seconds = duration_seconds + CASE
+			WHEN opened_at IS NULL THEN 0
+			ELSE GREATEST(0, EXTRACT(EPOCH FROM ($2::timestamptz - opened_at))::int)
+		END,
+		opened_at = NULL,
+		ended_at = GREATEST(COALESCE(ended_at, $2::timestamptz), $2::timestamptz),
+		updated_at = NOW()
+	WHERE booking_id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, bookingID, at)
+	return err
+}
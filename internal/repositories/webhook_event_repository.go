@@ -0,0 +1,311 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+)
+
+var (
+	ErrWebhookEventNotFound      = errors.New("webhook event not found")
+	ErrWebhookEventNotReplayable = errors.New("only failed or processed webhook events can be replayed")
+)
+
+type WebhookEventRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookEventRepository(db *sql.DB) *WebhookEventRepository {
+	return &WebhookEventRepository{db: db}
+}
+
+const webhookEventColumns = `
+		id,
+		gateway,
+		event_id,
+		event_type,
+		payload,
+		signature,
+		signature_valid,
+		secret_id,
+		status,
+		attempts,
+		next_attempt_at,
+		last_error,
+		received_at,
+		processed_at,
+		updated_at
+`
+
+func scanWebhookEvent(row rowScanner) (*models.WebhookEvent, error) {
+	var e models.WebhookEvent
+
+	err := row.Scan(
+		&e.ID,
+		&e.Gateway,
+		&e.EventID,
+		&e.EventType,
+		&e.Payload,
+		&e.Signature,
+		&e.SignatureValid,
+		&e.SecretID,
+		&e.Status,
+		&e.Attempts,
+		&e.NextAttemptAt,
+		&e.LastError,
+		&e.ReceivedAt,
+		&e.ProcessedAt,
+		&e.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookEventNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+func scanWebhookEvents(rows *sql.Rows) ([]*models.WebhookEvent, error) {
+	defer rows.Close()
+
+	var events []*models.WebhookEvent
+	for rows.Next() {
+		e, err := scanWebhookEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// Create stores a received event. It returns false when a verified event
+// with the same gateway event ID is already stored, i.e. a redelivery.
+func (r *WebhookEventRepository) Create(
+	ctx context.Context,
+	e *models.WebhookEvent,
+) (bool, error) {
+
+	query := `
+		INSERT INTO webhook_events (
+			id,
+			gateway,
+			event_id,
+			event_type,
+			payload,
+			signature,
+			signature_valid,
+			secret_id,
+			status,
+			next_attempt_at,
+			last_error
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (gateway, event_id) WHERE signature_valid DO NOTHING
+	`
+
+	res, err := r.db.ExecContext(
+		ctx,
+		query,
+		e.ID,
+		e.Gateway,
+		e.EventID,
+		e.EventType,
+		e.Payload,
+		e.Signature,
+		e.SignatureValid,
+		e.SecretID,
+		e.Status,
+		e.NextAttemptAt,
+		e.LastError,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// ClaimDue marks up to limit due events as processing and returns them.
+// Events left processing since before staleBefore belonged to a worker
+// that died and are claimed again.
+func (r *WebhookEventRepository) ClaimDue(
+	ctx context.Context,
+	now time.Time,
+	staleBefore time.Time,
+	limit int,
+) ([]*models.WebhookEvent, error) {
+
+	query := `
+		UPDATE webhook_events
+		SET
+			status = 'processing',
+			attempts = attempts + 1,
+			updated_at = $1
+		WHERE id IN (
+			SELECT id
+			FROM webhook_events
+			WHERE (status = 'pending' AND next_attempt_at <= $1)
+				OR (status = 'processing' AND updated_at < $2)
+			ORDER BY received_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + webhookEventColumns
+
+	rows, err := r.db.QueryContext(ctx, query, now, staleBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanWebhookEvents(rows)
+}
+
+func (r *WebhookEventRepository) MarkProcessed(
+	ctx context.Context,
+	id uuid.UUID,
+) error {
+
+	query := `
+		UPDATE webhook_events
+		SET
+			status = 'processed',
+			next_attempt_at = NULL,
+			last_error = NULL,
+			processed_at = now(),
+			updated_at = now()
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// MarkRetry records a failed attempt and schedules the next one
+func (r *WebhookEventRepository) MarkRetry(
+	ctx context.Context,
+	id uuid.UUID,
+	nextAttemptAt time.Time,
+	lastError string,
+) error {
+
+	query := `
+		UPDATE webhook_events
+		SET
+			status = 'pending',
+			next_attempt_at = $2,
+			last_error = $3,
+			updated_at = now()
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, nextAttemptAt, lastError)
+	return err
+}
+
+// MarkFailed gives up on an event until it is replayed
+func (r *WebhookEventRepository) MarkFailed(
+	ctx context.Context,
+	id uuid.UUID,
+	lastError string,
+) error {
+
+	query := `
+		UPDATE webhook_events
+		SET
+			status = 'failed',
+			next_attempt_at = NULL,
+			last_error = $2,
+			updated_at = now()
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, lastError)
+	return err
+}
+
+// List returns the newest events, optionally filtered by status and
+// gateway. The payload is included; callers that only show a summary
+// should drop it.
+func (r *WebhookEventRepository) List(
+	ctx context.Context,
+	status string,
+	gateway string,
+	limit int,
+) ([]*models.WebhookEvent, error) {
+
+	query := `
+		SELECT ` + webhookEventColumns + `
+		FROM webhook_events
+		WHERE ($1 = '' OR status = $1)
+			AND ($2 = '' OR gateway = $2)
+		ORDER BY received_at DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, status, gateway, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanWebhookEvents(rows)
+}
+
+func (r *WebhookEventRepository) GetByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (*models.WebhookEvent, error) {
+
+	query := `
+		SELECT ` + webhookEventColumns + `
+		FROM webhook_events
+		WHERE id = $1
+	`
+
+	return scanWebhookEvent(r.db.QueryRowContext(ctx, query, id))
+}
+
+// Replay queues a failed or processed event to be processed again with a
+// fresh retry budget. Rejected events are never replayed.
+func (r *WebhookEventRepository) Replay(
+	ctx context.Context,
+	id uuid.UUID,
+) (*models.WebhookEvent, error) {
+
+	query := `
+		UPDATE webhook_events
+		SET
+			status = 'pending',
+			attempts = 0,
+			next_attempt_at = now(),
+			updated_at = now()
+		WHERE id = $1
+			AND status IN ('failed', 'processed')
+		RETURNING ` + webhookEventColumns
+
+	e, err := scanWebhookEvent(r.db.QueryRowContext(ctx, query, id))
+	if !errors.Is(err, ErrWebhookEventNotFound) {
+		return e, err
+	}
+
+	// tell a missing event apart from one in the wrong state
+	if _, getErr := r.GetByID(ctx, id); getErr != nil {
+		return nil, getErr
+	}
+
+	return nil, ErrWebhookEventNotReplayable
+}
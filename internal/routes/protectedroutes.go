@@ -2,6 +2,7 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/preetsinghmakkar/OpenCall/internal/constants"
 	"github.com/preetsinghmakkar/OpenCall/internal/handlers"
 	"github.com/preetsinghmakkar/OpenCall/internal/middlewares"
 )
@@ -12,11 +13,17 @@ func RegisterProtectedEndpoints(
 	mentorHandler *handlers.MentorHandler,
 	mentorServiceHandler *handlers.MentorServiceHandler,
 	mentorAvailabilityHandler *handlers.MentorAvailabilityHandler,
+	packageHandler *handlers.PackageHandler,
 	bookingHandler *handlers.BookingHandler,
 	paymentHandler *handlers.PaymentHandler,
 	authHandler *handlers.AuthHandler,
 	jwtSecret string,
 	zegoHandler *handlers.ZegoHandler,
+	earningsHandler *handlers.EarningsHandler,
+	payoutHandler *handlers.PayoutHandler,
+	webhookHandler *handlers.WebhookHandler,
+	couponHandler *handlers.CouponHandler,
+	invoiceHandler *handlers.InvoiceHandler,
 ) {
 	protected := router.Group("/api")
 	protected.Use(middlewares.AuthMiddleware(jwtSecret))
@@ -29,15 +36,56 @@ func RegisterProtectedEndpoints(
 
 	protected.POST("/mentor/profile", mentorHandler.CreateProfile)
 	protected.POST("/mentor/services", mentorServiceHandler.Create)
+	protected.GET("/mentor/services", mentorServiceHandler.ListOwn)
+	protected.PUT("/mentor/services/order", mentorServiceHandler.Reorder)
+	protected.PUT("/mentor/services/:id", mentorServiceHandler.Update)
+	protected.PATCH("/mentor/services/:id/active", mentorServiceHandler.SetActive)
+	protected.DELETE("/mentor/services/:id", mentorServiceHandler.Delete)
 	protected.POST("/mentor/availability", mentorAvailabilityHandler.Create)
+	protected.GET("/mentor/availability", mentorAvailabilityHandler.ListOwn)
+	protected.PUT("/mentor/availability", mentorAvailabilityHandler.Replace)
+	protected.PUT("/mentor/availability/:id", mentorAvailabilityHandler.Update)
+	protected.DELETE("/mentor/availability/:id", mentorAvailabilityHandler.Delete)
+	protected.GET("/mentor/availability/overrides", mentorAvailabilityHandler.ListOverrides)
+	protected.POST("/mentor/availability/overrides", mentorAvailabilityHandler.CreateOverride)
+	protected.DELETE("/mentor/availability/overrides/:id", mentorAvailabilityHandler.DeleteOverride)
+	protected.POST("/mentor/packages", packageHandler.Create)
+	protected.GET("/mentor/packages", packageHandler.ListOwn)
+	protected.DELETE("/mentor/packages/:id", packageHandler.Delete)
+	protected.POST("/mentor/coupons", couponHandler.CreateMentorCoupon)
+	protected.GET("/mentor/coupons", couponHandler.ListMentorCoupons)
+	protected.PATCH("/mentor/coupons/:id/active", couponHandler.SetMentorCouponActive)
+	protected.POST("/packages/:id/purchase", packageHandler.Purchase)
+	protected.GET("/credits/me", packageHandler.MyCredits)
 	protected.POST("/bookings", bookingHandler.CreateBooking)
 	protected.GET("/bookings/me", bookingHandler.GetMyBookings)
+	protected.POST("/bookings/:id/cancel", bookingHandler.CancelBooking)
+	protected.POST("/bookings/:id/reschedule", bookingHandler.RescheduleBooking)
+	protected.GET("/bookings/:id/invoice", invoiceHandler.GetBookingInvoice)
 	protected.GET("/mentor/booked-sessions", bookingHandler.GetMentorBookedSessions)
+	protected.GET("/mentor/earnings", earningsHandler.GetEarnings)
+	protected.GET("/mentor/payout-account", payoutHandler.GetAccount)
+	protected.PUT("/mentor/payout-account", payoutHandler.UpsertAccount)
+	protected.GET("/mentor/payouts", payoutHandler.ListPayouts)
 
 	protected.POST("/payments", paymentHandler.CreatePayment)
 	protected.POST("/payments/verify", paymentHandler.VerifyPayment)
 
 	// Zego routes
+	protected.POST("/zego/token", zegoHandler.GenerateToken)
 	protected.GET("/zego/session/:bookingID", zegoHandler.GetSessionInfo)
+	protected.POST("/zego/session/:bookingID/events", zegoHandler.RecordSessionEvent)
+
+	// Admin routes
+	admin := protected.Group("/admin")
+	admin.Use(middlewares.RequireRole(constants.RoleAdmin))
+
+	admin.GET("/webhooks", webhookHandler.ListEvents)
+	admin.GET("/webhooks/:id", webhookHandler.GetEvent)
+	admin.POST("/webhooks/:id/replay", webhookHandler.ReplayEvent)
+	admin.GET("/payments/mismatches", paymentHandler.ListMismatches)
+	admin.POST("/coupons", couponHandler.CreatePlatformCoupon)
+	admin.GET("/coupons", couponHandler.ListPlatformCoupons)
+	admin.PATCH("/coupons/:id/active", couponHandler.SetPlatformCouponActive)
 
 }
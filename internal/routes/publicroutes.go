@@ -13,7 +13,8 @@ func RegisterPublicEndpoints(
 	mentorHandler *handlers.MentorHandler,
 	mentorServiceHandler *handlers.MentorServiceHandler,
 	mentorAvailabilityHandler *handlers.MentorAvailabilityHandler,
-	paymentHandler *handlers.PaymentHandler,
+	packageHandler *handlers.PackageHandler,
+	webhookHandler *handlers.WebhookHandler,
 	bookingRepo *repositories.BookingRepository,
 	userRepo *repositories.UserRepository,
 	mentorRepo *repositories.MentorRepository,
@@ -30,12 +31,13 @@ func RegisterPublicEndpoints(
 	public.GET("/users/:username", userHandlers.GetUserProfile)
 	public.GET("/mentors/:username", mentorHandler.GetProfile)
 	public.GET("/mentors/:username/services", mentorServiceHandler.GetByUsername)
+	public.GET("/mentors/:username/services/:serviceID/slots", mentorAvailabilityHandler.GetSlotCalendar)
+	public.GET("/mentors/:username/packages", packageHandler.GetByUsername)
 
 	public.GET("/mentors/:username/availability", mentorAvailabilityHandler.GetByUsername)
 
-	public.POST("/webhooks/razorpay", paymentHandler.RazorpayWebhook)
-
-	// Zego routes
-	public.POST("/zego/token", zegoHandler.GenerateToken)
+	public.POST("/webhooks/razorpay", webhookHandler.RazorpayWebhook)
+	public.POST("/webhooks/stripe", webhookHandler.StripeWebhook)
+	public.POST("/webhooks/zego", zegoHandler.ZegoCallback)
 
 }
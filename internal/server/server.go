@@ -18,12 +18,20 @@ type Server struct {
 	l      zerolog.Logger
 	router *gin.Engine
 	config *configs.Config
+
+	shutdownHooks []func()
 }
 
 func NewServer(l zerolog.Logger, router *gin.Engine, config *configs.Config) *Server {
 	return &Server{l: l, router: router, config: config}
 }
 
+// OnShutdown registers fn to run after the HTTP server has stopped
+// accepting requests, e.g. to stop background jobs.
+func (s *Server) OnShutdown(fn func()) {
+	s.shutdownHooks = append(s.shutdownHooks, fn)
+}
+
 // Serve creates a new http.Server with support for graceful shutdown
 func (s *Server) Serve() {
 	srv := &http.Server{
@@ -53,6 +61,10 @@ func (s *Server) Serve() {
 	if err := srv.Shutdown(ctx); err != nil {
 		s.l.Fatal().Err(err).Msg("Server Shutdown")
 	}
+
+	for _, hook := range s.shutdownHooks {
+		hook()
+	}
 	// catching ctx.Done(). timeout of 30 seconds.
 
 	<-ctx.Done()
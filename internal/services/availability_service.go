@@ -1,120 +1,444 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
 	"github.com/preetsinghmakkar/OpenCall/internal/models"
 	"github.com/preetsinghmakkar/OpenCall/internal/repositories"
+	"github.com/preetsinghmakkar/OpenCall/internal/utils"
 )
 
 type AvailabilityService struct {
 	mentorRepo       *repositories.MentorRepository
 	serviceRepo      *repositories.MentorServiceRepository
 	availabilityRepo *repositories.MentorAvailabilityRepository
+	overrideRepo     *repositories.MentorAvailabilityOverrideRepository
 	bookingRepo      *repositories.BookingRepository
+	limits           BookingLimits
 }
 
 func NewAvailabilityService(
 	mentorRepo *repositories.MentorRepository,
 	serviceRepo *repositories.MentorServiceRepository,
 	availabilityRepo *repositories.MentorAvailabilityRepository,
+	overrideRepo *repositories.MentorAvailabilityOverrideRepository,
 	bookingRepo *repositories.BookingRepository,
+	limits BookingLimits,
 ) *AvailabilityService {
 	return &AvailabilityService{
 		mentorRepo:       mentorRepo,
 		serviceRepo:      serviceRepo,
 		availabilityRepo: availabilityRepo,
+		overrideRepo:     overrideRepo,
 		bookingRepo:      bookingRepo,
+		limits:           limits,
 	}
 }
 
+// Longest range GetSlotCalendar serves in one request
+const maxCalendarDays = 60
+
+// How far around a range bookings can still affect it: the largest
+// buffer on each side plus the largest gap allowed on a service.
+const bookingLookaround = 12 * time.Hour
+
+// GetAvailableSlots returns the free slots of a service on a calendar day.
+// Availability rules are wall-clock times in the mentor's timezone. The
+// day and the returned slot times are in tz, or in the mentor's timezone
+// when tz is empty.
 func (s *AvailabilityService) GetAvailableSlots(
 	username string,
 	serviceID uuid.UUID,
 	dateStr string,
+	tz string,
 ) (*dtos.AvailabilityResponse, error) {
 
-	date, err := time.Parse("2006-01-02", dateStr)
+	mentor, service, mentorLoc, viewerLoc, err := s.load(username, serviceID, tz)
+	if err != nil {
+		return nil, err
+	}
+
+	day, err := utils.ParseLocalDate(dateStr, viewerLoc)
 	if err != nil {
 		return nil, errors.New("invalid date")
 	}
 
-	mentor, err := s.mentorRepo.FindByUsernameRaw(username)
+	// AddDate keeps the wall clock, so DST days are 23 or 25 hours long
+	free, err := s.freeSlots(mentor, service, mentorLoc, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	return &dtos.AvailabilityResponse{
+		Date:     dateStr,
+		Timezone: viewerLoc.String(),
+		Slots:    slotResponses(free, viewerLoc),
+	}, nil
+}
+
+// GetSlotCalendar returns the free slots of a service for every day from
+// fromStr to toStr inclusive, at most maxCalendarDays days. Dates and slot
+// times are in tz, or in the mentor's timezone when tz is empty. from
+// defaults to today and to defaults to the end of the allowed range.
+func (s *AvailabilityService) GetSlotCalendar(
+	username string,
+	serviceID uuid.UUID,
+	fromStr string,
+	toStr string,
+	tz string,
+) (*dtos.SlotCalendarResponse, error) {
+
+	mentor, service, mentorLoc, viewerLoc, err := s.load(username, serviceID, tz)
 	if err != nil {
 		return nil, err
 	}
 
+	now := time.Now().In(viewerLoc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, viewerLoc)
+
+	if fromStr != "" {
+		if from, err = utils.ParseLocalDate(fromStr, viewerLoc); err != nil {
+			return nil, errors.New("invalid from date")
+		}
+	}
+
+	to := from.AddDate(0, 0, maxCalendarDays-1)
+	if toStr != "" {
+		if to, err = utils.ParseLocalDate(toStr, viewerLoc); err != nil {
+			return nil, errors.New("invalid to date")
+		}
+	}
+
+	if to.Before(from) {
+		return nil, errors.New("to must not be before from")
+	}
+	if to.After(from.AddDate(0, 0, maxCalendarDays-1)) {
+		return nil, fmt.Errorf("range is limited to %d days", maxCalendarDays)
+	}
+
+	free, err := s.freeSlots(mentor, service, mentorLoc, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	// Every day in the range is listed, including the ones with no slots
+	days := []dtos.SlotCalendarDay{}
+	index := map[string]int{}
+
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		index[date] = len(days)
+		days = append(days, dtos.SlotCalendarDay{Date: date, Slots: []dtos.AvailableSlot{}})
+	}
+
+	for _, slot := range slotResponses(free, viewerLoc) {
+		i := index[slot.StartAt.In(viewerLoc).Format("2006-01-02")]
+		days[i].Slots = append(days[i].Slots, slot)
+	}
+
+	return &dtos.SlotCalendarResponse{
+		From:     from.Format("2006-01-02"),
+		To:       to.Format("2006-01-02"),
+		Timezone: viewerLoc.String(),
+		Days:     days,
+	}, nil
+}
+
+// load resolves the mentor, an active service of theirs, the mentor's
+// timezone and the viewer's timezone (the mentor's when tz is empty).
+func (s *AvailabilityService) load(
+	username string,
+	serviceID uuid.UUID,
+	tz string,
+) (*models.MentorProfile, *models.MentorService, *time.Location, *time.Location, error) {
+
+	mentor, err := s.mentorRepo.FindByUsernameRaw(username)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
 	service, err := s.serviceRepo.FindByID(serviceID)
 	if err != nil || service.MentorID != mentor.ID {
-		return nil, errors.New("invalid service")
+		return nil, nil, nil, nil, errors.New("invalid service")
+	}
+
+	mentorLoc, err := utils.LoadLocation(mentor.Timezone)
+	if err != nil {
+		return nil, nil, nil, nil, errors.New("mentor has an invalid timezone")
+	}
+
+	viewerLoc := mentorLoc
+	if tz != "" {
+		viewerLoc, err = utils.LoadLocation(tz)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
 	}
 
-	dayOfWeek := int(date.Weekday())
+	return mentor, service, mentorLoc, viewerLoc, nil
+}
+
+// freeSlots returns the bookable slots of service that start in
+// [from, to), sorted by start. Rules, overrides and bookings are each
+// loaded with a single query for the whole range.
+func (s *AvailabilityService) freeSlots(
+	mentor *models.MentorProfile,
+	service *models.MentorService,
+	mentorLoc *time.Location,
+	from time.Time,
+	to time.Time,
+) ([]slotWindow, error) {
+
+	earliest, latest := s.limits.bounds(time.Now())
+	if from.Before(earliest) {
+		from = earliest
+	}
+	if to.After(latest) {
+		to = latest.Add(time.Nanosecond) // latest itself is still allowed
+	}
+	if !from.Before(to) {
+		return nil, nil
+	}
+
+	duration := time.Duration(service.DurationMinutes) * time.Minute
 
-	rules, err := s.availabilityRepo.FindByMentorAndDay(mentor.ID, dayOfWeek)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rules, err := s.availabilityRepo.FindByMentorID(ctx, mentor.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	bookings, err := s.bookingRepo.FindForMentorOnDate(
+	rulesByDay := map[time.Weekday][]*models.MentorAvailabilityRule{}
+	for _, r := range rules {
+		day := time.Weekday(r.DayOfWeek)
+		rulesByDay[day] = append(rulesByDay[day], r)
+	}
+
+	// The range in the viewer's zone can straddle extra mentor days
+	mentorDays := localDaysBetween(from, to, mentorLoc)
+
+	overrides, err := s.overrideRepo.FindByMentorBetween(
 		mentor.ID,
-		date,
+		mentorDays[0],
+		mentorDays[len(mentorDays)-1],
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	duration := time.Duration(service.DurationMinutes) * time.Minute
-	slots := []dtos.AvailableSlot{}
+	// Whole mentor days are loaded so that the daily cap can be counted
+	bookings, err := s.bookingRepo.FindForMentorBetween(
+		mentor.ID,
+		mentorDays[0].Add(-bookingLookaround),
+		mentorDays[len(mentorDays)-1].AddDate(0, 0, 1).Add(bookingLookaround),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	perDay := map[string]int{}
+	for _, b := range bookings {
+		if b.ServiceID == service.ID {
+			perDay[b.BookingDate.Format("2006-01-02")]++
+		}
+	}
+
+	var free []slotWindow
+
+	for _, mentorDay := range mentorDays {
+		if service.MaxSessionsPerDay > 0 &&
+			perDay[mentorDay.Format("2006-01-02")] >= service.MaxSessionsPerDay {
+			continue
+		}
+
+		windows := dayWindows(rulesByDay[mentorDay.Weekday()], overrides, mentorDay, mentorLoc)
+
+		for _, slot := range generateSlots(windows, duration, slotStep(service)) {
+			if slot.start.Before(from) || !slot.start.Before(to) {
+				continue
+			}
+
+			if blocked(reservationFor(slot.start, slot.end, service), bookings) {
+				continue
+			}
+
+			free = append(free, slot)
+		}
+	}
+
+	sort.Slice(free, func(i, j int) bool {
+		return free[i].start.Before(free[j].start)
+	})
+
+	return free, nil
+}
+
+func slotResponses(slots []slotWindow, loc *time.Location) []dtos.AvailableSlot {
+	resp := make([]dtos.AvailableSlot, 0, len(slots))
+
+	for _, slot := range slots {
+		resp = append(resp, dtos.AvailableSlot{
+			Start:   slot.start.In(loc).Format("15:04"),
+			End:     slot.end.In(loc).Format("15:04"),
+			StartAt: slot.start.UTC(),
+			EndAt:   slot.end.UTC(),
+		})
+	}
+
+	return resp
+}
+
+type slotWindow struct {
+	start time.Time
+	end   time.Time
+}
+
+// dayWindows returns the bookable windows of one mentor-local day: the
+// weekly rules plus any extra windows for that date, minus blocked time.
+// A blackout on the date removes the whole day. Overrides for other dates
+// are ignored, so callers can pass a whole range at once.
+func dayWindows(
+	rules []*models.MentorAvailabilityRule,
+	overrides []*models.MentorAvailabilityOverride,
+	day time.Time,
+	loc *time.Location,
+) []slotWindow {
+
+	date := day.Format("2006-01-02")
+
+	var windows, blocks []slotWindow
 
 	for _, rule := range rules {
-		start := time.Date(
-			date.Year(), date.Month(), date.Day(),
-			rule.StartTime.Hour(), rule.StartTime.Minute(), 0, 0, time.UTC,
-		)
-
-		end := time.Date(
-			date.Year(), date.Month(), date.Day(),
-			rule.EndTime.Hour(), rule.EndTime.Minute(), 0, 0, time.UTC,
-		)
-
-		for start.Add(duration).Equal(end) || start.Add(duration).Before(end) {
-			slotEnd := start.Add(duration)
-
-			if !overlaps(start, slotEnd, bookings) {
-				slots = append(slots, dtos.AvailableSlot{
-					Start: start.Format("15:04"),
-					End:   slotEnd.Format("15:04"),
-				})
+		windows = append(windows, slotWindow{
+			start: utils.AtWallClock(day, rule.StartTime, loc),
+			end:   utils.AtWallClock(day, rule.EndTime, loc),
+		})
+	}
+
+	for _, o := range overrides {
+		if o.Date.Format("2006-01-02") != date {
+			continue
+		}
+
+		switch o.Kind {
+		case models.OverrideKindBlackout:
+			return nil
+
+		case models.OverrideKindExtra, models.OverrideKindBlock:
+			w := slotWindow{
+				start: utils.AtWallClock(day, *o.StartTime, loc),
+				end:   utils.AtWallClock(day, *o.EndTime, loc),
 			}
+			if o.Kind == models.OverrideKindExtra {
+				windows = append(windows, w)
+			} else {
+				blocks = append(blocks, w)
+			}
+		}
+	}
+
+	windows = mergeWindows(windows)
+
+	for _, block := range blocks {
+		windows = subtractWindow(windows, block)
+	}
+
+	return windows
+}
+
+// mergeWindows sorts windows and joins the ones that overlap or touch.
+func mergeWindows(windows []slotWindow) []slotWindow {
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].start.Before(windows[j].start)
+	})
 
-			start = slotEnd
+	var merged []slotWindow
+
+	for _, w := range windows {
+		if n := len(merged); n > 0 && !w.start.After(merged[n-1].end) {
+			if w.end.After(merged[n-1].end) {
+				merged[n-1].end = w.end
+			}
+			continue
 		}
+		merged = append(merged, w)
 	}
 
-	return &dtos.AvailabilityResponse{
-		Date:  dateStr,
-		Slots: slots,
-	}, nil
+	return merged
 }
 
-func overlaps(start, end time.Time, bookings []*models.Booking) bool {
-	for _, b := range bookings {
+// subtractWindow removes block from every window, splitting a window in
+// two when the block falls in its middle.
+func subtractWindow(windows []slotWindow, block slotWindow) []slotWindow {
+	var result []slotWindow
+
+	for _, w := range windows {
+		if !block.start.Before(w.end) || !block.end.After(w.start) {
+			result = append(result, w)
+			continue
+		}
+
+		if block.start.After(w.start) {
+			result = append(result, slotWindow{start: w.start, end: block.start})
+		}
+		if block.end.Before(w.end) {
+			result = append(result, slotWindow{start: block.end, end: w.end})
+		}
+	}
+
+	return result
+}
 
-		bStart := time.Date(
-			start.Year(), start.Month(), start.Day(),
-			b.StartTime.Hour(), b.StartTime.Minute(), 0, 0, time.UTC,
-		)
+// generateSlots lays out slots of duration inside each window, starting
+// every step from the window start. Slots step in absolute time, so a
+// window spanning a DST transition yields the hours that actually exist.
+func generateSlots(
+	windows []slotWindow,
+	duration time.Duration,
+	step time.Duration,
+) []slotWindow {
 
-		bEnd := time.Date(
-			start.Year(), start.Month(), start.Day(),
-			b.EndTime.Hour(), b.EndTime.Minute(), 0, 0, time.UTC,
-		)
+	var slots []slotWindow
 
-		if start.Before(bEnd) && end.After(bStart) {
+	for _, w := range windows {
+		for start := w.start; !start.Add(duration).After(w.end); start = start.Add(step) {
+			slots = append(slots, slotWindow{start: start, end: start.Add(duration)})
+		}
+	}
+
+	return slots
+}
+
+// localDaysBetween returns midnight in loc for every calendar day in loc
+// that overlaps [from, to).
+func localDaysBetween(from, to time.Time, loc *time.Location) []time.Time {
+	first := from.In(loc)
+	last := to.Add(-time.Nanosecond).In(loc)
+
+	day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc)
+	lastDay := time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, loc)
+
+	var days []time.Time
+	for !day.After(lastDay) {
+		days = append(days, day)
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return days
+}
+
+// blocked reports whether a reservation clashes with any booking.
+func blocked(res reservation, bookings []*models.Booking) bool {
+	for _, b := range bookings {
+		if res.clashes(bookingReservation(b)) {
 			return true
 		}
 	}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// BookingLimits bound how soon and how far ahead a session can start.
+// They apply both to the slots offered and to the slots accepted.
+type BookingLimits struct {
+	// MinNotice is the least time between booking and session start
+	MinNotice time.Duration
+	// MaxHorizon is how far ahead of now a session may start
+	MaxHorizon time.Duration
+}
+
+// bounds returns the earliest and latest allowed session starts at now.
+func (l BookingLimits) bounds(now time.Time) (time.Time, time.Time) {
+	return now.Add(l.MinNotice), now.Add(l.MaxHorizon)
+}
+
+// check reports why a session starting at start cannot be booked at now.
+func (l BookingLimits) check(start time.Time, now time.Time) error {
+	earliest, latest := l.bounds(now)
+
+	if !start.After(now) {
+		return errors.New("selected slot is in the past")
+	}
+	if start.Before(earliest) {
+		return fmt.Errorf(
+			"selected slot is too soon, sessions need %d minutes notice",
+			int(l.MinNotice.Minutes()),
+		)
+	}
+	if start.After(latest) {
+		return errors.New("selected slot is too far in the future")
+	}
+
+	return nil
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
+	appErrors "github.com/preetsinghmakkar/OpenCall/internal/errors"
 	"github.com/preetsinghmakkar/OpenCall/internal/models"
 	"github.com/preetsinghmakkar/OpenCall/internal/repositories"
+	"github.com/preetsinghmakkar/OpenCall/internal/utils"
+	"github.com/rs/zerolog/log"
 )
 
 type BookingService struct {
@@ -17,6 +21,12 @@ type BookingService struct {
 	mentorRepo       *repositories.MentorRepository
 	serviceRepo      *repositories.MentorServiceRepository
 	availabilityRepo *repositories.MentorAvailabilityRepository
+	overrideRepo     *repositories.MentorAvailabilityOverrideRepository
+	paymentRepo      *repositories.PaymentRepository
+	purchaseRepo     *repositories.PackagePurchaseRepository
+	paymentService   *PaymentService
+	couponService    *CouponService
+	limits           BookingLimits
 }
 
 func NewBookingService(
@@ -24,12 +34,24 @@ func NewBookingService(
 	mentorRepo *repositories.MentorRepository,
 	serviceRepo *repositories.MentorServiceRepository,
 	availabilityRepo *repositories.MentorAvailabilityRepository,
+	overrideRepo *repositories.MentorAvailabilityOverrideRepository,
+	paymentRepo *repositories.PaymentRepository,
+	purchaseRepo *repositories.PackagePurchaseRepository,
+	paymentService *PaymentService,
+	couponService *CouponService,
+	limits BookingLimits,
 ) *BookingService {
 	return &BookingService{
 		bookingRepo:      bookingRepo,
 		mentorRepo:       mentorRepo,
 		serviceRepo:      serviceRepo,
 		availabilityRepo: availabilityRepo,
+		overrideRepo:     overrideRepo,
+		paymentRepo:      paymentRepo,
+		purchaseRepo:     purchaseRepo,
+		paymentService:   paymentService,
+		couponService:    couponService,
+		limits:           limits,
 	}
 }
 
@@ -38,130 +60,586 @@ func (s *BookingService) CreateBooking(
 	req *dtos.CreateBookingRequest,
 ) (*dtos.BookingResponse, error) {
 
-	// 1️⃣ Parse date
-	bookingDate, err := time.Parse("2006-01-02", req.BookingDate)
-	if err != nil {
-		return nil, errors.New("invalid date format")
-	}
-
-	// 2️⃣ Parse start time
-	startTimeParsed, err := time.Parse("15:04", req.StartTime)
-	if err != nil {
-		return nil, errors.New("invalid start time")
-	}
-
-	// 3️⃣ Fetch service
+	// 1️⃣ Fetch service
 	service, err := s.serviceRepo.FindByID(req.ServiceID)
 	if err != nil || !service.IsActive {
 		return nil, errors.New("invalid service")
 	}
 
-	// 4️⃣ Fetch mentor
+	// 2️⃣ Fetch mentor
 	mentor, err := s.mentorRepo.FindByID(service.MentorID)
 	if err != nil || !mentor.IsActive {
 		return nil, errors.New("mentor not available")
 	}
 
-	// 4a️⃣ Prevent mentors from booking themselves
+	// 2a️⃣ Prevent mentors from booking themselves
 	if mentor.UserID == userID {
 		return nil, errors.New("cannot book your own service")
 	}
 
-	// 5️⃣ Build start/end datetime (UTC)
-	start := time.Date(
-		bookingDate.Year(), bookingDate.Month(), bookingDate.Day(),
-		startTimeParsed.Hour(), startTimeParsed.Minute(),
-		0, 0, time.UTC,
+	// 3️⃣ Parse and validate the slot against availability rules
+	bookingDate, start, end, err := s.resolveSlot(
+		mentor,
+		time.Duration(service.DurationMinutes)*time.Minute,
+		req.BookingDate,
+		req.StartTime,
+		req.Timezone,
 	)
+	if err != nil {
+		return nil, err
+	}
 
-	duration := time.Duration(service.DurationMinutes) * time.Minute
-	end := start.Add(duration)
+	if err := s.limits.check(start, time.Now()); err != nil {
+		return nil, err
+	}
 
-	// 6️⃣ Validate availability rules
-	dayOfWeek := int(bookingDate.Weekday())
+	// 4️⃣ TRANSACTION START
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	rules, err := s.availabilityRepo.FindByMentorAndDay(mentor.ID, dayOfWeek)
-	if err != nil || len(rules) == 0 {
-		return nil, errors.New("mentor not available on this day")
+	res := reservationFor(start, end, service)
+
+	booking := &models.Booking{
+		ID:          uuid.New(),
+		MentorID:    mentor.ID,
+		UserID:      userID,
+		ServiceID:   service.ID,
+		BookingDate: bookingDate,
+		StartTime:   start,
+		EndTime:     end,
+		Status:      models.BookingStatusPending,
+		PriceCents:  service.PriceCents,
+		Currency:    service.Currency,
+
+		BlockedFrom:   res.from,
+		BlockedUntil:  res.until,
+		MinGapMinutes: service.MinGapMinutes,
 	}
+	paidWithCredit := false
+	free := service.PriceCents == 0
+	var redemption *models.CouponRedemption
 
-	valid := false
-	for _, r := range rules {
-		ruleStart := time.Date(
-			start.Year(), start.Month(), start.Day(),
-			r.StartTime.Hour(), r.StartTime.Minute(),
-			0, 0, time.UTC,
-		)
+	err = s.bookingRepo.WithTx(ctx, func(tx *sql.Tx) error {
 
-		ruleEnd := time.Date(
-			start.Year(), start.Month(), start.Day(),
-			r.EndTime.Hour(), r.EndTime.Minute(),
-			0, 0, time.UTC,
+		conflict, err := s.bookingRepo.HasConflictTx(
+			ctx,
+			tx,
+			mentor.ID,
+			res.from,
+			res.until,
+			service.MinGapMinutes,
+			uuid.Nil,
 		)
+		if err != nil {
+			return err
+		}
+
+		if conflict {
+			return errors.New("slot already booked")
+		}
+
+		if service.MaxSessionsPerDay > 0 {
+			n, err := s.bookingRepo.CountForServiceDayTx(ctx, tx, service.ID, bookingDate, uuid.Nil)
+			if err != nil {
+				return err
+			}
+			if n >= service.MaxSessionsPerDay {
+				return errors.New("no more sessions of this service are available on this day")
+			}
+		}
+
+		// Free sessions need no payment. The cap is checked here, under
+		// the serializable transaction, so concurrent bookings can't both
+		// take the last free session.
+		if free && service.FreeBookingsPerMentee > 0 {
+			n, err := s.bookingRepo.CountFreeForUserTx(ctx, tx, service.ID, userID)
+			if err != nil {
+				return err
+			}
+			if n >= service.FreeBookingsPerMentee {
+				return errors.New("you have already booked the free sessions of this service")
+			}
+		}
+
+		var creditID uuid.UUID
+
+		switch {
+		// A coupon lowers the price the mentee pays; it is used instead of
+		// any package credit. The coupon stays locked until commit, so its
+		// limits hold under concurrent bookings.
+		case req.CouponCode != "":
+			redemption, err = s.couponService.ApplyTx(ctx, tx, req.CouponCode, userID, service, time.Now())
+			if err != nil {
+				return err
+			}
+			redemption.BookingID = booking.ID
+			booking.PriceCents -= redemption.DiscountCents
+
+		// A package credit for this service pays for the session.
+		case !free:
+			creditID, err = s.purchaseRepo.FindAvailableCreditTx(ctx, tx, userID, service.ID)
+			if err != nil && !errors.Is(err, repositories.ErrNoSessionCredit) {
+				return err
+			}
+
+			paidWithCredit = err == nil
+			if paidWithCredit {
+				booking.PriceCents = 0
+			}
+		}
+
+		// Nothing left to pay, so the booking is confirmed without a
+		// payment.
+		if booking.PriceCents == 0 {
+			booking.Status = models.BookingStatusConfirmed
+		}
+
+		if err := s.bookingRepo.CreateTx(ctx, tx, booking); err != nil {
+			return err
+		}
+
+		if paidWithCredit {
+			return s.purchaseRepo.RedeemCreditTx(ctx, tx, creditID, booking.ID)
+		}
+
+		if redemption != nil {
+			return s.couponService.RecordRedemptionTx(ctx, tx, redemption)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Response
+	loc := responseLocation(mentor, req.Timezone)
+
+	resp := &dtos.BookingResponse{
+		ID:        booking.ID,
+		Status:    string(booking.Status),
+		Date:      req.BookingDate,
+		StartTime: start.In(loc).Format("15:04"),
+		EndTime:   end.In(loc).Format("15:04"),
+		StartAt:   start.UTC(),
+		EndAt:     end.UTC(),
+		Timezone:  loc.String(),
+		Price:     booking.PriceCents,
+		Currency:  booking.Currency,
+
+		PaidWithCredit: paidWithCredit,
+	}
+
+	if redemption != nil {
+		resp.ListPrice = redemption.ListPriceCents
+		resp.Discount = redemption.DiscountCents
+	}
+
+	return resp, nil
+}
+
+// resolveSlot parses a requested date and start time, expressed in tz or
+// in the mentor's timezone when tz is empty, and checks that the session
+// fits inside one of the mentor's availability rules. It returns the
+// mentor-local booking date and the absolute start and end.
+func (s *BookingService) resolveSlot(
+	mentor *models.MentorProfile,
+	duration time.Duration,
+	dateStr string,
+	startStr string,
+	tz string,
+) (time.Time, time.Time, time.Time, error) {
+
+	fail := func(msg string) (time.Time, time.Time, time.Time, error) {
+		return time.Time{}, time.Time{}, time.Time{}, errors.New(msg)
+	}
+
+	mentorLoc, err := utils.LoadLocation(mentor.Timezone)
+	if err != nil {
+		return fail("mentor has an invalid timezone")
+	}
+
+	requestLoc := mentorLoc
+	if tz != "" {
+		requestLoc, err = utils.LoadLocation(tz)
+		if err != nil {
+			return fail("invalid timezone")
+		}
+	}
+
+	day, err := utils.ParseLocalDate(dateStr, requestLoc)
+	if err != nil {
+		return fail("invalid date format")
+	}
+
+	clock, err := time.Parse("15:04", startStr)
+	if err != nil {
+		return fail("invalid start time")
+	}
+
+	start := utils.AtWallClock(day, clock, requestLoc)
+
+	// A wall-clock time skipped by a DST jump does not exist
+	if start.In(requestLoc).Hour() != clock.Hour() ||
+		start.In(requestLoc).Minute() != clock.Minute() {
+		return fail("start time does not exist in this timezone on that date")
+	}
+
+	end := start.Add(duration)
+
+	// Rules are evaluated on the mentor's calendar day
+	local := start.In(mentorLoc)
+	bookingDate := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+
+	rules, err := s.availabilityRepo.FindByMentorAndDay(mentor.ID, int(local.Weekday()))
+	if err != nil {
+		return fail("mentor not available on this day")
+	}
+
+	overrides, err := s.overrideRepo.FindByMentorBetween(mentor.ID, bookingDate, bookingDate)
+	if err != nil {
+		return fail("mentor not available on this day")
+	}
+
+	mentorDay := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, mentorLoc)
+
+	windows := dayWindows(rules, overrides, mentorDay, mentorLoc)
+	if len(windows) == 0 {
+		return fail("mentor not available on this day")
+	}
+
+	for _, w := range windows {
+		if !start.Before(w.start) && !end.After(w.end) {
+			return bookingDate, start, end, nil
+		}
+	}
+
+	return fail("selected slot outside availability")
+}
+
+// responseLocation returns the zone a booking response is rendered in.
+func responseLocation(mentor *models.MentorProfile, tz string) *time.Location {
+	for _, name := range []string{tz, mentor.Timezone} {
+		if name == "" {
+			continue
+		}
+		if loc, err := utils.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// CancelBooking cancels a pending or confirmed booking on behalf of its
+// mentee or mentor. The refund owed on a captured payment is decided by
+// the mentor's cancellation policy and stored on the booking.
+func (s *BookingService) CancelBooking(
+	userID uuid.UUID,
+	bookingID uuid.UUID,
+	req *dtos.CancelBookingRequest,
+) (*dtos.CancelBookingResponse, *appErrors.AppError) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var resp *dtos.CancelBookingResponse
+	var refund *models.Refund
+	var appErr *appErrors.AppError
+
+	err := s.bookingRepo.WithTx(ctx, func(tx *sql.Tx) error {
+
+		booking, err := s.bookingRepo.GetByIDForUpdateTx(ctx, tx, bookingID)
+		if err != nil {
+			if errors.Is(err, repositories.ErrBookingNotFound) {
+				appErr = appErrors.BookingNotFound()
+			}
+			return err
+		}
+
+		mentor, err := s.mentorRepo.FindByID(booking.MentorID)
+		if err != nil {
+			return err
+		}
+
+		cancelledByMentor := mentor.UserID == userID
+		if booking.UserID != userID && !cancelledByMentor {
+			appErr = appErrors.BookingForbidden()
+			return errors.New(appErr.Message)
+		}
+
+		if booking.Status != models.BookingStatusPending &&
+			booking.Status != models.BookingStatusConfirmed {
+			appErr = appErrors.BookingNotModifiable()
+			return errors.New(appErr.Message)
+		}
+
+		now := time.Now().UTC()
+		sessionStart := booking.StartTime
+		if !now.Before(sessionStart) {
+			appErr = appErrors.BookingNotModifiable()
+			return errors.New(appErr.Message)
+		}
+
+		refundType, refundAmount := RefundNone, int64(0)
+		returnCredit := false
+
+		if booking.Status == models.BookingStatusConfirmed {
+			redeemed, err := s.purchaseRepo.HasRedeemedCreditTx(ctx, tx, booking.ID)
+			if err != nil {
+				return err
+			}
+
+			if redeemed && creditReturned(mentor, sessionStart, cancelledByMentor, now) {
+				if err := s.purchaseRepo.ReleaseCreditTx(ctx, tx, booking.ID); err != nil {
+					return err
+				}
+				returnCredit = true
+			}
+
+			payment, err := s.paymentRepo.GetPaidByBookingIDTx(ctx, tx, booking.ID)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			if payment != nil {
+				refundType, refundAmount = decideRefund(
+					mentor,
+					sessionStart,
+					payment.Amount,
+					cancelledByMentor,
+					now,
+				)
+			}
+		}
+
+		if err := s.bookingRepo.MarkCancelledTx(
+			ctx,
+			tx,
+			booking.ID,
+			userID,
+			strings.TrimSpace(req.Reason),
+			int(refundAmount),
+		); err != nil {
+			return err
+		}
+
+		// A checkout still open for a pending booking must not confirm it
+		// later; a late capture of it is refunded instead
+		if err := s.paymentRepo.SupersedeOpenTx(ctx, tx, booking.ID); err != nil {
+			return err
+		}
+
+		// The refund is queued with the cancellation, so it is owed even
+		// if submitting it to the gateway fails below
+		if refundAmount > 0 {
+			refund, err = s.paymentService.QueueRefundTx(
+				ctx,
+				tx,
+				booking.ID,
+				refundAmount,
+				strings.TrimSpace(req.Reason),
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		resp = &dtos.CancelBookingResponse{
+			ID:          booking.ID,
+			Status:      string(models.BookingStatusCancelled),
+			RefundType:  string(refundType),
+			RefundCents: int(refundAmount),
+			Currency:    booking.Currency,
 
-		if !start.Before(ruleStart) && !end.After(ruleEnd) {
-			valid = true
-			break
+			CreditReturned: returnCredit,
 		}
+
+		return nil
+	})
+
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if err != nil {
+		return nil, appErrors.InternalServerError()
 	}
 
-	if !valid {
-		return nil, errors.New("selected slot outside availability")
+	if refund != nil {
+		resp.RefundStatus = s.submitRefund(refund)
+	}
+
+	return resp, nil
+}
+
+// submitRefund asks the gateway to issue a cancellation's refund. The
+// cancellation and its refund are already committed, so a gateway
+// failure is logged and left to the refund job, which retries it.
+func (s *BookingService) submitRefund(refund *models.Refund) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	if err := s.paymentService.SubmitRefund(ctx, refund); err != nil {
+		log.Error().
+			Err(err).
+			Str("booking_id", refund.BookingID.String()).
+			Str("refund_id", refund.ID.String()).
+			Msg("refund submission failed")
 	}
 
-	// 7️⃣ TRANSACTION START
+	return refund.Status
+}
+
+// RescheduleBooking moves a booking to a new slot. Mentees may only
+// reschedule outside the mentor's cancellation window; mentors may move
+// a session at any time before it starts.
+func (s *BookingService) RescheduleBooking(
+	userID uuid.UUID,
+	bookingID uuid.UUID,
+	req *dtos.RescheduleBookingRequest,
+) (*dtos.BookingResponse, *appErrors.AppError) {
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	bookingID := uuid.New()
+	existing, err := s.bookingRepo.GetByID(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrBookingNotFound) {
+			return nil, appErrors.BookingNotFound()
+		}
+		return nil, appErrors.InternalServerError()
+	}
+
+	mentor, err := s.mentorRepo.FindByID(existing.MentorID)
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	byMentor := mentor.UserID == userID
+	if existing.UserID != userID && !byMentor {
+		return nil, appErrors.BookingForbidden()
+	}
+
+	// Keep the booked length: the service may have been edited, deactivated
+	// or deleted since, and that must not change an existing booking.
+	bookingDate, start, end, err := s.resolveSlot(
+		mentor,
+		existing.EndTime.Sub(existing.StartTime),
+		req.BookingDate,
+		req.StartTime,
+		req.Timezone,
+	)
+	if err != nil {
+		return nil, appErrors.InvalidSlot(err.Error())
+	}
+
+	now := time.Now().UTC()
+	if err := s.limits.check(start, now); err != nil {
+		return nil, appErrors.InvalidSlot(err.Error())
+	}
+
+	// The daily cap still applies while the service exists; a deleted
+	// service no longer has one.
+	service, err := s.serviceRepo.FindForManagement(ctx, existing.ServiceID)
+	if err != nil && !errors.Is(err, repositories.ErrMentorServiceNotFound) {
+		return nil, appErrors.InternalServerError()
+	}
+
+	var booking *models.Booking
+	var appErr *appErrors.AppError
 
 	err = s.bookingRepo.WithTx(ctx, func(tx *sql.Tx) error {
 
+		booking, err = s.bookingRepo.GetByIDForUpdateTx(ctx, tx, bookingID)
+		if err != nil {
+			return err
+		}
+
+		if booking.Status != models.BookingStatusPending &&
+			booking.Status != models.BookingStatusConfirmed {
+			appErr = appErrors.BookingNotModifiable()
+			return errors.New(appErr.Message)
+		}
+
+		currentStart := booking.StartTime
+		if !now.Before(currentStart) {
+			appErr = appErrors.BookingNotModifiable()
+			return errors.New(appErr.Message)
+		}
+
+		if !byMentor && insideCancellationWindow(mentor, currentStart, now) {
+			appErr = appErrors.RescheduleWindowClosed()
+			return errors.New(appErr.Message)
+		}
+
+		// The buffers and gap recorded at booking time move with the session
+		blockedFrom := start.Add(-booking.StartTime.Sub(booking.BlockedFrom))
+		blockedUntil := end.Add(booking.BlockedUntil.Sub(booking.EndTime))
+
 		conflict, err := s.bookingRepo.HasConflictTx(
 			ctx,
 			tx,
 			mentor.ID,
-			bookingDate,
-			start,
-			end,
+			blockedFrom,
+			blockedUntil,
+			booking.MinGapMinutes,
+			booking.ID,
 		)
 		if err != nil {
 			return err
 		}
 
 		if conflict {
-			return errors.New("slot already booked")
+			appErr = appErrors.SlotAlreadyBooked()
+			return errors.New(appErr.Message)
 		}
 
-		booking := &models.Booking{
-			ID:          bookingID,
-			MentorID:    mentor.ID,
-			UserID:      userID,
-			ServiceID:   service.ID,
-			BookingDate: bookingDate,
-			StartTime:   start,
-			EndTime:     end,
-			Status:      models.BookingStatusPending,
-			PriceCents:  service.PriceCents,
-			Currency:    service.Currency,
+		if service != nil && service.MaxSessionsPerDay > 0 {
+			n, err := s.bookingRepo.CountForServiceDayTx(ctx, tx, service.ID, bookingDate, booking.ID)
+			if err != nil {
+				return err
+			}
+			if n >= service.MaxSessionsPerDay {
+				appErr = appErrors.InvalidSlot("no more sessions of this service are available on this day")
+				return errors.New(appErr.Message)
+			}
 		}
 
-		return s.bookingRepo.CreateTx(ctx, tx, booking)
+		return s.bookingRepo.RescheduleTx(
+			ctx,
+			tx,
+			booking.ID,
+			bookingDate,
+			start,
+			end,
+			blockedFrom,
+			blockedUntil,
+		)
 	})
 
+	if appErr != nil {
+		return nil, appErr
+	}
+
 	if err != nil {
-		return nil, err
+		return nil, appErrors.InternalServerError()
 	}
 
-	// Response
+	// The price captured at booking time is kept as-is
+	loc := responseLocation(mentor, req.Timezone)
+
 	return &dtos.BookingResponse{
-		ID:        bookingID,
-		Status:    string(models.BookingStatusPending),
+		ID:        booking.ID,
+		Status:    string(booking.Status),
 		Date:      req.BookingDate,
-		StartTime: start.Format("15:04"),
-		EndTime:   end.Format("15:04"),
-		Price:     service.PriceCents,
-		Currency:  service.Currency,
+		StartTime: start.In(loc).Format("15:04"),
+		EndTime:   end.In(loc).Format("15:04"),
+		StartAt:   start.UTC(),
+		EndAt:     end.UTC(),
+		Timezone:  loc.String(),
+		Price:     booking.PriceCents,
+		Currency:  booking.Currency,
 	}, nil
 }
 
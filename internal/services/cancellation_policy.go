@@ -0,0 +1,71 @@
+package services
+
+import (
+	"time"
+
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+)
+
+type RefundType string
+
+const (
+	RefundFull    RefundType = "full"
+	RefundPartial RefundType = "partial"
+	RefundNone    RefundType = "none"
+)
+
+// decideRefund applies the mentor's cancellation policy to a paid amount.
+//
+//   - the mentor cancelling always refunds the mentee in full
+//   - a mentee cancelling before the window refunds in full
+//   - a mentee cancelling inside the window gets the late refund percent
+func decideRefund(
+	mentor *models.MentorProfile,
+	sessionStart time.Time,
+	paidAmount int64,
+	cancelledByMentor bool,
+	now time.Time,
+) (RefundType, int64) {
+
+	if paidAmount <= 0 {
+		return RefundNone, 0
+	}
+
+	if cancelledByMentor || !insideCancellationWindow(mentor, sessionStart, now) {
+		return RefundFull, paidAmount
+	}
+
+	amount := paidAmount * int64(mentor.LateCancellationRefundPercent) / 100
+	switch {
+	case amount <= 0:
+		return RefundNone, 0
+	case amount >= paidAmount:
+		return RefundFull, paidAmount
+	default:
+		return RefundPartial, amount
+	}
+}
+
+// insideCancellationWindow reports whether now is closer to the session
+// start than the mentor's cancellation window allows.
+func insideCancellationWindow(
+	mentor *models.MentorProfile,
+	sessionStart time.Time,
+	now time.Time,
+) bool {
+	window := time.Duration(mentor.CancellationWindowHours) * time.Hour
+	return sessionStart.Sub(now) < window
+}
+
+// creditReturned applies the policy to a booking paid with a session
+// credit. A credit cannot be split, so it is only given back when the
+// policy would refund the booking in full.
+func creditReturned(
+	mentor *models.MentorProfile,
+	sessionStart time.Time,
+	cancelledByMentor bool,
+	now time.Time,
+) bool {
+	refundType, _ := decideRefund(mentor, sessionStart, 1, cancelledByMentor, now)
+	return refundType == RefundFull
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
+	appErrors "github.com/preetsinghmakkar/OpenCall/internal/errors"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+	"github.com/preetsinghmakkar/OpenCall/internal/repositories"
+)
+
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,32}$`)
+
+// CouponService manages discount codes issued by mentors for their own
+// services and by the platform for any service, and applies them to
+// bookings.
+type CouponService struct {
+	couponRepo  *repositories.CouponRepository
+	serviceRepo *repositories.MentorServiceRepository
+	mentorRepo  *repositories.MentorRepository
+}
+
+func NewCouponService(
+	couponRepo *repositories.CouponRepository,
+	serviceRepo *repositories.MentorServiceRepository,
+	mentorRepo *repositories.MentorRepository,
+) *CouponService {
+	return &CouponService{
+		couponRepo:  couponRepo,
+		serviceRepo: serviceRepo,
+		mentorRepo:  mentorRepo,
+	}
+}
+
+// CreateMentorCoupon issues a coupon for the caller's services, or for
+// one of them when ServiceID is set.
+func (s *CouponService) CreateMentorCoupon(
+	userID uuid.UUID,
+	req *dtos.CreateCouponRequest,
+) (*dtos.CouponResponse, *appErrors.AppError) {
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, appErrors.MentorProfileRequired()
+	}
+
+	return s.createCoupon(userID, &mentor.ID, req)
+}
+
+// CreatePlatformCoupon issues a coupon valid on any mentor's services.
+// The platform funds its discounts.
+func (s *CouponService) CreatePlatformCoupon(
+	userID uuid.UUID,
+	req *dtos.CreateCouponRequest,
+) (*dtos.CouponResponse, *appErrors.AppError) {
+	return s.createCoupon(userID, nil, req)
+}
+
+func (s *CouponService) createCoupon(
+	userID uuid.UUID,
+	mentorID *uuid.UUID,
+	req *dtos.CreateCouponRequest,
+) (*dtos.CouponResponse, *appErrors.AppError) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coupon := &models.Coupon{
+		ID:                    uuid.New(),
+		Code:                  strings.ToUpper(strings.TrimSpace(req.Code)),
+		MentorID:              mentorID,
+		ServiceID:             req.ServiceID,
+		DiscountType:          req.DiscountType,
+		MaxRedemptions:        req.MaxRedemptions,
+		MaxRedemptionsPerUser: req.MaxRedemptionsPerUser,
+		StartsAt:              req.StartsAt,
+		ExpiresAt:             req.ExpiresAt,
+		CreatedBy:             userID,
+	}
+
+	if !couponCodePattern.MatchString(coupon.Code) {
+		return nil, appErrors.InvalidCoupon("code must be 3 to 32 letters, digits, - or _")
+	}
+
+	if req.StartsAt != nil && req.ExpiresAt != nil && !req.ExpiresAt.After(*req.StartsAt) {
+		return nil, appErrors.InvalidCoupon("expires_at must be after starts_at")
+	}
+
+	currency := strings.ToUpper(req.Currency)
+
+	if req.ServiceID != nil {
+		service, err := s.serviceRepo.FindForManagement(ctx, *req.ServiceID)
+		if err != nil {
+			return nil, serviceRepoError(err)
+		}
+		if mentorID != nil && service.MentorID != *mentorID {
+			return nil, appErrors.MentorServiceForbidden()
+		}
+		if currency == "" {
+			currency = service.Currency
+		}
+	}
+
+	switch req.DiscountType {
+	case models.CouponDiscountPercent:
+		if req.PercentOff < 1 {
+			return nil, appErrors.InvalidCoupon("percent_off must be between 1 and 100")
+		}
+		coupon.PercentOff = req.PercentOff
+
+	case models.CouponDiscountFixed:
+		if req.AmountOffCents < 1 {
+			return nil, appErrors.InvalidCoupon("amount_off_cents must be positive")
+		}
+		if currency == "" {
+			return nil, appErrors.InvalidCoupon("currency is required for fixed discounts")
+		}
+		coupon.AmountOffCents = req.AmountOffCents
+		coupon.Currency = &currency
+	}
+
+	if err := s.couponRepo.Create(ctx, coupon); err != nil {
+		if errors.Is(err, repositories.ErrCouponCodeTaken) {
+			return nil, appErrors.CouponCodeTaken()
+		}
+		return nil, appErrors.InternalServerError()
+	}
+
+	return couponResponse(coupon), nil
+}
+
+// ListMentorCoupons lists the caller's coupons, newest first.
+func (s *CouponService) ListMentorCoupons(
+	userID uuid.UUID,
+) ([]*dtos.CouponResponse, *appErrors.AppError) {
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, appErrors.MentorProfileRequired()
+	}
+
+	return s.listCoupons(&mentor.ID)
+}
+
+// ListPlatformCoupons lists the platform's coupons, newest first.
+func (s *CouponService) ListPlatformCoupons() ([]*dtos.CouponResponse, *appErrors.AppError) {
+	return s.listCoupons(nil)
+}
+
+func (s *CouponService) listCoupons(
+	mentorID *uuid.UUID,
+) ([]*dtos.CouponResponse, *appErrors.AppError) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coupons, err := s.couponRepo.FindByMentorID(ctx, mentorID)
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	resp := make([]*dtos.CouponResponse, 0, len(coupons))
+	for _, c := range coupons {
+		resp = append(resp, couponResponse(c))
+	}
+
+	return resp, nil
+}
+
+// SetMentorCouponActive turns one of the caller's coupons on or off.
+// Bookings already made with it keep their discount.
+func (s *CouponService) SetMentorCouponActive(
+	userID uuid.UUID,
+	couponID uuid.UUID,
+	active bool,
+) (*dtos.CouponResponse, *appErrors.AppError) {
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, appErrors.MentorProfileRequired()
+	}
+
+	return s.setActive(&mentor.ID, couponID, active)
+}
+
+// SetPlatformCouponActive turns a platform coupon on or off.
+func (s *CouponService) SetPlatformCouponActive(
+	couponID uuid.UUID,
+	active bool,
+) (*dtos.CouponResponse, *appErrors.AppError) {
+	return s.setActive(nil, couponID, active)
+}
+
+func (s *CouponService) setActive(
+	mentorID *uuid.UUID,
+	couponID uuid.UUID,
+	active bool,
+) (*dtos.CouponResponse, *appErrors.AppError) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coupon, err := s.couponRepo.GetByID(ctx, couponID)
+	if errors.Is(err, repositories.ErrCouponNotFound) {
+		return nil, appErrors.CouponNotFound()
+	}
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	if mentorID == nil && coupon.MentorID != nil {
+		return nil, appErrors.CouponNotFound()
+	}
+	if mentorID != nil && (coupon.MentorID == nil || *coupon.MentorID != *mentorID) {
+		return nil, appErrors.CouponForbidden()
+	}
+
+	if err := s.couponRepo.SetActive(ctx, couponID, active); err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	coupon.IsActive = active
+	return couponResponse(coupon), nil
+}
+
+// ApplyTx checks that code can be used by userID to book service now and
+// returns the redemption to record once the booking exists. The coupon
+// stays locked until tx ends, so concurrent bookings cannot exceed its
+// limits.
+func (s *CouponService) ApplyTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	code string,
+	userID uuid.UUID,
+	service *models.MentorService,
+	now time.Time,
+) (*models.CouponRedemption, error) {
+
+	coupon, err := s.couponRepo.GetByCodeForUpdateTx(
+		ctx,
+		tx,
+		strings.ToUpper(strings.TrimSpace(code)),
+	)
+	if errors.Is(err, repositories.ErrCouponNotFound) {
+		return nil, errors.New("coupon code is not valid")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !coupon.IsActive {
+		return nil, errors.New("coupon code is not valid")
+	}
+
+	if coupon.StartsAt != nil && now.Before(*coupon.StartsAt) {
+		return nil, errors.New("coupon is not valid yet")
+	}
+
+	if coupon.ExpiresAt != nil && !now.Before(*coupon.ExpiresAt) {
+		return nil, errors.New("coupon has expired")
+	}
+
+	if (coupon.MentorID != nil && *coupon.MentorID != service.MentorID) ||
+		(coupon.ServiceID != nil && *coupon.ServiceID != service.ID) {
+		return nil, errors.New("coupon is not valid for this service")
+	}
+
+	discount := coupon.Discount(service.PriceCents, service.Currency)
+	if discount == 0 {
+		return nil, errors.New("coupon is not valid for this service")
+	}
+
+	total, byUser, err := s.couponRepo.CountRedemptionsTx(ctx, tx, coupon.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if coupon.MaxRedemptions > 0 && total >= coupon.MaxRedemptions {
+		return nil, errors.New("coupon has reached its usage limit")
+	}
+
+	if coupon.MaxRedemptionsPerUser > 0 && byUser >= coupon.MaxRedemptionsPerUser {
+		return nil, errors.New("you have already used this coupon")
+	}
+
+	return &models.CouponRedemption{
+		ID:             uuid.New(),
+		CouponID:       coupon.ID,
+		UserID:         userID,
+		ListPriceCents: service.PriceCents,
+		DiscountCents:  discount,
+		Currency:       service.Currency,
+	}, nil
+}
+
+// RecordRedemptionTx stores a redemption returned by ApplyTx once its
+// booking has been created in the same transaction.
+func (s *CouponService) RecordRedemptionTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	redemption *models.CouponRedemption,
+) error {
+	return s.couponRepo.CreateRedemptionTx(ctx, tx, redemption)
+}
+
+func couponResponse(c *models.Coupon) *dtos.CouponResponse {
+	return &dtos.CouponResponse{
+		ID:           c.ID,
+		Code:         c.Code,
+		Platform:     c.MentorID == nil,
+		ServiceID:    c.ServiceID,
+		DiscountType: c.DiscountType,
+
+		PercentOff:     c.PercentOff,
+		AmountOffCents: c.AmountOffCents,
+		Currency:       c.Currency,
+
+		MaxRedemptions:        c.MaxRedemptions,
+		MaxRedemptionsPerUser: c.MaxRedemptionsPerUser,
+		Redemptions:           c.Redemptions,
+
+		StartsAt:  c.StartsAt,
+		ExpiresAt: c.ExpiresAt,
+		IsActive:  c.IsActive,
+		CreatedAt: c.CreatedAt,
+	}
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+)
+
+// A4 in points, with the margins invoices are laid out in
+const (
+	pdfPageWidth  = 595
+	pdfPageHeight = 842
+	pdfLeft       = 50
+	pdfRight      = 545
+)
+
+// Fonts of the page: the built-in Helvetica faces, so nothing has to be
+// embedded.
+const (
+	pdfRegular = "F1"
+	pdfBold    = "F2"
+)
+
+// helveticaWidths are the glyph widths of Helvetica for ' ' to '~', in
+// thousandths of the font size. Helvetica-Bold is close enough for the
+// digits and upper-case codes that are right-aligned.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// pdfPage builds the content stream of a one-page PDF. Text is encoded
+// as WinAnsi; characters outside Latin-1 are printed as '?'.
+type pdfPage struct {
+	content bytes.Buffer
+}
+
+func (p *pdfPage) text(x, y float64, font string, size float64, s string) {
+	fmt.Fprintf(&p.content, "BT /%s %g Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfEscape(s))
+}
+
+// textRight prints s so that it ends at x.
+func (p *pdfPage) textRight(x, y float64, font string, size float64, s string) {
+	p.text(x-pdfTextWidth(s, size), y, font, size, s)
+}
+
+// paragraph prints s wrapped to width and returns the y below it.
+func (p *pdfPage) paragraph(x, y float64, font string, size float64, width float64, s string) float64 {
+	for _, line := range pdfWrap(s, size, width) {
+		p.text(x, y, font, size, line)
+		y -= size * 1.35
+	}
+	return y
+}
+
+func (p *pdfPage) line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+// bytes assembles the document around the page's content.
+func (p *pdfPage) bytes(title string) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+				"/Resources << /Font << /%s 4 0 R /%s 5 0 R >> >> /Contents 6 0 R >>",
+			pdfPageWidth, pdfPageHeight, pdfRegular, pdfBold,
+		),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.content.Len(), p.content.String()),
+		fmt.Sprintf("<< /Title (%s) /Producer (OpenCall) >>", pdfEscape(title)),
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(
+		&out,
+		"trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(objects)+1, len(objects), xref,
+	)
+
+	return out.Bytes()
+}
+
+// pdfEscape encodes s as the body of a PDF literal string.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= ' ' && r <= '~':
+			b.WriteRune(r)
+		case r >= 0xA0 && r <= 0xFF:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+func pdfTextWidth(s string, size float64) float64 {
+	width := 0
+	for _, r := range s {
+		if r >= ' ' && r <= '~' {
+			width += helveticaWidths[r-' ']
+		} else {
+			width += 556
+		}
+	}
+	return float64(width) * size / 1000
+}
+
+// pdfWrap breaks s into lines no wider than width, at spaces.
+func pdfWrap(s string, size float64, width float64) []string {
+	var lines []string
+	line := ""
+
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && pdfTextWidth(candidate, size) > width {
+			lines = append(lines, line)
+			candidate = word
+		}
+		line = candidate
+	}
+
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// formatInvoiceAmount prints cents with thousands separators, e.g.
+// 123456 as "1,234.56".
+func formatInvoiceAmount(cents int64) string {
+	units := strconv.FormatInt(cents/100, 10)
+	for i := len(units) - 3; i > 0; i -= 3 {
+		units = units[:i] + "," + units[i:]
+	}
+	return fmt.Sprintf("%s.%02d", units, cents%100)
+}
+
+// formatHalfRate prints half of a GST rate, e.g. 9% of 18% or 2.5% of 5%.
+func formatHalfRate(rate int) string {
+	if rate%2 == 0 {
+		return fmt.Sprintf("%d%%", rate/2)
+	}
+	return fmt.Sprintf("%d.5%%", rate/2)
+}
+
+// renderInvoicePDF lays out an invoice or receipt on one A4 page.
+func renderInvoicePDF(inv *models.Invoice) []byte {
+	var p pdfPage
+	tax := inv.Kind == models.InvoiceKindTax
+
+	title, numberLabel := "RECEIPT", "Receipt No. "
+	if tax {
+		title, numberLabel = "TAX INVOICE", "Invoice No. "
+	}
+
+	p.text(pdfLeft, 780, pdfBold, 20, title)
+	p.textRight(pdfRight, 785, pdfBold, 10, numberLabel+inv.Number)
+	p.textRight(pdfRight, 770, pdfRegular, 10, "Date: "+inv.IssuedAt.In(istZone).Format("02 Jan 2006"))
+
+	// Supplier on the left, buyer on the right
+	y := 735.0
+	p.text(pdfLeft, y, pdfBold, 12, inv.SupplierName)
+	supplierY := p.paragraph(pdfLeft, y-16, pdfRegular, 10, 240, inv.SupplierAddress)
+	if inv.SupplierGSTIN != nil {
+		p.text(pdfLeft, supplierY, pdfRegular, 10, "GSTIN: "+*inv.SupplierGSTIN)
+		supplierY -= 13.5
+	}
+	if inv.SupplierState != nil {
+		p.text(pdfLeft, supplierY, pdfRegular, 10, "State: "+gstStateLabel(*inv.SupplierState))
+		supplierY -= 13.5
+	}
+
+	const buyerX = 330
+	p.text(buyerX, y, pdfBold, 10, "Bill to")
+	p.text(buyerX, y-16, pdfRegular, 10, inv.BuyerName)
+	p.text(buyerX, y-29.5, pdfRegular, 10, inv.BuyerEmail)
+	buyerY := y - 43
+	if inv.BillingState != nil {
+		p.text(buyerX, buyerY, pdfRegular, 10, "State: "+gstStateLabel(*inv.BillingState))
+		buyerY -= 13.5
+	}
+	if inv.PlaceOfSupply != nil {
+		p.text(buyerX, buyerY, pdfRegular, 10, "Place of supply: "+gstStateLabel(*inv.PlaceOfSupply))
+		buyerY -= 13.5
+	}
+
+	// Line item
+	y = min(supplierY, buyerY) - 20
+	p.line(pdfLeft, y+14, pdfRight, y+14)
+	p.text(pdfLeft, y, pdfBold, 10, "Description")
+	p.text(380, y, pdfBold, 10, "SAC")
+	p.textRight(pdfRight, y, pdfBold, 10, "Amount ("+inv.Currency+")")
+	p.line(pdfLeft, y-6, pdfRight, y-6)
+
+	y -= 22
+	p.text(380, y, pdfRegular, 10, inv.SAC)
+	p.textRight(pdfRight, y, pdfRegular, 10, formatInvoiceAmount(inv.TaxableCents))
+	y = p.paragraph(pdfLeft, y, pdfRegular, 10, 310, inv.Description)
+	p.line(pdfLeft, y+4, pdfRight, y+4)
+
+	// Totals
+	const labelX = 330
+	y -= 14
+	row := func(label, amount string, font string) {
+		p.text(labelX, y, font, 10, label)
+		p.textRight(pdfRight, y, font, 10, amount)
+		y -= 16
+	}
+
+	if tax {
+		row("Taxable value", formatInvoiceAmount(inv.TaxableCents), pdfRegular)
+		if inv.IGSTCents > 0 {
+			row(fmt.Sprintf("IGST @ %d%%", inv.GSTRatePercent), formatInvoiceAmount(inv.IGSTCents), pdfRegular)
+		} else {
+			half := formatHalfRate(inv.GSTRatePercent)
+			row("CGST @ "+half, formatInvoiceAmount(inv.CGSTCents), pdfRegular)
+			row("SGST @ "+half, formatInvoiceAmount(inv.SGSTCents), pdfRegular)
+		}
+		p.line(labelX, y+12, pdfRight, y+12)
+		row("Total ("+inv.Currency+")", formatInvoiceAmount(inv.TotalCents), pdfBold)
+	} else {
+		row("Amount paid ("+inv.Currency+")", formatInvoiceAmount(inv.TotalCents), pdfBold)
+	}
+
+	// Notes
+	y -= 20
+	p.text(pdfLeft, y, pdfRegular, 9, "Paid online, payment reference "+inv.PaymentRef+".")
+	if tax {
+		y -= 13
+		p.text(pdfLeft, y, pdfRegular, 9, "Tax payable on reverse charge: No.")
+	}
+	y -= 13
+	p.text(pdfLeft, y, pdfRegular, 9, "This is a computer-generated document and needs no signature.")
+
+	return p.bytes(title + " " + inv.Number)
+}
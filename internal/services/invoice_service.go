@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	appErrors "github.com/preetsinghmakkar/OpenCall/internal/errors"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+	"github.com/preetsinghmakkar/OpenCall/internal/repositories"
+)
+
+// ErrInvalidBillingState is returned for a billing state that is not a
+// GST state code.
+var ErrInvalidBillingState = errors.New("billing_state must be a GST state code")
+
+// Invoice number prefixes. Numbers look like INV/26-27/000042, within
+// the 16 characters GST allows.
+const (
+	invoiceSeriesTax     = "INV"
+	invoiceSeriesReceipt = "RCT"
+)
+
+// istZone is India Standard Time, which has no daylight saving. Invoice
+// dates and financial years (April to March) follow it.
+var istZone = time.FixedZone("IST", 5*60*60+30*60)
+
+// gstStates maps GST state codes to the state or union territory names
+// printed on invoices.
+var gstStates = map[string]string{
+	"01": "Jammu and Kashmir",
+	"02": "Himachal Pradesh",
+	"03": "Punjab",
+	"04": "Chandigarh",
+	"05": "Uttarakhand",
+	"06": "Haryana",
+	"07": "Delhi",
+	"08": "Rajasthan",
+	"09": "Uttar Pradesh",
+	"10": "Bihar",
+	"11": "Sikkim",
+	"12": "Arunachal Pradesh",
+	"13": "Nagaland",
+	"14": "Manipur",
+	"15": "Mizoram",
+	"16": "Tripura",
+	"17": "Meghalaya",
+	"18": "Assam",
+	"19": "West Bengal",
+	"20": "Jharkhand",
+	"21": "Odisha",
+	"22": "Chhattisgarh",
+	"23": "Madhya Pradesh",
+	"24": "Gujarat",
+	"26": "Dadra and Nagar Haveli and Daman and Diu",
+	"27": "Maharashtra",
+	"29": "Karnataka",
+	"30": "Goa",
+	"31": "Lakshadweep",
+	"32": "Kerala",
+	"33": "Tamil Nadu",
+	"34": "Puducherry",
+	"35": "Andaman and Nicobar Islands",
+	"36": "Telangana",
+	"37": "Andhra Pradesh",
+	"38": "Ladakh",
+	"97": "Other Territory",
+}
+
+// InvoiceSupplier is the business that issues invoices. Without a GSTIN
+// it is not registered for GST and issues receipts only.
+type InvoiceSupplier struct {
+	Name    string
+	Address string
+	GSTIN   string
+	// SAC is the service accounting code of mentoring sessions
+	SAC string
+	// GSTRatePercent is the GST included in INR prices
+	GSTRatePercent int
+}
+
+// state is the supplier's GST state code, the first two digits of its
+// GSTIN.
+func (s InvoiceSupplier) state() string {
+	if len(s.GSTIN) < 2 {
+		return ""
+	}
+	return s.GSTIN[:2]
+}
+
+// InvoiceService issues an invoice for every paid booking and renders it
+// as PDF for the mentee and the mentor.
+type InvoiceService struct {
+	invoiceRepo *repositories.InvoiceRepository
+	mentorRepo  *repositories.MentorRepository
+	supplier    InvoiceSupplier
+}
+
+func NewInvoiceService(
+	invoiceRepo *repositories.InvoiceRepository,
+	mentorRepo *repositories.MentorRepository,
+	supplier InvoiceSupplier,
+) *InvoiceService {
+	return &InvoiceService{
+		invoiceRepo: invoiceRepo,
+		mentorRepo:  mentorRepo,
+		supplier:    supplier,
+	}
+}
+
+// validBillingState reports whether code is empty or a GST state code.
+func validBillingState(code string) bool {
+	if code == "" {
+		return true
+	}
+	_, ok := gstStates[code]
+	return ok
+}
+
+// IssueTx issues the invoice for a captured booking payment. It runs in
+// the capture's transaction, so the invoice number is only used if the
+// payment is recorded as paid. Bookings that already have an invoice
+// are skipped.
+func (s *InvoiceService) IssueTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	payment *models.Payment,
+	paymentRef string,
+) error {
+
+	exists, err := s.invoiceRepo.ExistsForBookingTx(ctx, tx, *payment.BookingID)
+	if err != nil || exists {
+		return err
+	}
+
+	party, err := s.invoiceRepo.GetPartyTx(ctx, tx, *payment.BookingID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	inv := &models.Invoice{
+		ID:              uuid.New(),
+		Kind:            models.InvoiceKindReceipt,
+		FinancialYear:   financialYear(now),
+		BookingID:       party.BookingID,
+		PaymentID:       payment.ID,
+		UserID:          party.UserID,
+		MentorID:        party.MentorID,
+		SupplierName:    s.supplier.Name,
+		SupplierAddress: s.supplier.Address,
+		BuyerName:       party.BuyerName,
+		BuyerEmail:      party.BuyerEmail,
+		BillingState:    payment.BillingState,
+		Description: fmt.Sprintf(
+			"%s with %s on %s",
+			party.ServiceTitle,
+			party.MentorName,
+			party.BookingDate.Format("02 Jan 2006"),
+		),
+		SAC:          s.supplier.SAC,
+		PaymentRef:   paymentRef,
+		Currency:     strings.ToUpper(payment.Currency),
+		TaxableCents: payment.Amount,
+		TotalCents:   payment.Amount,
+		IssuedAt:     now,
+	}
+
+	series := invoiceSeriesReceipt
+	if s.supplier.GSTIN != "" && inv.Currency == "INR" {
+		series = invoiceSeriesTax
+		s.applyGST(inv)
+	}
+
+	inv.Sequence, err = s.invoiceRepo.NextNumberTx(ctx, tx, series, inv.FinancialYear)
+	if err != nil {
+		return err
+	}
+	inv.Number = fmt.Sprintf("%s/%s/%06d", series, inv.FinancialYear, inv.Sequence)
+
+	return s.invoiceRepo.CreateTx(ctx, tx, inv)
+}
+
+// applyGST turns inv into a tax invoice, splitting the GST out of its
+// total. Supplies within the supplier's state are charged CGST and SGST,
+// supplies to other states IGST. Without a billing state the place of
+// supply is the supplier's state.
+func (s *InvoiceService) applyGST(inv *models.Invoice) {
+	supplierState := s.supplier.state()
+	gstin := s.supplier.GSTIN
+	place := supplierState
+	if inv.BillingState != nil {
+		place = *inv.BillingState
+	}
+
+	inv.Kind = models.InvoiceKindTax
+	inv.SupplierGSTIN = &gstin
+	inv.SupplierState = &supplierState
+	inv.PlaceOfSupply = &place
+	inv.GSTRatePercent = s.supplier.GSTRatePercent
+
+	rate := int64(s.supplier.GSTRatePercent)
+	inv.TaxableCents = (inv.TotalCents*100 + (100+rate)/2) / (100 + rate)
+	tax := inv.TotalCents - inv.TaxableCents
+
+	if place == supplierState {
+		inv.CGSTCents = tax / 2
+		inv.SGSTCents = tax - inv.CGSTCents
+	} else {
+		inv.IGSTCents = tax
+	}
+}
+
+// GetBookingInvoice returns a booking's invoice and its PDF to the
+// mentee who paid for it or the mentor who hosts it.
+func (s *InvoiceService) GetBookingInvoice(
+	userID uuid.UUID,
+	bookingID uuid.UUID,
+) (*models.Invoice, []byte, *appErrors.AppError) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	inv, err := s.invoiceRepo.GetByBookingID(ctx, bookingID)
+	if errors.Is(err, repositories.ErrInvoiceNotFound) {
+		return nil, nil, appErrors.InvoiceNotFound()
+	}
+	if err != nil {
+		return nil, nil, appErrors.InternalServerError()
+	}
+
+	if inv.UserID != userID {
+		mentor, err := s.mentorRepo.FindByUserID(userID)
+		if err != nil || mentor.ID != inv.MentorID {
+			return nil, nil, appErrors.InvoiceForbidden()
+		}
+	}
+
+	return inv, renderInvoicePDF(inv), nil
+}
+
+// financialYear returns the Indian financial year t falls in, e.g.
+// "26-27" from April 2026 to March 2027.
+func financialYear(t time.Time) string {
+	t = t.In(istZone)
+
+	year := t.Year()
+	if t.Month() < time.April {
+		year--
+	}
+
+	return fmt.Sprintf("%02d-%02d", year%100, (year+1)%100)
+}
+
+// gstStateLabel prints a state code with its name, e.g. "29 - Karnataka".
+func gstStateLabel(code string) string {
+	if name, ok := gstStates[code]; ok {
+		return code + " - " + name
+	}
+	return code
+}
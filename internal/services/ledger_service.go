@@ -0,0 +1,381 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
+	appErrors "github.com/preetsinghmakkar/OpenCall/internal/errors"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+	"github.com/preetsinghmakkar/OpenCall/internal/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+// earningsBatchSize bounds how many bookings one PostSessionEarnings run
+// handles, so a backlog is worked off over several runs.
+const earningsBatchSize = 100
+
+// LedgerService keeps the double-entry ledger of what mentees paid, what
+// the platform owes mentors and the commission it keeps.
+type LedgerService struct {
+	ledgerRepo *repositories.LedgerRepository
+	mentorRepo *repositories.MentorRepository
+
+	// commissionPercent of every session is kept by the platform
+	commissionPercent int
+	// holdPeriod after a session ends before its earnings can be paid out
+	holdPeriod time.Duration
+}
+
+func NewLedgerService(
+	ledgerRepo *repositories.LedgerRepository,
+	mentorRepo *repositories.MentorRepository,
+	commissionPercent int,
+	holdPeriod time.Duration,
+) *LedgerService {
+	return &LedgerService{
+		ledgerRepo:        ledgerRepo,
+		mentorRepo:        mentorRepo,
+		commissionPercent: commissionPercent,
+		holdPeriod:        holdPeriod,
+	}
+}
+
+// PostSessionEarnings credits mentors for completed sessions that are not
+// in the ledger yet. The mentor's share is held until the dispute window
+// after the session's end has passed.
+func (s *LedgerService) PostSessionEarnings(ctx context.Context) error {
+	pending, err := s.ledgerRepo.FindUnpostedEarnings(ctx, earningsBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range pending {
+		// Mentors earn on the list price of sessions booked with a
+		// platform coupon; the platform covers the discount.
+		earned := e.GrossCents + e.PromotionCents
+		commission := earned * int64(s.commissionPercent) / 100
+		mentorID := e.MentorID
+		bookingID := e.BookingID
+		now := time.Now()
+
+		t := &models.LedgerTransaction{
+			ID:             uuid.New(),
+			Kind:           models.LedgerKindSessionEarning,
+			IdempotencyKey: "session_earning:" + bookingID.String(),
+			MentorID:       &mentorID,
+			BookingID:      &bookingID,
+			Memo:           "session completed",
+		}
+
+		entries := []*models.LedgerEntry{
+			ledgerEntry(models.LedgerAccountGatewayClearing, &mentorID, models.LedgerDebit, e.GrossCents, e.Currency, now),
+			ledgerEntry(models.LedgerAccountPlatformPromotions, &mentorID, models.LedgerDebit, e.PromotionCents, e.Currency, now),
+			ledgerEntry(models.LedgerAccountMentorPayable, &mentorID, models.LedgerCredit, earned-commission, e.Currency, e.EndTime.Add(s.holdPeriod)),
+			ledgerEntry(models.LedgerAccountPlatformRevenue, &mentorID, models.LedgerCredit, commission, e.Currency, now),
+		}
+
+		err := s.ledgerRepo.WithTx(ctx, func(tx *sql.Tx) error {
+			_, err := s.post(ctx, tx, t, entries)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		log.Info().Str("booking_id", bookingID.String()).Int64("gross_cents", e.GrossCents).Msg("posted session earnings")
+	}
+
+	return nil
+}
+
+// PostRefundTx reverses the part of a booking's posted earnings that a
+// processed refund gave back, split between the mentor and the platform
+// in the same ratio as the earnings. Refunds of sessions whose earnings
+// were never posted, and refunds that are not for a booking, need no
+// entries.
+func (s *LedgerService) PostRefundTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	refund *models.Refund,
+) error {
+
+	if refund.BookingID == nil {
+		return nil
+	}
+
+	earning, err := s.ledgerRepo.GetEarningEntriesTx(ctx, tx, *refund.BookingID)
+	if err != nil {
+		return err
+	}
+
+	var gross, promotion, mentorShare int64
+	var mentorID *uuid.UUID
+
+	for _, e := range earning {
+		switch e.Account {
+		case models.LedgerAccountGatewayClearing:
+			gross += e.AmountCents
+		case models.LedgerAccountPlatformPromotions:
+			promotion += e.AmountCents
+		case models.LedgerAccountMentorPayable:
+			mentorShare += e.AmountCents
+			mentorID = e.MentorID
+		}
+	}
+
+	if gross == 0 {
+		return nil
+	}
+
+	amount := refund.Amount
+	if amount > gross {
+		amount = gross
+	}
+
+	fromMentor := amount * mentorShare / (gross + promotion)
+	now := time.Now()
+	refundID := refund.ID
+
+	t := &models.LedgerTransaction{
+		ID:             uuid.New(),
+		Kind:           models.LedgerKindRefund,
+		IdempotencyKey: "refund:" + refund.ID.String(),
+		MentorID:       mentorID,
+		BookingID:      refund.BookingID,
+		RefundID:       &refundID,
+		Memo:           refund.Reason,
+	}
+
+	entries := []*models.LedgerEntry{
+		ledgerEntry(models.LedgerAccountGatewayClearing, mentorID, models.LedgerCredit, amount, refund.Currency, now),
+		ledgerEntry(models.LedgerAccountMentorPayable, mentorID, models.LedgerDebit, fromMentor, refund.Currency, now),
+		ledgerEntry(models.LedgerAccountPlatformRevenue, mentorID, models.LedgerDebit, amount-fromMentor, refund.Currency, now),
+	}
+
+	_, err = s.post(ctx, tx, t, entries)
+	return err
+}
+
+// post drops zero-amount lines, checks that debits equal credits and
+// stores the transaction.
+func (s *LedgerService) post(
+	ctx context.Context,
+	tx *sql.Tx,
+	t *models.LedgerTransaction,
+	entries []*models.LedgerEntry,
+) (bool, error) {
+
+	var balance int64
+	lines := make([]*models.LedgerEntry, 0, len(entries))
+
+	for _, e := range entries {
+		if e.AmountCents == 0 {
+			continue
+		}
+		if e.AmountCents < 0 {
+			return false, fmt.Errorf("ledger %s: negative amount on %s", t.Kind, e.Account)
+		}
+
+		if e.Direction == models.LedgerDebit {
+			balance += e.AmountCents
+		} else {
+			balance -= e.AmountCents
+		}
+		lines = append(lines, e)
+	}
+
+	if balance != 0 {
+		return false, fmt.Errorf("ledger %s is unbalanced by %d", t.Kind, balance)
+	}
+
+	if len(lines) == 0 {
+		return false, errors.New("ledger transaction has no entries")
+	}
+
+	return s.ledgerRepo.CreateTransactionTx(ctx, tx, t, lines)
+}
+
+func ledgerEntry(
+	account string,
+	mentorID *uuid.UUID,
+	direction string,
+	amount int64,
+	currency string,
+	availableAt time.Time,
+) *models.LedgerEntry {
+	return &models.LedgerEntry{
+		ID:          uuid.New(),
+		Account:     account,
+		MentorID:    mentorID,
+		Direction:   direction,
+		AmountCents: amount,
+		Currency:    currency,
+		AvailableAt: availableAt,
+	}
+}
+
+// GetEarnings returns the caller's balances and monthly statements.
+func (s *LedgerService) GetEarnings(
+	userID uuid.UUID,
+) (*dtos.EarningsResponse, *appErrors.AppError) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, appErrors.MentorProfileRequired()
+	}
+
+	loc := responseLocation(mentor, "")
+
+	balances, err := s.ledgerRepo.MentorBalances(ctx, mentor.ID, time.Now())
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	statements, err := s.ledgerRepo.MentorStatements(ctx, mentor.ID, loc.String())
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	return &dtos.EarningsResponse{
+		Timezone:   loc.String(),
+		Balances:   balances,
+		Statements: statements,
+	}, nil
+}
+
+// ExportEarningsCSV renders every movement on the caller's payable
+// account as CSV, with times in the mentor's timezone.
+func (s *LedgerService) ExportEarningsCSV(
+	userID uuid.UUID,
+) ([]byte, *appErrors.AppError) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, appErrors.MentorProfileRequired()
+	}
+
+	loc := responseLocation(mentor, "")
+
+	entries, err := s.ledgerRepo.MentorEntries(ctx, mentor.ID)
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	w.Write([]string{"date", "type", "booking_id", "amount_cents", "currency", "available_at"})
+
+	for _, e := range entries {
+		amount := e.AmountCents
+		if e.Direction == models.LedgerDebit {
+			amount = -amount
+		}
+
+		bookingID := ""
+		if e.BookingID != nil {
+			bookingID = e.BookingID.String()
+		}
+
+		w.Write([]string{
+			e.CreatedAt.In(loc).Format(time.RFC3339),
+			e.Kind,
+			bookingID,
+			strconv.FormatInt(amount, 10),
+			e.Currency,
+			e.AvailableAt.In(loc).Format(time.RFC3339),
+		})
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	return buf.Bytes(), nil
+}
+
+// PostPayoutTx moves a payout's amount out of the mentor's payable
+// balance into payouts_in_transit until the provider settles it.
+func (s *LedgerService) PostPayoutTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	payout *models.Payout,
+) error {
+
+	now := time.Now()
+	mentorID := payout.MentorID
+	payoutID := payout.ID
+
+	t := &models.LedgerTransaction{
+		ID:             uuid.New(),
+		Kind:           models.LedgerKindPayout,
+		IdempotencyKey: "payout:" + payout.ID.String(),
+		MentorID:       &mentorID,
+		PayoutID:       &payoutID,
+		Memo:           "payout sent",
+	}
+
+	entries := []*models.LedgerEntry{
+		ledgerEntry(models.LedgerAccountMentorPayable, &mentorID, models.LedgerDebit, payout.AmountCents, payout.Currency, now),
+		ledgerEntry(models.LedgerAccountPayoutsInTransit, &mentorID, models.LedgerCredit, payout.AmountCents, payout.Currency, now),
+	}
+
+	_, err := s.post(ctx, tx, t, entries)
+	return err
+}
+
+// PostPayoutSettledTx clears a payout from payouts_in_transit. A paid
+// payout leaves the platform's funds; a failed one goes back to the
+// mentor's available balance.
+func (s *LedgerService) PostPayoutSettledTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	payout *models.Payout,
+	status string,
+) error {
+
+	now := time.Now()
+	mentorID := payout.MentorID
+	payoutID := payout.ID
+
+	kind := models.LedgerKindPayoutPaid
+	counterAccount := models.LedgerAccountGatewayClearing
+	memo := "payout paid"
+	if status == models.PayoutStatusFailed {
+		kind = models.LedgerKindPayoutFailed
+		counterAccount = models.LedgerAccountMentorPayable
+		memo = "payout failed"
+	}
+
+	t := &models.LedgerTransaction{
+		ID:             uuid.New(),
+		Kind:           kind,
+		IdempotencyKey: kind + ":" + payout.ID.String(),
+		MentorID:       &mentorID,
+		PayoutID:       &payoutID,
+		Memo:           memo,
+	}
+
+	entries := []*models.LedgerEntry{
+		ledgerEntry(models.LedgerAccountPayoutsInTransit, &mentorID, models.LedgerDebit, payout.AmountCents, payout.Currency, now),
+		ledgerEntry(counterAccount, &mentorID, models.LedgerCredit, payout.AmountCents, payout.Currency, now),
+	}
+
+	_, err := s.post(ctx, tx, t, entries)
+	return err
+}
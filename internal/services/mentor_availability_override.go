@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
+	appErrors "github.com/preetsinghmakkar/OpenCall/internal/errors"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+	"github.com/preetsinghmakkar/OpenCall/internal/repositories"
+	"github.com/preetsinghmakkar/OpenCall/internal/utils"
+)
+
+// Overrides listed when the mentor does not pass a range
+const defaultOverrideListDays = 90
+
+// CreateOverride adds a blackout, a partial block or an extra window on a
+// date in the mentor's timezone.
+func (s *MentorAvailabilityService) CreateOverride(
+	userID uuid.UUID,
+	req *dtos.CreateAvailabilityOverrideRequest,
+) (*dtos.AvailabilityOverrideResponse, *appErrors.AppError) {
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, appErrors.MentorProfileRequired()
+	}
+
+	loc, err := utils.LoadLocation(mentor.Timezone)
+	if err != nil {
+		return nil, appErrors.InvalidAvailability("mentor has an invalid timezone")
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return nil, appErrors.InvalidAvailability("date must be YYYY-MM-DD")
+	}
+
+	now := time.Now().In(loc)
+	if date.Format("2006-01-02") < now.Format("2006-01-02") {
+		return nil, appErrors.InvalidAvailability("date is in the past")
+	}
+
+	override := &models.MentorAvailabilityOverride{
+		ID:       uuid.New(),
+		MentorID: mentor.ID,
+		Date:     date,
+		Kind:     req.Kind,
+		Reason:   req.Reason,
+	}
+
+	if req.Kind == models.OverrideKindBlackout {
+		if req.StartTime != "" || req.EndTime != "" {
+			return nil, appErrors.InvalidAvailability("a blackout covers the whole day and takes no times")
+		}
+	} else {
+		start, err := time.Parse("15:04", req.StartTime)
+		if err != nil {
+			return nil, appErrors.InvalidAvailability("start time must be HH:MM")
+		}
+
+		end, err := time.Parse("15:04", req.EndTime)
+		if err != nil {
+			return nil, appErrors.InvalidAvailability("end time must be HH:MM")
+		}
+
+		if !end.After(start) {
+			return nil, appErrors.InvalidAvailability("end time must be after start time")
+		}
+
+		override.StartTime = &start
+		override.EndTime = &end
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.overrideRepo.Create(ctx, override); err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	resp := overrideResponse(override, mentor.Timezone)
+	return &resp, nil
+}
+
+// ListOverrides returns the caller's overrides dated from..to, inclusive.
+// Empty bounds default to today and defaultOverrideListDays after it.
+func (s *MentorAvailabilityService) ListOverrides(
+	userID uuid.UUID,
+	fromStr string,
+	toStr string,
+) ([]dtos.AvailabilityOverrideResponse, *appErrors.AppError) {
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, appErrors.MentorProfileRequired()
+	}
+
+	loc, err := utils.LoadLocation(mentor.Timezone)
+	if err != nil {
+		return nil, appErrors.InvalidAvailability("mentor has an invalid timezone")
+	}
+
+	today := time.Now().In(loc)
+	from := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, defaultOverrideListDays)
+
+	if fromStr != "" {
+		if from, err = time.Parse("2006-01-02", fromStr); err != nil {
+			return nil, appErrors.InvalidAvailability("from must be YYYY-MM-DD")
+		}
+	}
+	if toStr != "" {
+		if to, err = time.Parse("2006-01-02", toStr); err != nil {
+			return nil, appErrors.InvalidAvailability("to must be YYYY-MM-DD")
+		}
+	}
+	if to.Before(from) {
+		return nil, appErrors.InvalidAvailability("to must not be before from")
+	}
+
+	overrides, err := s.overrideRepo.FindByMentorBetween(mentor.ID, from, to)
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	resp := make([]dtos.AvailabilityOverrideResponse, 0, len(overrides))
+	for _, o := range overrides {
+		resp = append(resp, overrideResponse(o, mentor.Timezone))
+	}
+
+	return resp, nil
+}
+
+// DeleteOverride removes one of the caller's overrides.
+func (s *MentorAvailabilityService) DeleteOverride(
+	userID uuid.UUID,
+	overrideID uuid.UUID,
+) *appErrors.AppError {
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
+	if err != nil {
+		return appErrors.MentorProfileRequired()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	override, err := s.overrideRepo.GetByID(ctx, overrideID)
+	if errors.Is(err, repositories.ErrAvailabilityOverrideNotFound) {
+		return appErrors.AvailabilityOverrideNotFound()
+	}
+	if err != nil {
+		return appErrors.InternalServerError()
+	}
+
+	if override.MentorID != mentor.ID {
+		return appErrors.AvailabilityOverrideForbidden()
+	}
+
+	err = s.overrideRepo.Delete(ctx, overrideID)
+	if errors.Is(err, repositories.ErrAvailabilityOverrideNotFound) {
+		return appErrors.AvailabilityOverrideNotFound()
+	}
+	if err != nil {
+		return appErrors.InternalServerError()
+	}
+
+	return nil
+}
+
+func overrideResponse(
+	o *models.MentorAvailabilityOverride,
+	timezone string,
+) dtos.AvailabilityOverrideResponse {
+
+	resp := dtos.AvailabilityOverrideResponse{
+		ID:       o.ID,
+		Date:     o.Date.Format("2006-01-02"),
+		Kind:     o.Kind,
+		Reason:   o.Reason,
+		Timezone: timezone,
+	}
+
+	if o.StartTime != nil && o.EndTime != nil {
+		resp.StartTime = o.StartTime.Format("15:04")
+		resp.EndTime = o.EndTime.Format("15:04")
+	}
+
+	return resp
+}
@@ -1,98 +1,388 @@
 package services
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
+	appErrors "github.com/preetsinghmakkar/OpenCall/internal/errors"
 	"github.com/preetsinghmakkar/OpenCall/internal/models"
 	"github.com/preetsinghmakkar/OpenCall/internal/repositories"
 )
 
+// Shortest availability window a mentor can publish
+const minAvailabilityWindow = 30 * time.Minute
+
 type MentorAvailabilityService struct {
 	availabilityRepo *repositories.MentorAvailabilityRepository
+	overrideRepo     *repositories.MentorAvailabilityOverrideRepository
 	mentorRepo       *repositories.MentorRepository
 }
 
 func NewMentorAvailabilityService(
 	availabilityRepo *repositories.MentorAvailabilityRepository,
+	overrideRepo *repositories.MentorAvailabilityOverrideRepository,
 	mentorRepo *repositories.MentorRepository,
 ) *MentorAvailabilityService {
 	return &MentorAvailabilityService{
 		availabilityRepo: availabilityRepo,
+		overrideRepo:     overrideRepo,
 		mentorRepo:       mentorRepo,
 	}
 }
 
+// CreateRule adds a window to the mentor's weekly schedule. A window that
+// overlaps an existing one on the same day is rejected; one that only
+// touches a neighbour is merged with it into a single rule.
 func (s *MentorAvailabilityService) CreateRule(
 	userID uuid.UUID,
 	req *dtos.CreateMentorAvailabilityRequest,
-) (*dtos.MentorAvailabilityResponse, error) {
+) (*dtos.MentorAvailabilityResponse, *appErrors.AppError) {
 
 	mentor, err := s.mentorRepo.FindByUserID(userID)
 	if err != nil {
-		return nil, err
+		return nil, appErrors.MentorProfileRequired()
 	}
 
-	start, err := time.Parse("15:04", req.StartTime)
+	rule, appErr := parseRule(mentor.ID, req)
+	if appErr != nil {
+		return nil, appErr
+	}
+	rule.ID = uuid.New()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = s.availabilityRepo.WithMentorLock(ctx, mentor.ID, func(tx *sql.Tx) error {
+		return s.placeRuleTx(ctx, tx, rule, &appErr)
+	})
+	if appErr != nil {
+		return nil, appErr
+	}
 	if err != nil {
-		return nil, err
+		return nil, appErrors.InternalServerError()
 	}
 
-	end, err := time.Parse("15:04", req.EndTime)
+	resp := availabilityResponse(rule, mentor.Timezone)
+	return &resp, nil
+}
+
+// ListOwnRules returns the caller's weekly schedule.
+func (s *MentorAvailabilityService) ListOwnRules(
+	userID uuid.UUID,
+) ([]dtos.MentorAvailabilityResponse, *appErrors.AppError) {
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
 	if err != nil {
-		return nil, err
+		return nil, appErrors.MentorProfileRequired()
 	}
 
-	// Enforce that availability duration is at least 30 minutes
-	if !end.After(start) {
-		return nil, errors.New("end time must be after start time")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rules, err := s.availabilityRepo.FindByMentorID(ctx, mentor.ID)
+	if err != nil {
+		return nil, appErrors.InternalServerError()
 	}
-	if end.Sub(start) < 30*time.Minute {
-		return nil, errors.New("availability duration must be at least 30 minutes")
+
+	return availabilityResponses(rules, mentor.Timezone), nil
+}
+
+// UpdateRule moves or resizes one of the mentor's rules, with the same
+// overlap and merge handling as CreateRule.
+func (s *MentorAvailabilityService) UpdateRule(
+	userID uuid.UUID,
+	ruleID uuid.UUID,
+	req *dtos.CreateMentorAvailabilityRequest,
+) (*dtos.MentorAvailabilityResponse, *appErrors.AppError) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mentor, appErr := s.ruleOwner(ctx, userID, ruleID)
+	if appErr != nil {
+		return nil, appErr
 	}
 
-	rule := &models.MentorAvailabilityRule{
-		ID:        uuid.New(),
-		MentorID:  mentor.ID,
-		DayOfWeek: req.DayOfWeek,
-		StartTime: start,
-		EndTime:   end,
+	rule, appErr := parseRule(mentor.ID, req)
+	if appErr != nil {
+		return nil, appErr
 	}
+	rule.ID = ruleID
 
-	createdRule, err := s.availabilityRepo.Create(rule)
+	err := s.availabilityRepo.WithMentorLock(ctx, mentor.ID, func(tx *sql.Tx) error {
+		// drop the old window first so it does not collide with itself
+		if err := s.availabilityRepo.DeleteTx(ctx, tx, ruleID); err != nil {
+			return err
+		}
+		return s.placeRuleTx(ctx, tx, rule, &appErr)
+	})
+	if appErr != nil {
+		return nil, appErr
+	}
+	if errors.Is(err, repositories.ErrAvailabilityRuleNotFound) {
+		return nil, appErrors.AvailabilityRuleNotFound()
+	}
 	if err != nil {
-		return nil, err
+		return nil, appErrors.InternalServerError()
 	}
 
-	return &dtos.MentorAvailabilityResponse{
-		ID:        createdRule.ID,
-		DayOfWeek: createdRule.DayOfWeek,
-		StartTime: createdRule.StartTime.Format("15:04"),
-		EndTime:   createdRule.EndTime.Format("15:04"),
-	}, nil
+	resp := availabilityResponse(rule, mentor.Timezone)
+	return &resp, nil
+}
+
+// DeleteRule removes one window. Existing bookings inside it are kept.
+func (s *MentorAvailabilityService) DeleteRule(
+	userID uuid.UUID,
+	ruleID uuid.UUID,
+) *appErrors.AppError {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mentor, appErr := s.ruleOwner(ctx, userID, ruleID)
+	if appErr != nil {
+		return appErr
+	}
+
+	err := s.availabilityRepo.WithMentorLock(ctx, mentor.ID, func(tx *sql.Tx) error {
+		return s.availabilityRepo.DeleteTx(ctx, tx, ruleID)
+	})
+	if errors.Is(err, repositories.ErrAvailabilityRuleNotFound) {
+		return appErrors.AvailabilityRuleNotFound()
+	}
+	if err != nil {
+		return appErrors.InternalServerError()
+	}
+
+	return nil
+}
+
+// ReplaceSchedule swaps the whole weekly schedule in one transaction.
+// Overlapping or touching windows on the same day are merged, so the
+// stored schedule never contains overlaps. An empty list clears it.
+func (s *MentorAvailabilityService) ReplaceSchedule(
+	userID uuid.UUID,
+	req *dtos.ReplaceMentorAvailabilityRequest,
+) ([]dtos.MentorAvailabilityResponse, *appErrors.AppError) {
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, appErrors.MentorProfileRequired()
+	}
+
+	rules := make([]*models.MentorAvailabilityRule, 0, len(req.Rules))
+	for i := range req.Rules {
+		rule, appErr := parseRule(mentor.ID, &req.Rules[i])
+		if appErr != nil {
+			return nil, appErrors.InvalidAvailability(fmt.Sprintf("rules[%d]: %s", i, appErr.Message))
+		}
+		rules = append(rules, rule)
+	}
+
+	rules = mergeRules(rules)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = s.availabilityRepo.WithMentorLock(ctx, mentor.ID, func(tx *sql.Tx) error {
+		if err := s.availabilityRepo.DeleteByMentorTx(ctx, tx, mentor.ID); err != nil {
+			return err
+		}
+
+		for _, rule := range rules {
+			rule.ID = uuid.New()
+			if err := s.availabilityRepo.CreateTx(ctx, tx, rule); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	return availabilityResponses(rules, mentor.Timezone), nil
 }
 
 func (s *MentorAvailabilityService) GetByUsername(
 	username string,
 ) ([]dtos.MentorAvailabilityResponse, error) {
 
+	mentor, err := s.mentorRepo.FindByUsernameRaw(username)
+	if err != nil {
+		return nil, err
+	}
+
 	rules, err := s.availabilityRepo.FindByUsername(username)
 	if err != nil {
 		return nil, err
 	}
 
-	resp := make([]dtos.MentorAvailabilityResponse, 0, len(rules))
+	return availabilityResponses(rules, mentor.Timezone), nil
+}
 
-	for _, r := range rules {
-		resp = append(resp, dtos.MentorAvailabilityResponse{
-			ID:        r.ID,
-			DayOfWeek: r.DayOfWeek,
-			StartTime: r.StartTime.Format("15:04"),
-			EndTime:   r.EndTime.Format("15:04"),
-		})
+// placeRuleTx inserts rule after checking it against the rest of that
+// day's schedule. Neighbours that touch it are folded into rule. A
+// validation failure is reported through appErr and aborts the tx.
+func (s *MentorAvailabilityService) placeRuleTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	rule *models.MentorAvailabilityRule,
+	appErr **appErrors.AppError,
+) error {
+
+	existing, err := s.availabilityRepo.FindByMentorTx(ctx, tx, rule.MentorID)
+	if err != nil {
+		return err
+	}
+
+	var touching []*models.MentorAvailabilityRule
+
+	for _, other := range existing {
+		if other.DayOfWeek != rule.DayOfWeek {
+			continue
+		}
+
+		if rule.StartTime.Before(other.EndTime) && rule.EndTime.After(other.StartTime) {
+			*appErr = appErrors.AvailabilityOverlap()
+			return errors.New((*appErr).Message)
+		}
+
+		if rule.StartTime.Equal(other.EndTime) || rule.EndTime.Equal(other.StartTime) {
+			touching = append(touching, other)
+		}
+	}
+
+	for _, other := range touching {
+		if other.StartTime.Before(rule.StartTime) {
+			rule.StartTime = other.StartTime
+		}
+		if other.EndTime.After(rule.EndTime) {
+			rule.EndTime = other.EndTime
+		}
+
+		if err := s.availabilityRepo.DeleteTx(ctx, tx, other.ID); err != nil {
+			return err
+		}
+	}
+
+	return s.availabilityRepo.CreateTx(ctx, tx, rule)
+}
+
+// ruleOwner returns the caller's mentor profile after checking that the
+// rule belongs to it.
+func (s *MentorAvailabilityService) ruleOwner(
+	ctx context.Context,
+	userID uuid.UUID,
+	ruleID uuid.UUID,
+) (*models.MentorProfile, *appErrors.AppError) {
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, appErrors.MentorProfileRequired()
+	}
+
+	rule, err := s.availabilityRepo.GetByID(ctx, ruleID)
+	if errors.Is(err, repositories.ErrAvailabilityRuleNotFound) {
+		return nil, appErrors.AvailabilityRuleNotFound()
 	}
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	if rule.MentorID != mentor.ID {
+		return nil, appErrors.AvailabilityRuleForbidden()
+	}
+
+	return mentor, nil
+}
+
+func parseRule(
+	mentorID uuid.UUID,
+	req *dtos.CreateMentorAvailabilityRequest,
+) (*models.MentorAvailabilityRule, *appErrors.AppError) {
 
-	return resp, nil
+	start, err := time.Parse("15:04", req.StartTime)
+	if err != nil {
+		return nil, appErrors.InvalidAvailability("start time must be HH:MM")
+	}
+
+	end, err := time.Parse("15:04", req.EndTime)
+	if err != nil {
+		return nil, appErrors.InvalidAvailability("end time must be HH:MM")
+	}
+
+	// Enforce that availability duration is at least 30 minutes
+	if !end.After(start) {
+		return nil, appErrors.InvalidAvailability("end time must be after start time")
+	}
+	if end.Sub(start) < minAvailabilityWindow {
+		return nil, appErrors.InvalidAvailability("availability duration must be at least 30 minutes")
+	}
+
+	return &models.MentorAvailabilityRule{
+		MentorID:  mentorID,
+		DayOfWeek: req.DayOfWeek,
+		StartTime: start,
+		EndTime:   end,
+	}, nil
+}
+
+// mergeRules sorts rules by day and start and joins windows on the same
+// day that overlap or touch.
+func mergeRules(rules []*models.MentorAvailabilityRule) []*models.MentorAvailabilityRule {
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].DayOfWeek != rules[j].DayOfWeek {
+			return rules[i].DayOfWeek < rules[j].DayOfWeek
+		}
+		return rules[i].StartTime.Before(rules[j].StartTime)
+	})
+
+	merged := make([]*models.MentorAvailabilityRule, 0, len(rules))
+
+	for _, rule := range rules {
+		if n := len(merged); n > 0 {
+			last := merged[n-1]
+			if last.DayOfWeek == rule.DayOfWeek && !rule.StartTime.After(last.EndTime) {
+				if rule.EndTime.After(last.EndTime) {
+					last.EndTime = rule.EndTime
+				}
+				continue
+			}
+		}
+		merged = append(merged, rule)
+	}
+
+	return merged
+}
+
+func availabilityResponse(
+	rule *models.MentorAvailabilityRule,
+	timezone string,
+) dtos.MentorAvailabilityResponse {
+	return dtos.MentorAvailabilityResponse{
+		ID:        rule.ID,
+		DayOfWeek: rule.DayOfWeek,
+		StartTime: rule.StartTime.Format("15:04"),
+		EndTime:   rule.EndTime.Format("15:04"),
+		Timezone:  timezone,
+	}
+}
+
+func availabilityResponses(
+	rules []*models.MentorAvailabilityRule,
+	timezone string,
+) []dtos.MentorAvailabilityResponse {
+	resp := make([]dtos.MentorAvailabilityResponse, 0, len(rules))
+	for _, r := range rules {
+		resp = append(resp, availabilityResponse(r, timezone))
+	}
+	return resp
 }
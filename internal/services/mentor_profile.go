@@ -1,9 +1,12 @@
 package services
 
 import (
+	"errors"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/constants"
 	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
 	"github.com/preetsinghmakkar/OpenCall/internal/models"
 	"github.com/preetsinghmakkar/OpenCall/internal/repositories"
@@ -26,13 +29,27 @@ func (s *MentorProfileService) CreateProfile(
 	req *dtos.CreateMentorProfileRequest,
 ) (*models.MentorProfile, error) {
 
+	// Availability rules are interpreted in this zone, so it must resolve
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		return nil, errors.New("invalid timezone")
+	}
+
 	profile := &models.MentorProfile{
-		ID:       uuid.New(),
-		UserID:   userID,
-		Title:    strings.TrimSpace(req.Title),
-		Bio:      strings.TrimSpace(req.Bio),
-		Timezone: req.Timezone,
-		IsActive: true,
+		ID:                            uuid.New(),
+		UserID:                        userID,
+		Title:                         strings.TrimSpace(req.Title),
+		Bio:                           strings.TrimSpace(req.Bio),
+		Timezone:                      req.Timezone,
+		IsActive:                      true,
+		CancellationWindowHours:       constants.DefaultCancellationWindowHours,
+		LateCancellationRefundPercent: constants.DefaultLateCancellationRefundPercent,
+	}
+
+	if req.CancellationWindowHours != nil {
+		profile.CancellationWindowHours = *req.CancellationWindowHours
+	}
+	if req.LateCancellationRefundPercent != nil {
+		profile.LateCancellationRefundPercent = *req.LateCancellationRefundPercent
 	}
 
 	if err := s.mentorRepo.CreateProfile(profile); err != nil {
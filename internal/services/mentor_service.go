@@ -1,10 +1,15 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/constants"
 	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
+	appErrors "github.com/preetsinghmakkar/OpenCall/internal/errors"
 	"github.com/preetsinghmakkar/OpenCall/internal/models"
 	"github.com/preetsinghmakkar/OpenCall/internal/repositories"
 )
@@ -27,11 +32,15 @@ func NewMentorOfferingService(
 func (s *MentorOfferingService) CreateService(
 	userID uuid.UUID,
 	req *dtos.CreateMentorServiceRequest,
-) (*models.MentorService, error) {
+) (*models.MentorService, *appErrors.AppError) {
+
+	if !validDuration(req.DurationMinutes) {
+		return nil, appErrors.InvalidServiceDuration()
+	}
 
 	mentor, err := s.mentorRepo.FindByUserID(userID)
 	if err != nil {
-		return nil, err // mentor profile not found
+		return nil, appErrors.MentorProfileRequired()
 	}
 
 	service := &models.MentorService{
@@ -40,12 +49,20 @@ func (s *MentorOfferingService) CreateService(
 		Title:           strings.TrimSpace(req.Title),
 		Description:     strings.TrimSpace(req.Description),
 		DurationMinutes: req.DurationMinutes,
-		PriceCents:      req.PriceCents,
+		PriceCents:      *req.PriceCents,
 		Currency:        strings.ToUpper(req.Currency),
 		IsActive:        true,
+
+		BufferBeforeMinutes: req.BufferBeforeMinutes,
+		BufferAfterMinutes:  req.BufferAfterMinutes,
+		SlotStepMinutes:     req.SlotStepMinutes,
+		MaxSessionsPerDay:   req.MaxSessionsPerDay,
+		MinGapMinutes:       req.MinGapMinutes,
+
+		FreeBookingsPerMentee: req.FreeBookingsPerMentee,
 	}
 	if err := s.serviceRepo.Create(service); err != nil {
-		return nil, err
+		return nil, appErrors.InternalServerError()
 	}
 
 	return service, nil
@@ -60,19 +77,226 @@ func (s *MentorOfferingService) GetServicesByUsername(
 		return nil, err
 	}
 
-	resp := make([]dtos.MentorServiceResponse, 0, len(services))
+	return mentorServiceResponses(services), nil
+}
+
+// GetOwnServices lists the caller's services, including inactive ones.
+func (s *MentorOfferingService) GetOwnServices(
+	userID uuid.UUID,
+) ([]dtos.MentorServiceResponse, *appErrors.AppError) {
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, appErrors.MentorProfileRequired()
+	}
+
+	services, err := s.serviceRepo.FindByMentorID(mentor.ID)
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	return mentorServiceResponses(services), nil
+}
+
+// UpdateService edits a service. Bookings store their own price and
+// start/end instants, so existing bookings are unaffected.
+func (s *MentorOfferingService) UpdateService(
+	userID uuid.UUID,
+	serviceID uuid.UUID,
+	req *dtos.UpdateMentorServiceRequest,
+) (*dtos.MentorServiceResponse, *appErrors.AppError) {
+
+	if !validDuration(req.DurationMinutes) {
+		return nil, appErrors.InvalidServiceDuration()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	service, appErr := s.ownedService(ctx, userID, serviceID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	service.Title = strings.TrimSpace(req.Title)
+	service.Description = strings.TrimSpace(req.Description)
+	service.DurationMinutes = req.DurationMinutes
+	service.PriceCents = *req.PriceCents
+	service.Currency = strings.ToUpper(req.Currency)
+	service.BufferBeforeMinutes = req.BufferBeforeMinutes
+	service.BufferAfterMinutes = req.BufferAfterMinutes
+	service.SlotStepMinutes = req.SlotStepMinutes
+	service.MaxSessionsPerDay = req.MaxSessionsPerDay
+	service.MinGapMinutes = req.MinGapMinutes
+	service.FreeBookingsPerMentee = req.FreeBookingsPerMentee
+
+	if err := s.serviceRepo.Update(ctx, service); err != nil {
+		return nil, serviceRepoError(err)
+	}
+
+	resp := mentorServiceResponse(service)
+	return &resp, nil
+}
+
+// SetServiceActive shows or hides a service on the mentor's public page.
+// Inactive services cannot be booked.
+func (s *MentorOfferingService) SetServiceActive(
+	userID uuid.UUID,
+	serviceID uuid.UUID,
+	active bool,
+) (*dtos.MentorServiceResponse, *appErrors.AppError) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	service, appErr := s.ownedService(ctx, userID, serviceID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if err := s.serviceRepo.SetActive(ctx, serviceID, active); err != nil {
+		return nil, serviceRepoError(err)
+	}
+
+	service.IsActive = active
+
+	resp := mentorServiceResponse(service)
+	return &resp, nil
+}
+
+// DeleteService soft-deletes a service. Bookings already made for it stay
+// valid.
+func (s *MentorOfferingService) DeleteService(
+	userID uuid.UUID,
+	serviceID uuid.UUID,
+) *appErrors.AppError {
 
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, appErr := s.ownedService(ctx, userID, serviceID); appErr != nil {
+		return appErr
+	}
+
+	if err := s.serviceRepo.SoftDelete(ctx, serviceID); err != nil {
+		return serviceRepoError(err)
+	}
+
+	return nil
+}
+
+// ReorderServices sets the display order. The request must list each of
+// the mentor's services exactly once.
+func (s *MentorOfferingService) ReorderServices(
+	userID uuid.UUID,
+	req *dtos.ReorderMentorServicesRequest,
+) ([]dtos.MentorServiceResponse, *appErrors.AppError) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, appErrors.MentorProfileRequired()
+	}
+
+	current, err := s.serviceRepo.FindByMentorID(mentor.ID)
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	owned := make(map[uuid.UUID]bool, len(current))
+	for _, svc := range current {
+		owned[svc.ID] = true
+	}
+
+	seen := make(map[uuid.UUID]bool, len(req.ServiceIDs))
+	for _, id := range req.ServiceIDs {
+		if !owned[id] {
+			return nil, appErrors.InvalidServiceOrder("unknown service " + id.String())
+		}
+		if seen[id] {
+			return nil, appErrors.InvalidServiceOrder("service listed twice: " + id.String())
+		}
+		seen[id] = true
+	}
+
+	if len(seen) != len(owned) {
+		return nil, appErrors.InvalidServiceOrder("every service must be listed")
+	}
+
+	if err := s.serviceRepo.Reorder(ctx, mentor.ID, req.ServiceIDs); err != nil {
+		return nil, serviceRepoError(err)
+	}
+
+	return s.GetOwnServices(userID)
+}
+
+// ownedService loads a non-deleted service and checks that it belongs to
+// the mentor profile of userID.
+func (s *MentorOfferingService) ownedService(
+	ctx context.Context,
+	userID uuid.UUID,
+	serviceID uuid.UUID,
+) (*models.MentorService, *appErrors.AppError) {
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, appErrors.MentorProfileRequired()
+	}
+
+	service, err := s.serviceRepo.FindForManagement(ctx, serviceID)
+	if err != nil {
+		return nil, serviceRepoError(err)
+	}
+
+	if service.MentorID != mentor.ID {
+		return nil, appErrors.MentorServiceForbidden()
+	}
+
+	return service, nil
+}
+
+// validDuration reports whether a session length, in minutes, is one a
+// service can be offered in.
+func validDuration(minutes int) bool {
+	return minutes > 0 &&
+		minutes <= constants.MaxSessionDurationMinutes &&
+		minutes%constants.SessionDurationStepMinutes == 0
+}
+
+func serviceRepoError(err error) *appErrors.AppError {
+	if errors.Is(err, repositories.ErrMentorServiceNotFound) {
+		return appErrors.MentorServiceNotFound()
+	}
+	return appErrors.InternalServerError()
+}
+
+func mentorServiceResponse(svc *models.MentorService) dtos.MentorServiceResponse {
+	return dtos.MentorServiceResponse{
+		ID:              svc.ID,
+		Title:           svc.Title,
+		Description:     svc.Description,
+		DurationMinutes: svc.DurationMinutes,
+		PriceCents:      svc.PriceCents,
+		Currency:        svc.Currency,
+		IsActive:        svc.IsActive,
+		SortOrder:       svc.SortOrder,
+
+		BufferBeforeMinutes: svc.BufferBeforeMinutes,
+		BufferAfterMinutes:  svc.BufferAfterMinutes,
+		SlotStepMinutes:     svc.SlotStepMinutes,
+		MaxSessionsPerDay:   svc.MaxSessionsPerDay,
+		MinGapMinutes:       svc.MinGapMinutes,
+
+		FreeBookingsPerMentee: svc.FreeBookingsPerMentee,
+	}
+}
+
+func mentorServiceResponses(services []*models.MentorService) []dtos.MentorServiceResponse {
+	resp := make([]dtos.MentorServiceResponse, 0, len(services))
 	for _, svc := range services {
-		resp = append(resp, dtos.MentorServiceResponse{
-			ID:              svc.ID,
-			Title:           svc.Title,
-			Description:     svc.Description,
-			DurationMinutes: svc.DurationMinutes,
-			PriceCents:      svc.PriceCents,
-			Currency:        svc.Currency,
-			IsActive:        svc.IsActive,
-		})
-	}
-
-	return resp, nil
+		resp = append(resp, mentorServiceResponse(svc))
+	}
+	return resp
 }
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidWebhookSignature is returned by VerifyWebhook when a payload
+// was not signed by the gateway.
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// ErrNoGatewayForCurrency is returned when no configured gateway accepts
+// a currency.
+var ErrNoGatewayForCurrency = errors.New("no payment gateway accepts this currency")
+
+// ErrUnknownPaymentGateway is returned for a gateway name that is not
+// configured.
+var ErrUnknownPaymentGateway = errors.New("unknown payment gateway")
+
+// PaymentGateway takes payments from mentees. Each payment row records
+// the gateway that created it, so later calls for that payment go back to
+// the same gateway.
+type PaymentGateway interface {
+	// Name identifies the gateway in stored payments and webhook routes
+	Name() string
+
+	// CreateOrder opens an order the client pays for. The receipt ties the
+	// gateway order back to our booking or package purchase.
+	CreateOrder(ctx context.Context, req OrderRequest) (*GatewayOrder, error)
+
+	// VerifyPayment checks the client's proof that orderID was paid and
+	// returns the capture as a PaymentEvent, with the amount and currency
+	// the gateway actually took
+	VerifyPayment(
+		ctx context.Context,
+		orderID string,
+		paymentID string,
+		signature string,
+	) (*PaymentEvent, error)
+
+	// FetchOrder asks the gateway for an order's current state and reports
+	// it as the webhook event that would have moved it there: captured,
+	// failed, or an empty Type while the order is still unpaid.
+	FetchOrder(ctx context.Context, orderID string) (*PaymentEvent, error)
+
+	// VerifyWebhook checks the signature of a webhook payload as it
+	// arrives and returns the ID of the webhook secret that signed it.
+	// Stored events are not verified again.
+	VerifyWebhook(payload []byte, signature string) (string, error)
+
+	// ParseWebhook maps a webhook payload onto a PaymentEvent. Events we
+	// don't act on come back with an empty Type.
+	ParseWebhook(payload []byte) (*PaymentEvent, error)
+
+	// Refund issues a full or partial refund against a captured payment
+	// and returns the gateway refund ID and one of models.RefundStatus*.
+	// Submissions are retried, so a repeated receipt must return the
+	// refund already issued for it instead of refunding twice.
+	Refund(ctx context.Context, req RefundRequest) (string, string, error)
+}
+
+type OrderRequest struct {
+	AmountCents int64
+	Currency    string
+	Receipt     string
+	Description string
+}
+
+// GatewayOrder is what the client needs to pay an order. ClientSecret is
+// only set by gateways that confirm payments in the browser (Stripe).
+type GatewayOrder struct {
+	OrderID      string
+	ClientSecret string
+}
+
+type RefundRequest struct {
+	PaymentID   string
+	AmountCents int64
+	Receipt     string // our refunds.id
+}
+
+const (
+	PaymentEventCaptured = "payment_captured"
+	// PaymentEventFailed ends an order: it can no longer be paid
+	PaymentEventFailed = "payment_failed"
+	// PaymentEventAttemptFailed is a declined try at paying an order that
+	// can still be paid
+	PaymentEventAttemptFailed   = "payment_attempt_failed"
+	PaymentEventRefundProcessed = "refund_processed"
+	PaymentEventRefundFailed    = "refund_failed"
+)
+
+// PaymentEvent is a gateway webhook reduced to what PaymentService acts
+// on. Amount and Currency are in the gateway's smallest unit.
+type PaymentEvent struct {
+	// ID is the gateway's event ID when the payload carries one
+	ID string
+	// Name is the gateway's own event type, e.g. "payment.captured"
+	Name string
+
+	Type      string
+	OrderID   string
+	PaymentID string
+	Amount    int64
+	Currency  string
+	// FailureReason is the gateway's message for a failed attempt
+	FailureReason string
+
+	RefundID      string
+	RefundReceipt string // our refunds.id, when the gateway echoes it
+}
+
+// PaymentGateways picks the gateway for a new payment by its currency and
+// finds the gateway of an existing payment by name.
+type PaymentGateways struct {
+	byName     map[string]PaymentGateway
+	byCurrency map[string]PaymentGateway
+}
+
+func NewPaymentGateways() *PaymentGateways {
+	return &PaymentGateways{
+		byName:     map[string]PaymentGateway{},
+		byCurrency: map[string]PaymentGateway{},
+	}
+}
+
+// Register adds a gateway for the given currencies. A currency registered
+// twice goes to the later gateway.
+func (g *PaymentGateways) Register(gateway PaymentGateway, currencies ...string) {
+	g.byName[gateway.Name()] = gateway
+	for _, currency := range currencies {
+		g.byCurrency[strings.ToUpper(currency)] = gateway
+	}
+}
+
+func (g *PaymentGateways) ForCurrency(currency string) (PaymentGateway, error) {
+	gateway, ok := g.byCurrency[strings.ToUpper(currency)]
+	if !ok {
+		return nil, ErrNoGatewayForCurrency
+	}
+	return gateway, nil
+}
+
+func (g *PaymentGateways) Get(name string) (PaymentGateway, error) {
+	gateway, ok := g.byName[name]
+	if !ok {
+		return nil, ErrUnknownPaymentGateway
+	}
+	return gateway, nil
+}
+
+// webhookSecret is one secret a gateway signs webhooks with. A gateway
+// accepts several while its secret is being rotated.
+type webhookSecret struct {
+	key []byte
+	// id names the secret in stored events without revealing it
+	id string
+}
+
+func newWebhookSecrets(secrets []string) []webhookSecret {
+	out := make([]webhookSecret, 0, len(secrets))
+	for _, secret := range secrets {
+		sum := sha256.Sum256([]byte(secret))
+		out = append(out, webhookSecret{
+			key: []byte(secret),
+			id:  hex.EncodeToString(sum[:8]),
+		})
+	}
+	return out
+}
+
+// matchWebhookSecret returns the ID of the secret under which one of
+// signatures is the hex HMAC-SHA256 of data, or "" when none is.
+// Signatures are compared in constant time.
+func matchWebhookSecret(
+	secrets []webhookSecret,
+	data []byte,
+	signatures ...string,
+) string {
+	for _, secret := range secrets {
+		h := hmac.New(sha256.New, secret.key)
+		h.Write(data)
+		expected := hex.EncodeToString(h.Sum(nil))
+
+		for _, sig := range signatures {
+			if hmac.Equal([]byte(expected), []byte(sig)) {
+				return secret.id
+			}
+		}
+	}
+
+	return ""
+}
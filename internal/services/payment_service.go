@@ -2,48 +2,97 @@ package services
 
 import (
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
 	"database/sql"
-	"encoding/hex"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
 	"github.com/preetsinghmakkar/OpenCall/internal/models"
 	"github.com/preetsinghmakkar/OpenCall/internal/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrBookingNotPayable is returned when a payment is opened for a booking
+// that is no longer awaiting payment.
+var ErrBookingNotPayable = errors.New("booking is not awaiting payment")
+
+// ErrPaymentAmountMismatch is returned when the gateway captured a
+// different amount or currency than the booking or purchase costs. The
+// payment is flagged for review instead of being fulfilled.
+var ErrPaymentAmountMismatch = errors.New("captured amount does not match the price")
+
+// ErrPaymentRefunded is returned when a payment was captured after its
+// booking stopped awaiting payment, or for a package purchase that was
+// already paid. The payment is refunded instead of being fulfilled.
+var ErrPaymentRefunded = errors.New("nothing is awaiting this payment any more; it is being refunded")
+
+const (
+	// refundSubmitBatch is how many queued refunds one run submits
+	refundSubmitBatch = 50
+	// refundSubmitLease after which a claimed refund whose submission
+	// never finished is submitted again
+	refundSubmitLease = 5 * time.Minute
+	// refundMaxAttempts before a refund is given up and left to be
+	// refunded by hand
+	refundMaxAttempts = 10
+
+	refundRetryBaseDelay = time.Minute
+	refundRetryMaxDelay  = 2 * time.Hour
 )
 
 type PaymentService struct {
-	db             *sql.DB
-	paymentRepo    *repositories.PaymentRepository
-	bookingRepo    *repositories.BookingRepository
-	razorpay       *RazorpayClient
-	razorpaySecret string
+	db           *sql.DB
+	paymentRepo  *repositories.PaymentRepository
+	bookingRepo  *repositories.BookingRepository
+	refundRepo   *repositories.RefundRepository
+	purchaseRepo *repositories.PackagePurchaseRepository
+	mismatchRepo *repositories.PaymentMismatchRepository
+	ledger       *LedgerService
+	invoices     *InvoiceService
+	gateways     *PaymentGateways
 }
 
 func NewPaymentService(
 	db *sql.DB,
 	paymentRepo *repositories.PaymentRepository,
 	bookingRepo *repositories.BookingRepository,
-	razorpay *RazorpayClient,
-	secret string,
+	refundRepo *repositories.RefundRepository,
+	purchaseRepo *repositories.PackagePurchaseRepository,
+	mismatchRepo *repositories.PaymentMismatchRepository,
+	ledger *LedgerService,
+	invoices *InvoiceService,
+	gateways *PaymentGateways,
 ) *PaymentService {
 	return &PaymentService{
-		db:             db,
-		paymentRepo:    paymentRepo,
-		bookingRepo:    bookingRepo,
-		razorpay:       razorpay,
-		razorpaySecret: secret,
+		db:           db,
+		paymentRepo:  paymentRepo,
+		bookingRepo:  bookingRepo,
+		refundRepo:   refundRepo,
+		purchaseRepo: purchaseRepo,
+		mismatchRepo: mismatchRepo,
+		ledger:       ledger,
+		invoices:     invoices,
+		gateways:     gateways,
 	}
 }
 
+// CreatePayment opens a gateway order for a pending booking. The
+// billing state, a GST state code, is optional and decides the tax on
+// the booking's invoice.
 func (s *PaymentService) CreatePayment(
 	ctx context.Context,
 	bookingID uuid.UUID,
 	userID uuid.UUID,
+	billingState string,
 ) (*models.Payment, error) {
 
+	if !validBillingState(billingState) {
+		return nil, ErrInvalidBillingState
+	}
+
 	booking, err := s.bookingRepo.GetByID(ctx, bookingID)
 	if err != nil {
 		return nil, err
@@ -53,32 +102,277 @@ func (s *PaymentService) CreatePayment(
 		return nil, errors.New("unauthorized")
 	}
 
+	if booking.Status != models.BookingStatusPending {
+		return nil, ErrBookingNotPayable
+	}
+
+	gateway, err := s.gateways.ForCurrency(booking.Currency)
+	if err != nil {
+		return nil, err
+	}
+
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	orderID, err := s.razorpay.CreateOrder(
-		int64(booking.PriceCents),
-		booking.ID.String(),
-	)
+	order, err := gateway.CreateOrder(ctx, OrderRequest{
+		AmountCents: int64(booking.PriceCents),
+		Currency:    booking.Currency,
+		Receipt:     booking.ID.String(),
+		Description: "OpenCall session booking",
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	payment := &models.Payment{
 		ID:             uuid.New(),
-		BookingID:      booking.ID,
+		BookingID:      &booking.ID,
 		UserID:         userID,
-		Gateway:        "razorpay",
-		GatewayOrderID: orderID,
+		Gateway:        gateway.Name(),
+		GatewayOrderID: order.OrderID,
 		Amount:         int64(booking.PriceCents),
-		Currency:       "INR",
-		Status:         "created",
+		Currency:       booking.Currency,
+		Status:         models.PaymentStatusCreated,
+		ClientSecret:   order.ClientSecret,
+	}
+	if billingState != "" {
+		payment.BillingState = &billingState
+	}
+
+	// The previous attempt stays capturable in case the mentee already
+	// paid it, but the new order is the one the booking waits on.
+	if err := s.paymentRepo.SupersedeOpenTx(ctx, tx, booking.ID); err != nil {
+		return nil, err
+	}
+
+	if err := s.paymentRepo.Create(ctx, tx, payment); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// CreatePackagePayment records a pending purchase of pkg by userID and
+// opens a gateway order for its price. The purchase's credits are issued
+// when the payment is captured.
+func (s *PaymentService) CreatePackagePayment(
+	ctx context.Context,
+	userID uuid.UUID,
+	pkg *models.MentorServicePackage,
+) (*models.PackagePurchase, *models.Payment, error) {
+
+	purchase := &models.PackagePurchase{
+		ID:           uuid.New(),
+		PackageID:    pkg.ID,
+		UserID:       userID,
+		MentorID:     pkg.MentorID,
+		ServiceID:    pkg.ServiceID,
+		SessionCount: pkg.SessionCount,
+		PriceCents:   pkg.PriceCents,
+		Currency:     pkg.Currency,
+		Status:       models.PackagePurchaseStatusPending,
+	}
+
+	gateway, err := s.gateways.ForCurrency(purchase.Currency)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.purchaseRepo.CreateTx(ctx, tx, purchase); err != nil {
+		return nil, nil, err
+	}
+
+	order, err := gateway.CreateOrder(ctx, OrderRequest{
+		AmountCents: int64(purchase.PriceCents),
+		Currency:    purchase.Currency,
+		Receipt:     purchase.ID.String(),
+		Description: "OpenCall session package",
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payment := &models.Payment{
+		ID:                uuid.New(),
+		PackagePurchaseID: &purchase.ID,
+		UserID:            userID,
+		Gateway:           gateway.Name(),
+		GatewayOrderID:    order.OrderID,
+		Amount:            int64(purchase.PriceCents),
+		Currency:          purchase.Currency,
+		Status:            models.PaymentStatusCreated,
+		ClientSecret:      order.ClientSecret,
 	}
 
 	if err := s.paymentRepo.Create(ctx, tx, payment); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return purchase, payment, nil
+}
+
+// fulfilTx delivers what a captured payment paid for: it confirms the
+// booking or issues the package purchase's credits.
+func (s *PaymentService) fulfilTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	payment *models.Payment,
+) error {
+	if payment.PackagePurchaseID != nil {
+		return s.purchaseRepo.MarkPaidTx(ctx, tx, *payment.PackagePurchaseID)
+	}
+
+	return s.bookingRepo.MarkConfirmed(ctx, tx, *payment.BookingID)
+}
+
+// applyCapture moves a payment to paid and delivers what it paid for. A
+// capture whose amount or currency differs from the payment, or from the
+// booking or purchase price, moves the payment to amount_mismatch and is
+// recorded for review instead. A capture for a booking that is no longer
+// pending (expired, cancelled or confirmed by another attempt), or for a
+// purchase that is already paid, is recorded and refunded in full.
+// Payments that are already paid, refunded or flagged are left alone. It
+// returns the payment as it now stands and the mismatch it recorded, if
+// any.
+func (s *PaymentService) applyCapture(
+	ctx context.Context,
+	paymentID uuid.UUID,
+	event *PaymentEvent,
+	signature string,
+) (*models.Payment, *models.PaymentMismatch, error) {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	payment, err := s.paymentRepo.GetForUpdateTx(ctx, tx, paymentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch payment.Status {
+	case models.PaymentStatusCreated,
+		models.PaymentStatusSuperseded,
+		models.PaymentStatusFailed:
+	default:
+		return payment, nil, nil // idempotent
+	}
+
+	priceCents, currency, payable, err := s.priceTx(ctx, tx, payment)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if event.Amount != payment.Amount ||
+		!strings.EqualFold(event.Currency, payment.Currency) ||
+		payment.Amount != priceCents ||
+		!strings.EqualFold(payment.Currency, currency) {
+
+		if err := s.paymentRepo.MarkAmountMismatchTx(
+			ctx,
+			tx,
+			payment.ID,
+			event.PaymentID,
+		); err != nil {
+			return nil, nil, err
+		}
+
+		mismatch := &models.PaymentMismatch{
+			ID:               uuid.New(),
+			PaymentID:        payment.ID,
+			Gateway:          payment.Gateway,
+			GatewayPaymentID: event.PaymentID,
+			ExpectedAmount:   priceCents,
+			ExpectedCurrency: currency,
+			ActualAmount:     event.Amount,
+			ActualCurrency:   strings.ToUpper(event.Currency),
+		}
+
+		if _, err := s.mismatchRepo.CreateTx(ctx, tx, mismatch); err != nil {
+			return nil, nil, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return nil, nil, err
+		}
+
+		payment.Status = models.PaymentStatusAmountMismatch
+		return payment, mismatch, nil
+	}
+
+	if err := s.paymentRepo.MarkPaid(
+		ctx,
+		tx,
+		payment.ID,
+		event.PaymentID,
+		signature,
+	); err != nil {
+		return nil, nil, err
+	}
+
+	if !payable {
+		payment, err = s.refundUnfulfilledTx(ctx, tx, payment)
+		return payment, nil, err
+	}
+
+	if err := s.fulfilTx(ctx, tx, payment); err != nil {
+		return nil, nil, err
+	}
+
+	// Paid bookings are invoiced in the same transaction, so invoice
+	// numbers are only taken by captures that commit.
+	if payment.BookingID != nil {
+		if err := s.invoices.IssueTx(ctx, tx, payment, event.PaymentID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	payment.Status = models.PaymentStatusPaid
+	return payment, nil, nil
+}
+
+// refundUnfulfilledTx queues a full refund of a capture that has
+// nothing left to fulfil and commits tx, which has already recorded the
+// capture. The refund is submitted right away and retried by the refund
+// job if that fails.
+func (s *PaymentService) refundUnfulfilledTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	payment *models.Payment,
+) (*models.Payment, error) {
+
+	refund, err := s.queueRefundTx(
+		ctx,
+		tx,
+		payment,
+		payment.Amount,
+		"captured after the booking or purchase stopped awaiting payment",
+		true,
+	)
+	if err != nil {
 		return nil, err
 	}
 
@@ -86,13 +380,57 @@ func (s *PaymentService) CreatePayment(
 		return nil, err
 	}
 
+	log.Warn().
+		Str("payment_id", payment.ID.String()).
+		Str("refund_id", refund.ID.String()).
+		Msg("payment captured with nothing awaiting it, refunding")
+
+	payment.Status = models.PaymentStatusRefundPending
+
+	// Failures are logged and rescheduled by SubmitRefund
+	if err := s.SubmitRefund(ctx, refund); err == nil &&
+		refund.Status == models.RefundStatusProcessed {
+		payment.Status = models.PaymentStatusRefunded
+	}
+
 	return payment, nil
 }
 
+// priceTx returns what the payment's booking or package purchase costs,
+// locking it until the capture is applied, and whether it still awaits
+// payment: a booking while it is pending, a purchase until it is paid.
+func (s *PaymentService) priceTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	payment *models.Payment,
+) (int64, string, bool, error) {
+
+	if payment.PackagePurchaseID != nil {
+		purchase, err := s.purchaseRepo.GetForUpdateTx(ctx, tx, *payment.PackagePurchaseID)
+		if err != nil {
+			return 0, "", false, err
+		}
+		payable := purchase.Status != models.PackagePurchaseStatusPaid
+		return int64(purchase.PriceCents), purchase.Currency, payable, nil
+	}
+
+	booking, err := s.bookingRepo.GetByIDForUpdateTx(ctx, tx, *payment.BookingID)
+	if err != nil {
+		return 0, "", false, err
+	}
+	payable := booking.Status == models.BookingStatusPending
+	return int64(booking.PriceCents), booking.Currency, payable, nil
+}
+
+// VerifyPayment confirms a payment the client reports as complete. The
+// gateway payment ID and signature are what Razorpay Checkout returns;
+// Stripe payments are checked with Stripe and need neither. A payment
+// that was already confirmed, e.g. by its webhook, is not an error.
 func (s *PaymentService) VerifyPayment(
 	ctx context.Context,
+	userID uuid.UUID,
 	paymentID uuid.UUID,
-	razorpayPaymentID string,
+	gatewayPaymentID string,
 	signature string,
 ) error {
 
@@ -101,87 +439,184 @@ func (s *PaymentService) VerifyPayment(
 		return err
 	}
 
-	data := payment.GatewayOrderID + "|" + razorpayPaymentID
-
-	h := hmac.New(sha256.New, []byte(s.razorpaySecret))
-	h.Write([]byte(data))
-	expected := hex.EncodeToString(h.Sum(nil))
+	if payment.UserID != userID {
+		return errors.New("unauthorized")
+	}
 
-	if expected != signature {
-		return errors.New("invalid payment signature")
+	if payment.Status == models.PaymentStatusPaid {
+		return nil // idempotent
 	}
 
-	tx, err := s.db.BeginTx(ctx, nil)
+	gateway, err := s.gateways.Get(payment.Gateway)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
 
-	if err := s.paymentRepo.MarkPaid(
+	event, err := gateway.VerifyPayment(
 		ctx,
-		tx,
-		paymentID,
-		razorpayPaymentID,
+		payment.GatewayOrderID,
+		gatewayPaymentID,
 		signature,
-	); err != nil {
+	)
+	if err != nil {
 		return err
 	}
 
-	if err := s.bookingRepo.MarkConfirmed(ctx, tx, payment.BookingID); err != nil {
+	payment, _, err = s.applyCapture(ctx, payment.ID, event, signature)
+	if err != nil {
 		return err
 	}
 
-	return tx.Commit()
+	switch payment.Status {
+	case models.PaymentStatusPaid:
+		return nil
+	case models.PaymentStatusAmountMismatch:
+		return ErrPaymentAmountMismatch
+	case models.PaymentStatusRefundPending,
+		models.PaymentStatusRefunded:
+		return ErrPaymentRefunded
+	}
+
+	return fmt.Errorf("payment is %s", payment.Status)
 }
 
-func (s *PaymentService) VerifyWebhookSignature(
+// VerifyWebhook checks a webhook's signature with the named gateway and
+// returns the ID of the secret that signed it. A bad signature returns
+// ErrInvalidWebhookSignature.
+func (s *PaymentService) VerifyWebhook(
+	gatewayName string,
 	payload []byte,
 	signature string,
-) error {
-	h := hmac.New(sha256.New, []byte(s.razorpaySecret))
-	h.Write(payload)
+) (string, error) {
+	gateway, err := s.gateways.Get(gatewayName)
+	if err != nil {
+		return "", err
+	}
+
+	return gateway.VerifyWebhook(payload, signature)
+}
+
+func (s *PaymentService) ParseWebhook(
+	gatewayName string,
+	payload []byte,
+) (*PaymentEvent, error) {
+	gateway, err := s.gateways.Get(gatewayName)
+	if err != nil {
+		return nil, err
+	}
+
+	return gateway.ParseWebhook(payload)
+}
 
-	expected := hex.EncodeToString(h.Sum(nil))
-	if expected != signature {
-		return errors.New("invalid webhook signature")
+// HandleWebhookEvent applies a parsed webhook event. Events of types we
+// don't act on are ignored.
+func (s *PaymentService) HandleWebhookEvent(event *PaymentEvent) error {
+	switch event.Type {
+	case PaymentEventCaptured:
+		return s.HandlePaymentCaptured(event)
+	case PaymentEventFailed:
+		return s.HandlePaymentFailed(event)
+	case PaymentEventAttemptFailed:
+		return s.HandlePaymentAttemptFailed(event)
+	case PaymentEventRefundProcessed:
+		return s.HandleRefundProcessed(event)
+	case PaymentEventRefundFailed:
+		return s.HandleRefundFailed(event)
 	}
+
 	return nil
 }
 
+// HandlePaymentCaptured applies a capture reported by the gateway. A
+// capture that doesn't match the price is flagged for review, which is
+// not an error: retrying the event would not change the outcome.
 func (s *PaymentService) HandlePaymentCaptured(
-	event dtos.RazorpayWebhookEvent,
+	event *PaymentEvent,
 ) error {
 
-	orderID := event.Payload.Payment.Entity.OrderID
-	paymentID := event.Payload.Payment.Entity.ID
+	ctx := context.Background()
 
-	payment, err := s.paymentRepo.GetByGatewayOrderID(context.Background(), orderID)
+	payment, err := s.paymentRepo.GetByGatewayOrderID(ctx, event.OrderID)
 	if err != nil {
 		return err
 	}
 
-	if payment.Status == "paid" {
-		return nil // idempotent
+	_, _, err = s.applyCapture(ctx, payment.ID, event, "")
+	return err
+}
+
+// HandlePaymentAttemptFailed records a declined try at paying an order
+// that can still be paid. The booking or purchase stays pending, so the
+// mentee can try again until the order is canceled or the booking
+// expires.
+func (s *PaymentService) HandlePaymentAttemptFailed(
+	event *PaymentEvent,
+) error {
+
+	ctx := context.Background()
+
+	payment, err := s.paymentRepo.GetByGatewayOrderID(ctx, event.OrderID)
+	if err != nil {
+		return err
+	}
+
+	return s.paymentRepo.RecordAttemptFailure(ctx, payment.ID, event.FailureReason)
+}
+
+// HandlePaymentFailed marks an attempt failed. Only the booking's open
+// attempt fails the booking or purchase too; a superseded attempt failing
+// leaves the newer one in place.
+func (s *PaymentService) HandlePaymentFailed(
+	event *PaymentEvent,
+) error {
+
+	ctx := context.Background()
+
+	payment, err := s.paymentRepo.GetByGatewayOrderID(ctx, event.OrderID)
+	if err != nil {
+		return err
 	}
 
-	tx, err := s.db.Begin()
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	if err := s.paymentRepo.MarkPaidByGateway(
+	payment, err = s.paymentRepo.GetForUpdateTx(ctx, tx, payment.ID)
+	if err != nil {
+		return err
+	}
+
+	// Idempotency guard
+	if payment.Status != models.PaymentStatusCreated &&
+		payment.Status != models.PaymentStatusSuperseded {
+		return nil
+	}
+
+	if err := s.paymentRepo.MarkFailedByGateway(
 		tx,
 		payment.ID,
-		paymentID,
 	); err != nil {
 		return err
 	}
 
-	if err := s.bookingRepo.MarkConfirmed(
-		context.Background(),
+	if payment.Status == models.PaymentStatusSuperseded {
+		return tx.Commit()
+	}
+
+	if payment.PackagePurchaseID != nil {
+		if err := s.purchaseRepo.MarkFailedTx(
+			ctx,
+			tx,
+			*payment.PackagePurchaseID,
+		); err != nil {
+			return err
+		}
+	} else if err := s.bookingRepo.MarkPaymentFailed(
+		ctx,
 		tx,
-		payment.BookingID,
+		*payment.BookingID,
 	); err != nil {
 		return err
 	}
@@ -189,42 +624,460 @@ func (s *PaymentService) HandlePaymentCaptured(
 	return tx.Commit()
 }
 
-func (s *PaymentService) HandlePaymentFailed(
-	event dtos.RazorpayWebhookEvent,
+// ReconcilePayment asks the gateway about an unpaid payment attempt, e.g.
+// because the mentee closed the tab before verifying and the webhook
+// never arrived, and applies the captured or failed transition it missed.
+// It returns the transition applied, empty when the order is still
+// unpaid, and the mismatch when the gateway captured a different amount
+// or currency than the price.
+func (s *PaymentService) ReconcilePayment(
+	ctx context.Context,
+	payment *models.Payment,
+) (string, *models.PaymentMismatch, error) {
+
+	gateway, err := s.gateways.Get(payment.Gateway)
+	if err != nil {
+		return "", nil, err
+	}
+
+	event, err := gateway.FetchOrder(ctx, payment.GatewayOrderID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch event.Type {
+	case PaymentEventCaptured:
+		_, mismatch, err := s.applyCapture(ctx, payment.ID, event, "")
+		return event.Type, mismatch, err
+
+	case PaymentEventFailed:
+		return event.Type, nil, s.HandlePaymentFailed(event)
+
+	case PaymentEventAttemptFailed:
+		// Only recorded: the order can still be paid, so there is no
+		// outcome to report
+		return "", nil, s.HandlePaymentAttemptFailed(event)
+	}
+
+	return "", nil, nil
+}
+
+// ListMismatches returns the newest payment mismatches found by the
+// reconciler
+func (s *PaymentService) ListMismatches(
+	ctx context.Context,
+	limit int,
+) ([]*dtos.PaymentMismatchResponse, error) {
+
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	mismatches, err := s.mismatchRepo.List(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*dtos.PaymentMismatchResponse, 0, len(mismatches))
+	for _, m := range mismatches {
+		resp = append(resp, &dtos.PaymentMismatchResponse{
+			ID:               m.ID,
+			PaymentID:        m.PaymentID,
+			Gateway:          m.Gateway,
+			GatewayPaymentID: m.GatewayPaymentID,
+			ExpectedAmount:   m.ExpectedAmount,
+			ExpectedCurrency: m.ExpectedCurrency,
+			ActualAmount:     m.ActualAmount,
+			ActualCurrency:   m.ActualCurrency,
+			DetectedAt:       m.DetectedAt,
+		})
+	}
+
+	return resp, nil
+}
+
+// QueueRefundTx records a refund of amount (in the smallest currency
+// unit) of the captured payment for a booking, in the transaction that
+// owes it. The payment moves to refund_pending until the gateway reports
+// the refund as processed or failed.
+//
+// The refund is leased to the caller, which submits it with SubmitRefund
+// once tx commits; if that never happens the refund job picks it up when
+// the lease runs out.
+func (s *PaymentService) QueueRefundTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	bookingID uuid.UUID,
+	amount int64,
+	reason string,
+) (*models.Refund, error) {
+
+	if amount <= 0 {
+		return nil, errors.New("refund amount must be positive")
+	}
+
+	payment, err := s.paymentRepo.GetPaidByBookingIDTx(ctx, tx, bookingID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("no captured payment for booking")
+		}
+		return nil, err
+	}
+
+	if payment.GatewayPaymentID == nil {
+		return nil, errors.New("payment has no gateway payment id")
+	}
+
+	if amount > payment.Amount {
+		return nil, errors.New("refund amount exceeds captured amount")
+	}
+
+	return s.queueRefundTx(ctx, tx, payment, amount, reason, false)
+}
+
+// queueRefundTx records a refund of a captured payment and moves the
+// payment to refund_pending. Unfulfilled refunds return a capture that
+// confirmed nothing.
+func (s *PaymentService) queueRefundTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	payment *models.Payment,
+	amount int64,
+	reason string,
+	unfulfilled bool,
+) (*models.Refund, error) {
+
+	leaseUntil := time.Now().Add(refundSubmitLease)
+
+	refund := &models.Refund{
+		ID:                uuid.New(),
+		PaymentID:         payment.ID,
+		BookingID:         payment.BookingID,
+		PackagePurchaseID: payment.PackagePurchaseID,
+		Gateway:           payment.Gateway,
+		Amount:            amount,
+		Currency:          payment.Currency,
+		Reason:            reason,
+		Status:            models.RefundStatusPending,
+		Unfulfilled:       unfulfilled,
+		Attempts:          1,
+		NextAttemptAt:     &leaseUntil,
+	}
+
+	if err := s.refundRepo.CreateTx(ctx, tx, refund); err != nil {
+		return nil, err
+	}
+
+	if err := s.paymentRepo.UpdateStatusTx(
+		ctx,
+		tx,
+		payment.ID,
+		models.PaymentStatusRefundPending,
+	); err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// SubmitRefund asks the gateway to issue a queued refund. A failed
+// submission is scheduled again with backoff; once it has used up its
+// attempts the refund is marked failed and the payment goes back to
+// paid, to be refunded by hand.
+func (s *PaymentService) SubmitRefund(
+	ctx context.Context,
+	refund *models.Refund,
+) error {
+
+	err := s.submitRefund(ctx, refund)
+	if err == nil {
+		return nil
+	}
+
+	if refund.Attempts >= refundMaxAttempts {
+		log.Error().
+			Err(err).
+			Str("refund_id", refund.ID.String()).
+			Str("payment_id", refund.PaymentID.String()).
+			Int("attempts", refund.Attempts).
+			Msg("refund submission failed, giving up")
+
+		if failErr := s.applyRefundFailed(ctx, refund.ID); failErr != nil {
+			return failErr
+		}
+		refund.Status = models.RefundStatusFailed
+		return err
+	}
+
+	next := time.Now().Add(refundRetryDelay(refund.Attempts))
+
+	log.Warn().
+		Err(err).
+		Str("refund_id", refund.ID.String()).
+		Str("payment_id", refund.PaymentID.String()).
+		Int("attempts", refund.Attempts).
+		Time("next_attempt_at", next).
+		Msg("refund submission failed, will retry")
+
+	if retryErr := s.refundRepo.MarkSubmitRetry(ctx, refund.ID, next, err.Error()); retryErr != nil {
+		return retryErr
+	}
+
+	return err
+}
+
+func (s *PaymentService) submitRefund(
+	ctx context.Context,
+	refund *models.Refund,
 ) error {
 
-	orderID := event.Payload.Payment.Entity.OrderID
+	payment, err := s.paymentRepo.GetByID(ctx, refund.PaymentID)
+	if err != nil {
+		return err
+	}
+
+	if payment.GatewayPaymentID == nil {
+		return errors.New("payment has no gateway payment id")
+	}
+
+	gateway, err := s.gateways.Get(refund.Gateway)
+	if err != nil {
+		return err
+	}
+
+	gatewayRefundID, status, err := gateway.Refund(ctx, RefundRequest{
+		PaymentID:   *payment.GatewayPaymentID,
+		AmountCents: refund.Amount,
+		Receipt:     refund.ID.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.refundRepo.SetGatewayRefundID(ctx, refund.ID, gatewayRefundID); err != nil {
+		return err
+	}
+	refund.GatewayRefundID = &gatewayRefundID
+
+	// Instant refunds can come back already processed; otherwise the
+	// refund.processed webhook finishes the transition.
+	switch status {
+	case models.RefundStatusProcessed:
+		if err := s.applyRefundProcessed(ctx, refund.ID); err != nil {
+			return err
+		}
+		refund.Status = models.RefundStatusProcessed
+	case models.RefundStatusFailed:
+		if err := s.applyRefundFailed(ctx, refund.ID); err != nil {
+			return err
+		}
+		refund.Status = models.RefundStatusFailed
+	}
+
+	return nil
+}
+
+// SubmitDueRefunds submits the queued refunds that are due. It is run
+// by a background job.
+func (s *PaymentService) SubmitDueRefunds(ctx context.Context) error {
+	now := time.Now()
 
-	payment, err := s.paymentRepo.GetByGatewayOrderID(
-		context.Background(),
-		orderID,
+	refunds, err := s.refundRepo.ClaimUnsubmitted(
+		ctx,
+		now,
+		now.Add(refundSubmitLease),
+		refundSubmitBatch,
 	)
 	if err != nil {
 		return err
 	}
 
+	for _, refund := range refunds {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		// Failures are logged and rescheduled by SubmitRefund
+		_ = s.SubmitRefund(ctx, refund)
+	}
+
+	return nil
+}
+
+// refundRetryDelay doubles from the base delay after each attempt, up to
+// the max delay.
+func refundRetryDelay(attempts int) time.Duration {
+	delay := refundRetryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= refundRetryMaxDelay {
+			return refundRetryMaxDelay
+		}
+	}
+	return delay
+}
+
+func (s *PaymentService) HandleRefundProcessed(
+	event *PaymentEvent,
+) error {
+
+	ctx := context.Background()
+
+	refund, err := s.findWebhookRefund(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	return s.applyRefundProcessed(ctx, refund.ID)
+}
+
+func (s *PaymentService) HandleRefundFailed(
+	event *PaymentEvent,
+) error {
+
+	ctx := context.Background()
+
+	refund, err := s.findWebhookRefund(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	return s.applyRefundFailed(ctx, refund.ID)
+}
+
+// findWebhookRefund matches a refund webhook to our refunds row, first by
+// the gateway refund ID and then by the receipt we attached, in case the
+// webhook arrives before the gateway refund ID was stored.
+func (s *PaymentService) findWebhookRefund(
+	ctx context.Context,
+	event *PaymentEvent,
+) (*models.Refund, error) {
+
+	refund, err := s.refundRepo.GetByGatewayRefundID(ctx, event.RefundID)
+	if err == nil {
+		return refund, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	refundID, parseErr := uuid.Parse(event.RefundReceipt)
+	if parseErr != nil {
+		return nil, err
+	}
+
+	refund, err = s.refundRepo.GetByID(ctx, refundID)
+	if err != nil {
+		return nil, err
+	}
+
+	if refund.GatewayRefundID == nil {
+		if err := s.refundRepo.SetGatewayRefundID(ctx, refund.ID, event.RefundID); err != nil {
+			return nil, err
+		}
+	}
+
+	return refund, nil
+}
+
+func (s *PaymentService) applyRefundProcessed(
+	ctx context.Context,
+	refundID uuid.UUID,
+) error {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	refund, err := s.refundRepo.GetForUpdateTx(ctx, tx, refundID)
+	if err != nil {
+		return err
+	}
+
 	// Idempotency guard
-	if payment.Status == "failed" || payment.Status == "paid" {
+	if refund.Status == models.RefundStatusProcessed {
 		return nil
 	}
 
-	tx, err := s.db.Begin()
+	if err := s.refundRepo.UpdateStatusTx(
+		ctx,
+		tx,
+		refund.ID,
+		models.RefundStatusProcessed,
+	); err != nil {
+		return err
+	}
+
+	if err := s.paymentRepo.UpdateStatusTx(
+		ctx,
+		tx,
+		refund.PaymentID,
+		models.PaymentStatusRefunded,
+	); err != nil {
+		return err
+	}
+
+	// The capture never confirmed anything, so there is no booking to
+	// mark refunded and no earning to reverse
+	if refund.Unfulfilled {
+		return tx.Commit()
+	}
+
+	if err := s.bookingRepo.MarkRefundedTx(
+		ctx,
+		tx,
+		*refund.BookingID,
+		int(refund.Amount),
+	); err != nil {
+		return err
+	}
+
+	if err := s.ledger.PostRefundTx(ctx, tx, refund); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *PaymentService) applyRefundFailed(
+	ctx context.Context,
+	refundID uuid.UUID,
+) error {
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	if err := s.paymentRepo.MarkFailedByGateway(
+	refund, err := s.refundRepo.GetForUpdateTx(ctx, tx, refundID)
+	if err != nil {
+		return err
+	}
+
+	// Idempotency guard
+	if refund.Status != models.RefundStatusPending {
+		return nil
+	}
+
+	if err := s.refundRepo.UpdateStatusTx(
+		ctx,
 		tx,
-		payment.ID,
+		refund.ID,
+		models.RefundStatusFailed,
 	); err != nil {
 		return err
 	}
 
-	if err := s.bookingRepo.MarkPaymentFailed(
-		context.Background(),
+	// The money is still captured, so the payment goes back to paid and
+	// can be refunded again.
+	if err := s.paymentRepo.UpdateStatusTx(
+		ctx,
 		tx,
-		payment.BookingID,
+		refund.PaymentID,
+		models.PaymentStatusPaid,
 	); err != nil {
 		return err
 	}
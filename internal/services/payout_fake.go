@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+)
+
+// FakePayoutProvider pays out instantly and in memory. It is meant for
+// local development and tests; nothing leaves the process.
+type FakePayoutProvider struct {
+	mu      sync.Mutex
+	byKey   map[string]*PayoutResult
+	payouts map[string]*PayoutResult
+}
+
+func NewFakePayoutProvider() *FakePayoutProvider {
+	return &FakePayoutProvider{
+		byKey:   map[string]*PayoutResult{},
+		payouts: map[string]*PayoutResult{},
+	}
+}
+
+func (f *FakePayoutProvider) Name() string {
+	return "fake"
+}
+
+func (f *FakePayoutProvider) CreateDestination(
+	ctx context.Context,
+	mentorID uuid.UUID,
+	method string,
+	details models.PayoutDetails,
+) (string, error) {
+	return "fake_dest_" + mentorID.String(), nil
+}
+
+func (f *FakePayoutProvider) CreatePayout(
+	ctx context.Context,
+	req PayoutRequest,
+) (*PayoutResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if res, ok := f.byKey[req.IdempotencyKey]; ok {
+		copied := *res
+		return &copied, nil
+	}
+
+	res := &PayoutResult{
+		ProviderPayoutID: "fake_pout_" + uuid.NewString(),
+		Status:           models.PayoutStatusPaid,
+	}
+	f.byKey[req.IdempotencyKey] = res
+	f.payouts[res.ProviderPayoutID] = res
+
+	copied := *res
+	return &copied, nil
+}
+
+func (f *FakePayoutProvider) GetPayout(
+	ctx context.Context,
+	providerPayoutID string,
+) (*PayoutResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	res, ok := f.payouts[providerPayoutID]
+	if !ok {
+		return nil, errors.New("payout not found")
+	}
+
+	copied := *res
+	return &copied, nil
+}
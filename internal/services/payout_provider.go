@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+)
+
+// PayoutProvider sends money to mentors. Implementations must treat the
+// idempotency key of CreatePayout as unique, so retrying a payout whose
+// outcome is unknown never pays twice.
+type PayoutProvider interface {
+	// Name identifies the provider in stored payouts and accounts
+	Name() string
+
+	// CreateDestination registers a mentor's bank account or UPI ID and
+	// returns the provider's ID for it
+	CreateDestination(
+		ctx context.Context,
+		mentorID uuid.UUID,
+		method string,
+		details models.PayoutDetails,
+	) (string, error)
+
+	CreatePayout(ctx context.Context, req PayoutRequest) (*PayoutResult, error)
+
+	GetPayout(ctx context.Context, providerPayoutID string) (*PayoutResult, error)
+}
+
+type PayoutRequest struct {
+	IdempotencyKey string
+	DestinationID  string
+	Method         string // bank_account | vpa
+	AmountCents    int64
+	Currency       string
+	Narration      string
+}
+
+// PayoutResult is the provider's view of a payout. Status is one of
+// models.PayoutStatusProcessing, PayoutStatusPaid or PayoutStatusFailed.
+type PayoutResult struct {
+	ProviderPayoutID string
+	Status           string
+	FailureReason    string
+}
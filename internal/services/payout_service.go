@@ -0,0 +1,397 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
+	appErrors "github.com/preetsinghmakkar/OpenCall/internal/errors"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+	"github.com/preetsinghmakkar/OpenCall/internal/repositories"
+	"github.com/preetsinghmakkar/OpenCall/internal/utils"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ifscPattern          = regexp.MustCompile(`^[A-Z]{4}0[A-Z0-9]{6}$`)
+	accountNumberPattern = regexp.MustCompile(`^[0-9]{9,18}$`)
+	vpaPattern           = regexp.MustCompile(`^[a-zA-Z0-9.\-_]{2,256}@[a-zA-Z]{2,64}$`)
+)
+
+// PayoutService stores mentors' payout accounts and pays out their
+// available balances in batches through a PayoutProvider.
+type PayoutService struct {
+	payoutRepo *repositories.PayoutRepository
+	mentorRepo *repositories.MentorRepository
+	ledger     *LedgerService
+
+	// provider is nil when payouts are disabled
+	provider PayoutProvider
+	// detailsKey encrypts payout account details at rest
+	detailsKey []byte
+	// minCents is the smallest available balance that is paid out
+	minCents int64
+}
+
+func NewPayoutService(
+	payoutRepo *repositories.PayoutRepository,
+	mentorRepo *repositories.MentorRepository,
+	ledger *LedgerService,
+	provider PayoutProvider,
+	detailsKey []byte,
+	minCents int64,
+) *PayoutService {
+	return &PayoutService{
+		payoutRepo: payoutRepo,
+		mentorRepo: mentorRepo,
+		ledger:     ledger,
+		provider:   provider,
+		detailsKey: detailsKey,
+		minCents:   minCents,
+	}
+}
+
+// UpsertAccount validates and stores where the caller is paid. The
+// details are encrypted before they reach the database.
+func (s *PayoutService) UpsertAccount(
+	userID uuid.UUID,
+	req *dtos.UpsertPayoutAccountRequest,
+) (*dtos.PayoutAccountResponse, *appErrors.AppError) {
+
+	if len(s.detailsKey) == 0 {
+		return nil, appErrors.PayoutsNotConfigured()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, appErrors.MentorProfileRequired()
+	}
+
+	details := models.PayoutDetails{
+		AccountHolderName: strings.TrimSpace(req.AccountHolderName),
+	}
+
+	var hint string
+
+	switch req.Method {
+	case models.PayoutMethodBankAccount:
+		details.AccountNumber = strings.TrimSpace(req.AccountNumber)
+		details.IFSC = strings.ToUpper(strings.TrimSpace(req.IFSC))
+
+		if !accountNumberPattern.MatchString(details.AccountNumber) {
+			return nil, appErrors.InvalidPayoutAccount("account_number must be 9 to 18 digits")
+		}
+		if !ifscPattern.MatchString(details.IFSC) {
+			return nil, appErrors.InvalidPayoutAccount("ifsc is not a valid IFSC code")
+		}
+
+		hint = details.IFSC + " ••••" + details.AccountNumber[len(details.AccountNumber)-4:]
+
+	case models.PayoutMethodVPA:
+		details.VPA = strings.TrimSpace(req.VPA)
+
+		if !vpaPattern.MatchString(details.VPA) {
+			return nil, appErrors.InvalidPayoutAccount("vpa is not a valid UPI ID")
+		}
+
+		at := strings.Index(details.VPA, "@")
+		hint = details.VPA[:1] + "••••" + details.VPA[at:]
+	}
+
+	plaintext, err := json.Marshal(details)
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	ciphertext, err := utils.Encrypt(s.detailsKey, plaintext)
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	account := &models.MentorPayoutAccount{
+		MentorID:          mentor.ID,
+		Method:            req.Method,
+		DetailsCiphertext: ciphertext,
+		DisplayHint:       hint,
+	}
+
+	if err := s.payoutRepo.UpsertAccount(ctx, account); err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	return &dtos.PayoutAccountResponse{
+		Method:      account.Method,
+		DisplayHint: account.DisplayHint,
+		Verified:    false,
+		UpdatedAt:   time.Now().UTC(),
+	}, nil
+}
+
+// GetAccount returns the caller's payout account in masked form.
+func (s *PayoutService) GetAccount(
+	userID uuid.UUID,
+) (*dtos.PayoutAccountResponse, *appErrors.AppError) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, appErrors.MentorProfileRequired()
+	}
+
+	account, err := s.payoutRepo.GetAccount(ctx, mentor.ID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrPayoutAccountNotFound) {
+			return nil, appErrors.PayoutAccountNotFound()
+		}
+		return nil, appErrors.InternalServerError()
+	}
+
+	return &dtos.PayoutAccountResponse{
+		Method:      account.Method,
+		DisplayHint: account.DisplayHint,
+		Verified:    account.ProviderDestinationID != nil,
+		UpdatedAt:   account.UpdatedAt.UTC(),
+	}, nil
+}
+
+// ListPayouts returns the caller's payouts, newest first.
+func (s *PayoutService) ListPayouts(
+	userID uuid.UUID,
+) ([]dtos.PayoutResponse, *appErrors.AppError) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, appErrors.MentorProfileRequired()
+	}
+
+	payouts, err := s.payoutRepo.ListByMentor(ctx, mentor.ID)
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	resp := make([]dtos.PayoutResponse, 0, len(payouts))
+	for _, p := range payouts {
+		resp = append(resp, dtos.PayoutResponse{
+			ID:            p.ID,
+			AmountCents:   p.AmountCents,
+			Currency:      p.Currency,
+			Status:        p.Status,
+			FailureReason: p.FailureReason,
+			CreatedAt:     p.CreatedAt.UTC(),
+			UpdatedAt:     p.UpdatedAt.UTC(),
+		})
+	}
+
+	return resp, nil
+}
+
+// RunBatch reconciles in-flight payouts with the provider and then pays
+// out every available balance above the threshold in a new batch. A
+// payout that fails to submit stays pending and is retried on the next
+// run under the same idempotency key.
+func (s *PayoutService) RunBatch(ctx context.Context) error {
+	if err := s.reconcile(ctx); err != nil {
+		return err
+	}
+
+	balances, err := s.payoutRepo.FindPayable(ctx, time.Now(), s.minCents)
+	if err != nil {
+		return err
+	}
+
+	if len(balances) == 0 {
+		return nil
+	}
+
+	batch := &models.PayoutBatch{ID: uuid.New()}
+	if err := s.payoutRepo.CreateBatch(ctx, batch); err != nil {
+		return err
+	}
+
+	count := 0
+
+	for _, b := range balances {
+		payout := &models.Payout{
+			ID:          uuid.New(),
+			BatchID:     batch.ID,
+			MentorID:    b.MentorID,
+			AmountCents: b.AvailableCents,
+			Currency:    b.Currency,
+			Status:      models.PayoutStatusPending,
+			Provider:    s.provider.Name(),
+		}
+
+		created := false
+		err := s.payoutRepo.WithTx(ctx, func(tx *sql.Tx) error {
+			ok, err := s.payoutRepo.CreatePayoutTx(ctx, tx, payout)
+			if err != nil || !ok {
+				return err
+			}
+			created = true
+			return s.ledger.PostPayoutTx(ctx, tx, payout)
+		})
+		if err != nil {
+			return err
+		}
+
+		// Another server got to this mentor first
+		if !created {
+			continue
+		}
+
+		if err := s.payoutRepo.AddToBatch(ctx, batch.ID, payout.AmountCents); err != nil {
+			return err
+		}
+
+		count++
+		s.submit(ctx, payout)
+	}
+
+	log.Info().Str("batch_id", batch.ID.String()).Int("payouts", count).Msg("payout batch created")
+
+	return nil
+}
+
+// reconcile resubmits payouts the provider never accepted and settles
+// the ones it has finished.
+func (s *PayoutService) reconcile(ctx context.Context) error {
+	inFlight, err := s.payoutRepo.FindInFlight(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range inFlight {
+		if p.Provider != s.provider.Name() {
+			log.Warn().Str("payout_id", p.ID.String()).Str("provider", p.Provider).Msg("payout belongs to another provider")
+			continue
+		}
+
+		if p.ProviderPayoutID == nil {
+			s.submit(ctx, p)
+			continue
+		}
+
+		res, err := s.provider.GetPayout(ctx, *p.ProviderPayoutID)
+		if err != nil {
+			log.Error().Err(err).Str("payout_id", p.ID.String()).Msg("payout status check failed")
+			continue
+		}
+
+		if err := s.settle(ctx, p, res); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// submit sends a pending payout to the provider, registering the
+// mentor's payout account with it first if needed. Failures are logged
+// and left for the next run.
+func (s *PayoutService) submit(ctx context.Context, p *models.Payout) {
+	l := log.With().Str("payout_id", p.ID.String()).Logger()
+
+	account, err := s.payoutRepo.GetAccount(ctx, p.MentorID)
+	if err != nil {
+		l.Error().Err(err).Msg("payout account unavailable")
+		return
+	}
+
+	destinationID, err := s.destination(ctx, account)
+	if err != nil {
+		l.Error().Err(err).Msg("payout destination registration failed")
+		return
+	}
+
+	res, err := s.provider.CreatePayout(ctx, PayoutRequest{
+		IdempotencyKey: p.ID.String(),
+		DestinationID:  destinationID,
+		Method:         account.Method,
+		AmountCents:    p.AmountCents,
+		Currency:       p.Currency,
+		Narration:      "OpenCall mentor payout",
+	})
+	if err != nil {
+		l.Error().Err(err).Msg("payout submission failed")
+		return
+	}
+
+	if err := s.payoutRepo.MarkSubmitted(ctx, p.ID, res.ProviderPayoutID); err != nil {
+		l.Error().Err(err).Msg("recording payout submission failed")
+		return
+	}
+
+	if err := s.settle(ctx, p, res); err != nil {
+		l.Error().Err(err).Msg("settling payout failed")
+	}
+}
+
+// destination returns the provider's ID for a payout account, creating
+// it from the decrypted details on first use.
+func (s *PayoutService) destination(
+	ctx context.Context,
+	account *models.MentorPayoutAccount,
+) (string, error) {
+
+	if account.ProviderDestinationID != nil &&
+		account.Provider != nil && *account.Provider == s.provider.Name() {
+		return *account.ProviderDestinationID, nil
+	}
+
+	plaintext, err := utils.Decrypt(s.detailsKey, account.DetailsCiphertext)
+	if err != nil {
+		return "", err
+	}
+
+	var details models.PayoutDetails
+	if err := json.Unmarshal(plaintext, &details); err != nil {
+		return "", err
+	}
+
+	destinationID, err := s.provider.CreateDestination(ctx, account.MentorID, account.Method, details)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.payoutRepo.SetAccountDestination(ctx, account.MentorID, s.provider.Name(), destinationID); err != nil {
+		return "", err
+	}
+
+	return destinationID, nil
+}
+
+// settle applies a finished provider result to a payout and the ledger.
+// Results that are still processing change nothing.
+func (s *PayoutService) settle(
+	ctx context.Context,
+	p *models.Payout,
+	res *PayoutResult,
+) error {
+
+	if res.Status != models.PayoutStatusPaid && res.Status != models.PayoutStatusFailed {
+		return nil
+	}
+
+	return s.payoutRepo.WithTx(ctx, func(tx *sql.Tx) error {
+		changed, err := s.payoutRepo.SettleTx(ctx, tx, p.ID, res.Status, res.FailureReason)
+		if err != nil || !changed {
+			return err
+		}
+
+		return s.ledger.PostPayoutSettledTx(ctx, tx, p, res.Status)
+	})
+}
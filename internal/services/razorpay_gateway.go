@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
+	"github.com/razorpay/razorpay-go"
+)
+
+// RazorpayGateway takes payments through Razorpay orders and Checkout.
+// Webhooks are signed with the webhook secret set in the dashboard, not
+// the key secret.
+type RazorpayGateway struct {
+	client         *razorpay.Client
+	keySecret      string
+	webhookSecrets []webhookSecret
+}
+
+// NewRazorpayGateway accepts webhooks signed with any of webhookSecrets,
+// so the secret can be rotated without rejecting deliveries.
+func NewRazorpayGateway(key, secret string, webhookSecrets []string) *RazorpayGateway {
+	return &RazorpayGateway{
+		client:         razorpay.NewClient(key, secret),
+		keySecret:      secret,
+		webhookSecrets: newWebhookSecrets(webhookSecrets),
+	}
+}
+
+func (r *RazorpayGateway) Name() string {
+	return "razorpay"
+}
+
+func (r *RazorpayGateway) CreateOrder(
+	ctx context.Context,
+	req OrderRequest,
+) (*GatewayOrder, error) {
+	data := map[string]interface{}{
+		"amount":   req.AmountCents,
+		"currency": req.Currency,
+		"receipt":  req.Receipt,
+	}
+
+	body, err := r.client.Order.Create(data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	orderID, ok := body["id"].(string)
+	if !ok {
+		return nil, errors.New("invalid razorpay order response")
+	}
+
+	return &GatewayOrder{OrderID: orderID}, nil
+}
+
+// VerifyPayment checks the signature Checkout returns, an HMAC of
+// "order_id|payment_id" under the key secret, then fetches the payment
+// for the amount Razorpay took. A payment that is only authorized, as
+// with manual capture, is captured first; an authorization alone can
+// still lapse and is not a payment.
+func (r *RazorpayGateway) VerifyPayment(
+	ctx context.Context,
+	orderID string,
+	paymentID string,
+	signature string,
+) (*PaymentEvent, error) {
+	if paymentID == "" || signature == "" {
+		return nil, errors.New("razorpay payment id and signature are required")
+	}
+
+	if !r.validSignature(r.keySecret, []byte(orderID+"|"+paymentID), signature) {
+		return nil, errors.New("invalid payment signature")
+	}
+
+	payment, err := r.client.Payment.Fetch(paymentID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentOrderID, _ := payment["order_id"].(string)
+	status, _ := payment["status"].(string)
+	amount, _ := payment["amount"].(float64)
+	currency, _ := payment["currency"].(string)
+
+	if paymentOrderID != orderID {
+		return nil, errors.New("payment does not belong to this order")
+	}
+
+	if status == "authorized" {
+		payment, err = r.client.Payment.Capture(
+			paymentID,
+			int(amount),
+			map[string]interface{}{"currency": currency},
+			nil,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		status, _ = payment["status"].(string)
+		amount, _ = payment["amount"].(float64)
+		currency, _ = payment["currency"].(string)
+	}
+
+	if status != "captured" {
+		return nil, fmt.Errorf("payment is %s", status)
+	}
+
+	return &PaymentEvent{
+		Type:      PaymentEventCaptured,
+		OrderID:   orderID,
+		PaymentID: paymentID,
+		Amount:    int64(amount),
+		Currency:  currency,
+	}, nil
+}
+
+// FetchOrder looks through the payment attempts on the order. The order
+// is captured when any attempt was. When every attempt failed the order
+// can still be paid, so that is reported as a failed attempt; the booking
+// reaper expires bookings whose checkout is abandoned.
+func (r *RazorpayGateway) FetchOrder(
+	ctx context.Context,
+	orderID string,
+) (*PaymentEvent, error) {
+	body, err := r.client.Order.Payments(orderID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	items, _ := body["items"].([]interface{})
+
+	out := &PaymentEvent{OrderID: orderID}
+	failed := 0
+
+	for _, item := range items {
+		payment, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("invalid razorpay order payments response")
+		}
+
+		id, _ := payment["id"].(string)
+		status, _ := payment["status"].(string)
+		amount, _ := payment["amount"].(float64)
+		currency, _ := payment["currency"].(string)
+
+		switch status {
+		case "captured":
+			out.Type = PaymentEventCaptured
+			out.PaymentID = id
+			out.Amount = int64(amount)
+			out.Currency = currency
+			return out, nil
+		case "failed":
+			failed++
+			out.PaymentID = id
+			out.FailureReason, _ = payment["error_description"].(string)
+		}
+	}
+
+	if len(items) > 0 && failed == len(items) {
+		out.Type = PaymentEventAttemptFailed
+	}
+
+	return out, nil
+}
+
+// VerifyWebhook checks the X-Razorpay-Signature header, an HMAC of the
+// payload under the webhook secret.
+func (r *RazorpayGateway) VerifyWebhook(payload []byte, signature string) (string, error) {
+	secretID := matchWebhookSecret(r.webhookSecrets, payload, signature)
+	if secretID == "" {
+		return "", ErrInvalidWebhookSignature
+	}
+	return secretID, nil
+}
+
+// ParseWebhook leaves the event ID empty: Razorpay sends it in the
+// X-Razorpay-Event-Id header, not the payload. payment.failed is sent
+// for each declined try, and the order stays open for the next one, so
+// it is a failed attempt rather than a failed order.
+func (r *RazorpayGateway) ParseWebhook(payload []byte) (*PaymentEvent, error) {
+	var event dtos.RazorpayWebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+
+	payment := event.Payload.Payment.Entity
+	refund := event.Payload.Refund.Entity
+
+	out := &PaymentEvent{Name: event.Event}
+
+	switch event.Event {
+	case "payment.captured":
+		out.Type = PaymentEventCaptured
+	case "payment.failed":
+		out.Type = PaymentEventAttemptFailed
+	case "refund.processed":
+		out.Type = PaymentEventRefundProcessed
+	case "refund.failed":
+		out.Type = PaymentEventRefundFailed
+	default:
+		return out, nil
+	}
+
+	out.OrderID = payment.OrderID
+	out.PaymentID = payment.ID
+	out.Amount = payment.Amount
+	out.Currency = payment.Currency
+	out.FailureReason = payment.ErrorDescription
+	out.RefundID = refund.ID
+	out.RefundReceipt = refund.Receipt
+
+	return out, nil
+}
+
+// Refund returns the gateway refund ID and its initial status. The
+// receipt ties the gateway refund back to our refunds row. Razorpay has
+// no idempotency keys, so a retried submission first looks for a refund
+// that already carries the receipt, in case an earlier response was lost.
+func (r *RazorpayGateway) Refund(
+	ctx context.Context,
+	req RefundRequest,
+) (string, string, error) {
+	existing, err := r.client.Payment.FetchMultipleRefund(
+		req.PaymentID,
+		map[string]interface{}{"count": 100},
+		nil,
+	)
+	if err != nil {
+		return "", "", err
+	}
+
+	items, _ := existing["items"].([]interface{})
+	for _, item := range items {
+		refund, _ := item.(map[string]interface{})
+		receipt, _ := refund["receipt"].(string)
+		refundID, _ := refund["id"].(string)
+		if receipt == req.Receipt && refundID != "" {
+			status, _ := refund["status"].(string)
+			return refundID, status, nil
+		}
+	}
+
+	data := map[string]interface{}{
+		"speed":   "normal",
+		"receipt": req.Receipt,
+		"notes": map[string]interface{}{
+			"refund_id": req.Receipt,
+		},
+	}
+
+	body, err := r.client.Payment.Refund(req.PaymentID, int(req.AmountCents), data, nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	refundID, ok := body["id"].(string)
+	if !ok {
+		return "", "", errors.New("invalid razorpay refund response")
+	}
+
+	status, _ := body["status"].(string)
+
+	return refundID, status, nil
+}
+
+func (r *RazorpayGateway) validSignature(
+	secret string,
+	data []byte,
+	signature string,
+) bool {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(data)
+
+	expected := hex.EncodeToString(h.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
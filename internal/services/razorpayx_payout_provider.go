@@ -0,0 +1,216 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+)
+
+const razorpayXBaseURL = "https://api.razorpay.com/v1"
+
+// RazorpayXPayoutProvider pays mentors from a RazorpayX business account
+// through the contacts, fund accounts and payouts APIs.
+type RazorpayXPayoutProvider struct {
+	keyID         string
+	keySecret     string
+	accountNumber string
+	http          *http.Client
+}
+
+func NewRazorpayXPayoutProvider(
+	keyID string,
+	keySecret string,
+	accountNumber string,
+) *RazorpayXPayoutProvider {
+	return &RazorpayXPayoutProvider{
+		keyID:         keyID,
+		keySecret:     keySecret,
+		accountNumber: accountNumber,
+		http:          &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (r *RazorpayXPayoutProvider) Name() string {
+	return "razorpayx"
+}
+
+// CreateDestination creates a contact for the mentor and a fund account
+// holding their bank account or UPI ID, and returns the fund account ID.
+func (r *RazorpayXPayoutProvider) CreateDestination(
+	ctx context.Context,
+	mentorID uuid.UUID,
+	method string,
+	details models.PayoutDetails,
+) (string, error) {
+
+	var contact struct {
+		ID string `json:"id"`
+	}
+	if err := r.do(ctx, http.MethodPost, "/contacts", "", map[string]interface{}{
+		"name":         details.AccountHolderName,
+		"type":         "vendor",
+		"reference_id": mentorID.String(),
+	}, &contact); err != nil {
+		return "", err
+	}
+
+	body := map[string]interface{}{
+		"contact_id": contact.ID,
+	}
+
+	switch method {
+	case models.PayoutMethodBankAccount:
+		body["account_type"] = "bank_account"
+		body["bank_account"] = map[string]interface{}{
+			"name":           details.AccountHolderName,
+			"ifsc":           details.IFSC,
+			"account_number": details.AccountNumber,
+		}
+	case models.PayoutMethodVPA:
+		body["account_type"] = "vpa"
+		body["vpa"] = map[string]interface{}{
+			"address": details.VPA,
+		}
+	default:
+		return "", fmt.Errorf("unsupported payout method %q", method)
+	}
+
+	var fundAccount struct {
+		ID string `json:"id"`
+	}
+	if err := r.do(ctx, http.MethodPost, "/fund_accounts", "", body, &fundAccount); err != nil {
+		return "", err
+	}
+
+	return fundAccount.ID, nil
+}
+
+type razorpayXPayout struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason"`
+	StatusDetails struct {
+		Description string `json:"description"`
+	} `json:"status_details"`
+}
+
+func (r *RazorpayXPayoutProvider) CreatePayout(
+	ctx context.Context,
+	req PayoutRequest,
+) (*PayoutResult, error) {
+
+	mode := "IMPS"
+	if req.Method == models.PayoutMethodVPA {
+		mode = "UPI"
+	}
+
+	var p razorpayXPayout
+	if err := r.do(ctx, http.MethodPost, "/payouts", req.IdempotencyKey, map[string]interface{}{
+		"account_number":       r.accountNumber,
+		"fund_account_id":      req.DestinationID,
+		"amount":               req.AmountCents,
+		"currency":             req.Currency,
+		"mode":                 mode,
+		"purpose":              "payout",
+		"queue_if_low_balance": true,
+		"reference_id":         req.IdempotencyKey,
+		"narration":            req.Narration,
+	}, &p); err != nil {
+		return nil, err
+	}
+
+	return p.result(), nil
+}
+
+func (r *RazorpayXPayoutProvider) GetPayout(
+	ctx context.Context,
+	providerPayoutID string,
+) (*PayoutResult, error) {
+
+	var p razorpayXPayout
+	if err := r.do(ctx, http.MethodGet, "/payouts/"+providerPayoutID, "", nil, &p); err != nil {
+		return nil, err
+	}
+
+	return p.result(), nil
+}
+
+// result maps RazorpayX payout states onto ours. A reversal after the
+// payout was processed is reported as failed.
+func (p *razorpayXPayout) result() *PayoutResult {
+	res := &PayoutResult{ProviderPayoutID: p.ID}
+
+	switch p.Status {
+	case "processed":
+		res.Status = models.PayoutStatusPaid
+	case "rejected", "cancelled", "failed", "reversed":
+		res.Status = models.PayoutStatusFailed
+		res.FailureReason = p.FailureReason
+		if res.FailureReason == "" {
+			res.FailureReason = p.StatusDetails.Description
+		}
+		if res.FailureReason == "" {
+			res.FailureReason = p.Status
+		}
+	default: // queued, pending, processing
+		res.Status = models.PayoutStatusProcessing
+	}
+
+	return res
+}
+
+func (r *RazorpayXPayoutProvider) do(
+	ctx context.Context,
+	method string,
+	path string,
+	idempotencyKey string,
+	body interface{},
+	out interface{},
+) error {
+
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, razorpayXBaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+
+	req.SetBasicAuth(r.keyID, r.keySecret)
+	req.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set("X-Payout-Idempotency", idempotencyKey)
+	}
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				Description string `json:"description"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("razorpayx %s %s: %d %s", method, path, resp.StatusCode, apiErr.Error.Description)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
+	appErrors "github.com/preetsinghmakkar/OpenCall/internal/errors"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+	"github.com/preetsinghmakkar/OpenCall/internal/repositories"
+)
+
+// PackageService manages multi-session packages: mentors define them on
+// their services and mentees buy them for session credits that later
+// bookings redeem.
+type PackageService struct {
+	packageRepo    *repositories.MentorServicePackageRepository
+	purchaseRepo   *repositories.PackagePurchaseRepository
+	serviceRepo    *repositories.MentorServiceRepository
+	mentorRepo     *repositories.MentorRepository
+	paymentService *PaymentService
+}
+
+func NewPackageService(
+	packageRepo *repositories.MentorServicePackageRepository,
+	purchaseRepo *repositories.PackagePurchaseRepository,
+	serviceRepo *repositories.MentorServiceRepository,
+	mentorRepo *repositories.MentorRepository,
+	paymentService *PaymentService,
+) *PackageService {
+	return &PackageService{
+		packageRepo:    packageRepo,
+		purchaseRepo:   purchaseRepo,
+		serviceRepo:    serviceRepo,
+		mentorRepo:     mentorRepo,
+		paymentService: paymentService,
+	}
+}
+
+func (s *PackageService) CreatePackage(
+	userID uuid.UUID,
+	req *dtos.CreateServicePackageRequest,
+) (*dtos.ServicePackageResponse, *appErrors.AppError) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, appErrors.MentorProfileRequired()
+	}
+
+	service, err := s.serviceRepo.FindForManagement(ctx, req.ServiceID)
+	if err != nil {
+		return nil, serviceRepoError(err)
+	}
+
+	if service.MentorID != mentor.ID {
+		return nil, appErrors.MentorServiceForbidden()
+	}
+
+	pkg := &models.MentorServicePackage{
+		ID:           uuid.New(),
+		MentorID:     mentor.ID,
+		ServiceID:    service.ID,
+		Title:        strings.TrimSpace(req.Title),
+		SessionCount: req.SessionCount,
+		PriceCents:   req.PriceCents,
+		Currency:     service.Currency,
+		IsActive:     true,
+	}
+
+	if err := s.packageRepo.Create(ctx, pkg); err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	resp := packageResponse(pkg, service.DurationMinutes)
+	return &resp, nil
+}
+
+// ListOwnPackages lists the caller's packages, including inactive ones.
+func (s *PackageService) ListOwnPackages(
+	userID uuid.UUID,
+) ([]dtos.ServicePackageResponse, *appErrors.AppError) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, appErrors.MentorProfileRequired()
+	}
+
+	packages, err := s.packageRepo.FindByMentorID(ctx, mentor.ID)
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	services, err := s.serviceRepo.FindByMentorID(mentor.ID)
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	return packageResponses(packages, services), nil
+}
+
+// GetPackagesByUsername lists the packages a mentor currently sells.
+func (s *PackageService) GetPackagesByUsername(
+	username string,
+) ([]dtos.ServicePackageResponse, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	packages, err := s.packageRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := s.serviceRepo.FindByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+
+	return packageResponses(packages, services), nil
+}
+
+// DeletePackage stops a package from being sold. Credits already bought
+// stay redeemable.
+func (s *PackageService) DeletePackage(
+	userID uuid.UUID,
+	packageID uuid.UUID,
+) *appErrors.AppError {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mentor, err := s.mentorRepo.FindByUserID(userID)
+	if err != nil {
+		return appErrors.MentorProfileRequired()
+	}
+
+	pkg, err := s.packageRepo.FindForManagement(ctx, packageID)
+	if err != nil {
+		return packageRepoError(err)
+	}
+
+	if pkg.MentorID != mentor.ID {
+		return appErrors.ServicePackageForbidden()
+	}
+
+	if err := s.packageRepo.SoftDelete(ctx, packageID); err != nil {
+		return packageRepoError(err)
+	}
+
+	return nil
+}
+
+// PurchasePackage starts a package purchase and returns the gateway order
+// to pay. Credits are issued once the payment is captured.
+func (s *PackageService) PurchasePackage(
+	userID uuid.UUID,
+	packageID uuid.UUID,
+) (*dtos.PackagePurchaseResponse, *appErrors.AppError) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	pkg, err := s.packageRepo.FindByID(ctx, packageID)
+	if err != nil {
+		return nil, packageRepoError(err)
+	}
+
+	mentor, err := s.mentorRepo.FindByID(pkg.MentorID)
+	if err != nil || !mentor.IsActive {
+		return nil, appErrors.ServicePackageNotFound()
+	}
+
+	if mentor.UserID == userID {
+		return nil, appErrors.OwnPackagePurchase()
+	}
+
+	purchase, payment, err := s.paymentService.CreatePackagePayment(ctx, userID, pkg)
+	if err != nil {
+		if errors.Is(err, ErrNoGatewayForCurrency) {
+			return nil, appErrors.CurrencyNotPayable()
+		}
+		return nil, appErrors.InternalServerError()
+	}
+
+	return &dtos.PackagePurchaseResponse{
+		PurchaseID:      purchase.ID,
+		PaymentID:       payment.ID,
+		Gateway:         payment.Gateway,
+		GatewayOrderID:  payment.GatewayOrderID,
+		RazorpayOrderID: payment.GatewayOrderID,
+		ClientSecret:    payment.ClientSecret,
+		Amount:          payment.Amount,
+		Currency:        payment.Currency,
+		SessionCount:    purchase.SessionCount,
+	}, nil
+}
+
+// GetMyCredits returns the caller's session credits per service.
+func (s *PackageService) GetMyCredits(
+	userID uuid.UUID,
+) ([]*dtos.SessionCreditBalance, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	balances, err := s.purchaseRepo.GetCreditBalances(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if balances == nil {
+		return []*dtos.SessionCreditBalance{}, nil
+	}
+
+	return balances, nil
+}
+
+func packageRepoError(err error) *appErrors.AppError {
+	if errors.Is(err, repositories.ErrServicePackageNotFound) {
+		return appErrors.ServicePackageNotFound()
+	}
+	return appErrors.InternalServerError()
+}
+
+func packageResponse(
+	pkg *models.MentorServicePackage,
+	durationMinutes int,
+) dtos.ServicePackageResponse {
+	return dtos.ServicePackageResponse{
+		ID:              pkg.ID,
+		ServiceID:       pkg.ServiceID,
+		Title:           pkg.Title,
+		SessionCount:    pkg.SessionCount,
+		DurationMinutes: durationMinutes,
+		PriceCents:      pkg.PriceCents,
+		Currency:        pkg.Currency,
+		IsActive:        pkg.IsActive,
+	}
+}
+
+func packageResponses(
+	packages []*models.MentorServicePackage,
+	services []*models.MentorService,
+) []dtos.ServicePackageResponse {
+
+	durations := make(map[uuid.UUID]int, len(services))
+	for _, svc := range services {
+		durations[svc.ID] = svc.DurationMinutes
+	}
+
+	resp := make([]dtos.ServicePackageResponse, 0, len(packages))
+	for _, pkg := range packages {
+		resp = append(resp, packageResponse(pkg, durations[pkg.ServiceID]))
+	}
+	return resp
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"time"
+
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+)
+
+// reservation is the part of the mentor's calendar a session takes: the
+// session itself widened by its buffers, plus the free time it needs to
+// its neighbours.
+type reservation struct {
+	from  time.Time
+	until time.Time
+	gap   time.Duration
+}
+
+// reservationFor applies a service's buffers and gap to a session.
+func reservationFor(start, end time.Time, service *models.MentorService) reservation {
+	return reservation{
+		from:  start.Add(-time.Duration(service.BufferBeforeMinutes) * time.Minute),
+		until: end.Add(time.Duration(service.BufferAfterMinutes) * time.Minute),
+		gap:   time.Duration(service.MinGapMinutes) * time.Minute,
+	}
+}
+
+// bookingReservation is the reservation recorded on an existing booking.
+func bookingReservation(b *models.Booking) reservation {
+	return reservation{
+		from:  b.BlockedFrom,
+		until: b.BlockedUntil,
+		gap:   time.Duration(b.MinGapMinutes) * time.Minute,
+	}
+}
+
+// clashes reports whether two reservations are closer than the larger of
+// their gaps. HasConflictTx applies the same rule in SQL.
+func (r reservation) clashes(other reservation) bool {
+	gap := max(r.gap, other.gap)
+	return r.from.Before(other.until.Add(gap)) && other.from.Before(r.until.Add(gap))
+}
+
+// slotStep is how far apart consecutive slot starts are.
+func slotStep(service *models.MentorService) time.Duration {
+	if service.SlotStepMinutes > 0 {
+		return time.Duration(service.SlotStepMinutes) * time.Minute
+	}
+	return time.Duration(service.DurationMinutes) * time.Minute
+}
@@ -0,0 +1,367 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
+	appErrors "github.com/preetsinghmakkar/OpenCall/internal/errors"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+	"github.com/preetsinghmakkar/OpenCall/internal/repositories"
+	"github.com/preetsinghmakkar/OpenCall/internal/utils"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	SessionRoleMentee = "mentee"
+	SessionRoleMentor = "mentor"
+)
+
+// SessionService decides who may join a booking's video session, issues
+// the Zego tokens for it and records the participants' attendance.
+type SessionService struct {
+	bookingRepo      *repositories.BookingRepository
+	mentorRepo       *repositories.MentorRepository
+	userRepo         *repositories.UserRepository
+	sessionEventRepo *repositories.SessionEventRepository
+	videoSessionRepo *repositories.VideoSessionRepository
+	zego             *ZegoCloudService
+}
+
+func NewSessionService(
+	bookingRepo *repositories.BookingRepository,
+	mentorRepo *repositories.MentorRepository,
+	userRepo *repositories.UserRepository,
+	sessionEventRepo *repositories.SessionEventRepository,
+	videoSessionRepo *repositories.VideoSessionRepository,
+	zego *ZegoCloudService,
+) *SessionService {
+	return &SessionService{
+		bookingRepo:      bookingRepo,
+		mentorRepo:       mentorRepo,
+		userRepo:         userRepo,
+		sessionEventRepo: sessionEventRepo,
+		videoSessionRepo: videoSessionRepo,
+		zego:             zego,
+	}
+}
+
+// sessionAccess is a booking as seen by one of its participants.
+type sessionAccess struct {
+	booking *models.Booking
+	role    string
+
+	// canJoin is false with a reason in message when the session cannot
+	// be joined right now
+	canJoin bool
+	message string
+}
+
+// access loads a booking for userID, who must be its mentee or mentor,
+// and works out whether the session can be joined at now.
+func (s *SessionService) access(
+	ctx context.Context,
+	userID uuid.UUID,
+	bookingID uuid.UUID,
+	now time.Time,
+) (*sessionAccess, *appErrors.AppError) {
+
+	booking, err := s.bookingRepo.GetByID(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrBookingNotFound) {
+			return nil, appErrors.BookingNotFound()
+		}
+		return nil, appErrors.InternalServerError()
+	}
+
+	mentor, err := s.mentorRepo.FindByID(booking.MentorID)
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	role, ok := sessionRole(booking, mentor, userID)
+	if !ok {
+		return nil, appErrors.BookingForbidden()
+	}
+
+	a := &sessionAccess{booking: booking, role: role}
+
+	if booking.Status != models.BookingStatusConfirmed {
+		a.message = "Booking must be confirmed to join the session"
+		return a, nil
+	}
+
+	tz := mentor.Timezone
+	if tz == "" {
+		tz = "UTC"
+	}
+
+	ok, message, err := utils.ValidateSessionTime(booking.StartTime, booking.EndTime, tz)
+	if err != nil {
+		// a mentor with a broken timezone must not lock both sides out
+		ok, message, _ = utils.ValidateSessionTime(booking.StartTime, booking.EndTime, "UTC")
+	}
+	if !ok {
+		a.message = message
+		return a, nil
+	}
+
+	// Tokens never outlive the session, so the grace period after the
+	// scheduled end is not joinable.
+	if !now.Before(booking.EndTime) {
+		a.message = "Session time has ended"
+		return a, nil
+	}
+
+	a.canJoin = true
+	return a, nil
+}
+
+// sessionRole reports whether userID takes part in booking, and as whom.
+func sessionRole(
+	booking *models.Booking,
+	mentor *models.MentorProfile,
+	userID uuid.UUID,
+) (string, bool) {
+
+	switch userID {
+	case booking.UserID:
+		return SessionRoleMentee, true
+	case mentor.UserID:
+		return SessionRoleMentor, true
+	}
+
+	return "", false
+}
+
+// GetSessionInfo describes the caller's access to a booking's session.
+func (s *SessionService) GetSessionInfo(
+	userID uuid.UUID,
+	bookingID uuid.UUID,
+) (*dtos.SessionInfoResponse, *appErrors.AppError) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	a, appErr := s.access(ctx, userID, bookingID, time.Now())
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return &dtos.SessionInfoResponse{
+		BookingID: a.booking.ID,
+		AppID:     s.zego.GetAppID(),
+		RoomID:    s.zego.RoomID(a.booking.ID),
+		Role:      a.role,
+		StartAt:   a.booking.StartTime.UTC(),
+		EndAt:     a.booking.EndTime.UTC(),
+		CanJoin:   a.canJoin,
+		Message:   a.message,
+	}, nil
+}
+
+// IssueToken signs a Zego token for the caller to join a booking's room.
+// It expires when the session ends.
+func (s *SessionService) IssueToken(
+	userID uuid.UUID,
+	bookingID uuid.UUID,
+) (*dtos.ZegoTokenResponse, *appErrors.AppError) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	a, appErr := s.access(ctx, userID, bookingID, now)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if !a.canJoin {
+		return nil, appErrors.SessionNotJoinable(a.message)
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	roomID := s.zego.RoomID(a.booking.ID)
+	expiresAt := a.booking.EndTime
+
+	// Callbacks only carry the room ID, so the room must be known before
+	// anyone can open it.
+	if err := s.videoSessionRepo.EnsureRoom(ctx, a.booking.ID, roomID); err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	token, err := s.zego.GenerateToken(userID.String(), user.Username, roomID, now, expiresAt)
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	// Attendance is best effort; a failed insert must not keep the
+	// participant out of their session.
+	if err := s.recordEvent(ctx, a.booking.ID, userID, a.role, models.SessionEventTokenIssued, models.SessionEventSourceClient, now); err != nil {
+		log.Error().Err(err).Str("booking_id", bookingID.String()).Msg("recording token issue failed")
+	}
+
+	return &dtos.ZegoTokenResponse{
+		Token:     token,
+		AppID:     s.zego.GetAppID(),
+		RoomID:    roomID,
+		UserID:    userID.String(),
+		UserName:  user.Username,
+		ExpiresAt: expiresAt.UTC(),
+	}, nil
+}
+
+// RecordEvent stores that the caller joined or left a booking's video
+// room. Joins are only accepted while the session can be joined; leaves
+// are always accepted from participants.
+func (s *SessionService) RecordEvent(
+	userID uuid.UUID,
+	bookingID uuid.UUID,
+	eventType string,
+) *appErrors.AppError {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	a, appErr := s.access(ctx, userID, bookingID, now)
+	if appErr != nil {
+		return appErr
+	}
+
+	if eventType == models.SessionEventJoined && !a.canJoin {
+		return appErrors.SessionNotJoinable(a.message)
+	}
+
+	if err := s.recordEvent(ctx, a.booking.ID, userID, a.role, eventType, models.SessionEventSourceClient, now); err != nil {
+		return appErrors.InternalServerError()
+	}
+
+	return nil
+}
+
+func (s *SessionService) recordEvent(
+	ctx context.Context,
+	bookingID uuid.UUID,
+	userID uuid.UUID,
+	role string,
+	eventType string,
+	source string,
+	at time.Time,
+) error {
+
+	return s.sessionEventRepo.Create(ctx, &models.SessionEvent{
+		ID:         uuid.New(),
+		BookingID:  bookingID,
+		UserID:     userID,
+		Role:       role,
+		EventType:  eventType,
+		OccurredAt: at,
+		Source:     source,
+	})
+}
+
+// VerifyZegoCallback checks that a callback was signed by ZEGOCLOUD for
+// our app.
+func (s *SessionService) VerifyZegoCallback(cb dtos.ZegoCallback) error {
+	if string(cb.AppID) != strconv.FormatInt(s.zego.GetAppID(), 10) {
+		return errors.New("callback for another app")
+	}
+
+	return s.zego.VerifyCallback(
+		string(cb.Timestamp),
+		string(cb.Nonce),
+		cb.Signature,
+		time.Now(),
+	)
+}
+
+// HandleZegoCallback applies a verified ZEGOCLOUD room callback to the
+// booking whose room it concerns. Rooms we did not issue tokens for and
+// events we do not track are ignored.
+func (s *SessionService) HandleZegoCallback(cb dtos.ZegoCallback) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	room, err := s.videoSessionRepo.GetByRoomID(ctx, cb.RoomID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrVideoSessionNotFound) {
+			log.Warn().Str("room_id", cb.RoomID).Str("event", cb.Event).Msg("callback for unknown room")
+			return nil
+		}
+		return err
+	}
+
+	switch cb.Event {
+	case "room_create":
+		return s.videoSessionRepo.MarkOpened(ctx, room.BookingID, callbackTime(cb.CreateTime, cb.Timestamp))
+
+	case "room_close":
+		return s.videoSessionRepo.MarkClosed(ctx, room.BookingID, callbackTime(cb.CloseTime, cb.Timestamp))
+
+	case "room_login":
+		return s.recordCallbackEvent(ctx, room.BookingID, cb.UserAccount, models.SessionEventJoined, callbackTime(cb.LoginTime, cb.Timestamp))
+
+	case "room_logout":
+		return s.recordCallbackEvent(ctx, room.BookingID, cb.UserAccount, models.SessionEventLeft, callbackTime(cb.LogoutTime, cb.Timestamp))
+	}
+
+	return nil
+}
+
+// recordCallbackEvent stores a login or logout reported by ZEGOCLOUD.
+// Accounts that are not participants of the booking are ignored.
+func (s *SessionService) recordCallbackEvent(
+	ctx context.Context,
+	bookingID uuid.UUID,
+	userAccount string,
+	eventType string,
+	at time.Time,
+) error {
+
+	userID, err := uuid.Parse(userAccount)
+	if err != nil {
+		return nil
+	}
+
+	booking, err := s.bookingRepo.GetByID(ctx, bookingID)
+	if err != nil {
+		return err
+	}
+
+	mentor, err := s.mentorRepo.FindByID(booking.MentorID)
+	if err != nil {
+		return err
+	}
+
+	role, ok := sessionRole(booking, mentor, userID)
+	if !ok {
+		log.Warn().Str("booking_id", bookingID.String()).Msg("callback for non-participant")
+		return nil
+	}
+
+	return s.recordEvent(ctx, bookingID, userID, role, eventType, models.SessionEventSourceZego, at)
+}
+
+// callbackTime returns the event time of a callback from its
+// millisecond field, falling back to the callback's own timestamp.
+func callbackTime(millis dtos.ZegoValue, timestamp dtos.ZegoValue) time.Time {
+	if ms, err := strconv.ParseInt(string(millis), 10, 64); err == nil && ms > 0 {
+		return time.UnixMilli(ms)
+	}
+
+	if sec, err := strconv.ParseInt(string(timestamp), 10, 64); err == nil && sec > 0 {
+		return time.Unix(sec, 0)
+	}
+
+	return time.Now()
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+)
+
+const (
+	stripeBaseURL = "https://api.stripe.com/v1"
+
+	// stripeWebhookTolerance is how old a signed webhook may be, as in
+	// Stripe's own libraries
+	stripeWebhookTolerance = 5 * time.Minute
+)
+
+// StripeGateway takes payments through Stripe PaymentIntents confirmed in
+// the browser with the intent's client secret. The PaymentIntent ID is
+// both our gateway order ID and gateway payment ID.
+type StripeGateway struct {
+	secretKey      string
+	webhookSecrets []webhookSecret
+	http           *http.Client
+}
+
+// NewStripeGateway accepts webhooks signed with any of webhookSecrets, so
+// the endpoint secret can be rolled without rejecting deliveries.
+func NewStripeGateway(secretKey string, webhookSecrets []string) *StripeGateway {
+	return &StripeGateway{
+		secretKey:      secretKey,
+		webhookSecrets: newWebhookSecrets(webhookSecrets),
+		http:           &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (s *StripeGateway) Name() string {
+	return "stripe"
+}
+
+type stripePaymentIntent struct {
+	ID             string `json:"id"`
+	ClientSecret   string `json:"client_secret"`
+	Status         string `json:"status"`
+	Amount         int64  `json:"amount"`
+	AmountReceived int64  `json:"amount_received"`
+	Currency       string `json:"currency"`
+
+	LastPaymentError *struct {
+		Message string `json:"message"`
+	} `json:"last_payment_error"`
+}
+
+type stripeRefund struct {
+	ID            string            `json:"id"`
+	Status        string            `json:"status"`
+	PaymentIntent string            `json:"payment_intent"`
+	Amount        int64             `json:"amount"`
+	Currency      string            `json:"currency"`
+	Metadata      map[string]string `json:"metadata"`
+}
+
+func (s *StripeGateway) CreateOrder(
+	ctx context.Context,
+	req OrderRequest,
+) (*GatewayOrder, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(req.AmountCents, 10))
+	form.Set("currency", strings.ToLower(req.Currency))
+	form.Set("automatic_payment_methods[enabled]", "true")
+	form.Set("metadata[receipt]", req.Receipt)
+	if req.Description != "" {
+		form.Set("description", req.Description)
+	}
+
+	var intent stripePaymentIntent
+	if err := s.do(ctx, http.MethodPost, "/payment_intents", "", form, &intent); err != nil {
+		return nil, err
+	}
+
+	return &GatewayOrder{
+		OrderID:      intent.ID,
+		ClientSecret: intent.ClientSecret,
+	}, nil
+}
+
+// VerifyPayment asks Stripe whether the PaymentIntent succeeded. The
+// browser only reports that confirmation finished, so there is no client
+// signature to check.
+func (s *StripeGateway) VerifyPayment(
+	ctx context.Context,
+	orderID string,
+	paymentID string,
+	signature string,
+) (*PaymentEvent, error) {
+	var intent stripePaymentIntent
+	if err := s.do(ctx, http.MethodGet, "/payment_intents/"+orderID, "", nil, &intent); err != nil {
+		return nil, err
+	}
+
+	if intent.Status != "succeeded" {
+		return nil, fmt.Errorf("payment is %s", intent.Status)
+	}
+
+	return &PaymentEvent{
+		Type:      PaymentEventCaptured,
+		OrderID:   intent.ID,
+		PaymentID: intent.ID,
+		Amount:    intent.AmountReceived,
+		Currency:  strings.ToUpper(intent.Currency),
+	}, nil
+}
+
+// FetchOrder treats a canceled PaymentIntent, or one whose last attempt
+// was declined, as failed. Stripe would let the mentee retry the latter,
+// but the reconciler only looks at checkouts that were abandoned.
+func (s *StripeGateway) FetchOrder(
+	ctx context.Context,
+	orderID string,
+) (*PaymentEvent, error) {
+	var intent stripePaymentIntent
+	if err := s.do(ctx, http.MethodGet, "/payment_intents/"+orderID, "", nil, &intent); err != nil {
+		return nil, err
+	}
+
+	out := &PaymentEvent{
+		OrderID:   intent.ID,
+		PaymentID: intent.ID,
+		Amount:    intent.AmountReceived,
+		Currency:  strings.ToUpper(intent.Currency),
+	}
+
+	// An intent that needs a new payment method after a decline can
+	// still be paid, so only a canceled one has failed
+	switch intent.Status {
+	case "succeeded":
+		out.Type = PaymentEventCaptured
+	case "canceled":
+		out.Type = PaymentEventFailed
+	}
+
+	return out, nil
+}
+
+// VerifyWebhook checks the Stripe-Signature header: an HMAC-SHA256 of
+// "timestamp.payload" under the endpoint secret, in one or more v1
+// entries.
+func (s *StripeGateway) VerifyWebhook(payload []byte, signature string) (string, error) {
+	secretID := s.matchSignature(payload, signature, time.Now())
+	if secretID == "" {
+		return "", ErrInvalidWebhookSignature
+	}
+	return secretID, nil
+}
+
+func (s *StripeGateway) ParseWebhook(payload []byte) (*PaymentEvent, error) {
+	var event struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Data struct {
+			Object json.RawMessage `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+
+	out := &PaymentEvent{ID: event.ID, Name: event.Type}
+
+	switch event.Type {
+	case "payment_intent.succeeded",
+		"payment_intent.payment_failed",
+		"payment_intent.canceled":
+		var intent stripePaymentIntent
+		if err := json.Unmarshal(event.Data.Object, &intent); err != nil {
+			return nil, err
+		}
+
+		// A failed payment leaves the intent open for another try; only
+		// a canceled intent can no longer be paid
+		switch event.Type {
+		case "payment_intent.succeeded":
+			out.Type = PaymentEventCaptured
+		case "payment_intent.payment_failed":
+			out.Type = PaymentEventAttemptFailed
+			if intent.LastPaymentError != nil {
+				out.FailureReason = intent.LastPaymentError.Message
+			}
+		case "payment_intent.canceled":
+			out.Type = PaymentEventFailed
+		}
+		out.OrderID = intent.ID
+		out.PaymentID = intent.ID
+		out.Amount = intent.AmountReceived
+		out.Currency = strings.ToUpper(intent.Currency)
+
+	case "refund.created", "refund.updated", "refund.failed", "charge.refund.updated":
+		var refund stripeRefund
+		if err := json.Unmarshal(event.Data.Object, &refund); err != nil {
+			return nil, err
+		}
+
+		switch refundStatus(refund.Status) {
+		case models.RefundStatusProcessed:
+			out.Type = PaymentEventRefundProcessed
+		case models.RefundStatusFailed:
+			out.Type = PaymentEventRefundFailed
+		default:
+			return out, nil
+		}
+		out.OrderID = refund.PaymentIntent
+		out.PaymentID = refund.PaymentIntent
+		out.Amount = refund.Amount
+		out.Currency = strings.ToUpper(refund.Currency)
+		out.RefundID = refund.ID
+		out.RefundReceipt = refund.Metadata["refund_id"]
+	}
+
+	return out, nil
+}
+
+func (s *StripeGateway) Refund(
+	ctx context.Context,
+	req RefundRequest,
+) (string, string, error) {
+	form := url.Values{}
+	form.Set("payment_intent", req.PaymentID)
+	form.Set("amount", strconv.FormatInt(req.AmountCents, 10))
+	form.Set("metadata[refund_id]", req.Receipt)
+
+	var refund stripeRefund
+	if err := s.do(ctx, http.MethodPost, "/refunds", "refund:"+req.Receipt, form, &refund); err != nil {
+		return "", "", err
+	}
+
+	return refund.ID, refundStatus(refund.Status), nil
+}
+
+// refundStatus maps Stripe refund states onto ours
+func refundStatus(status string) string {
+	switch status {
+	case "succeeded":
+		return models.RefundStatusProcessed
+	case "failed", "canceled":
+		return models.RefundStatusFailed
+	default: // pending, requires_action
+		return models.RefundStatusPending
+	}
+}
+
+// matchSignature returns the ID of the webhook secret the header was
+// signed with, or "" when the signature is invalid or too old.
+func (s *StripeGateway) matchSignature(
+	payload []byte,
+	header string,
+	now time.Time,
+) string {
+	var timestamp string
+	var signatures []string
+
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ""
+	}
+
+	age := now.Sub(time.Unix(ts, 0))
+	if age > stripeWebhookTolerance || age < -stripeWebhookTolerance {
+		return ""
+	}
+
+	signed := append([]byte(timestamp+"."), payload...)
+	return matchWebhookSecret(s.webhookSecrets, signed, signatures...)
+}
+
+func (s *StripeGateway) do(
+	ctx context.Context,
+	method string,
+	path string,
+	idempotencyKey string,
+	form url.Values,
+	out interface{},
+) error {
+
+	var body *strings.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	} else {
+		body = strings.NewReader("")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, stripeBaseURL+path, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+s.secretKey)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Error.Message == "" {
+			return fmt.Errorf("stripe %s %s: %d", method, path, resp.StatusCode)
+		}
+		return errors.New("stripe: " + apiErr.Error.Message)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
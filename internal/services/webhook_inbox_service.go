@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/preetsinghmakkar/OpenCall/internal/dtos"
+	appErrors "github.com/preetsinghmakkar/OpenCall/internal/errors"
+	"github.com/preetsinghmakkar/OpenCall/internal/models"
+	"github.com/preetsinghmakkar/OpenCall/internal/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// webhookBatchSize is how many due events one processing run claims
+	webhookBatchSize = 50
+	// webhookProcessingTimeout after which a claimed event whose worker
+	// died is claimed again
+	webhookProcessingTimeout = 5 * time.Minute
+
+	webhookRetryBaseDelay = 30 * time.Second
+	webhookRetryMaxDelay  = time.Hour
+)
+
+// WebhookInboxService stores payment gateway webhooks as they arrive and
+// applies them to payments in the background, retrying with backoff.
+// Gateways only see a failure when an event could not be stored.
+type WebhookInboxService struct {
+	repo     *repositories.WebhookEventRepository
+	payments *PaymentService
+	// maxAttempts before an event is marked failed and left for replay
+	maxAttempts int
+}
+
+func NewWebhookInboxService(
+	repo *repositories.WebhookEventRepository,
+	payments *PaymentService,
+	maxAttempts int,
+) *WebhookInboxService {
+	return &WebhookInboxService{
+		repo:        repo,
+		payments:    payments,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Receive verifies and stores a webhook. Events with a bad signature are
+// logged, not stored, since anyone can post them, and
+// ErrInvalidWebhookSignature is returned; a gateway that is not
+// configured returns ErrUnknownPaymentGateway. A
+// redelivery of an event already stored is accepted and dropped. eventID
+// is the gateway's event ID from the request headers, if it sends one.
+func (s *WebhookInboxService) Receive(
+	gateway string,
+	eventID string,
+	payload []byte,
+	signature string,
+) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	e := &models.WebhookEvent{
+		ID:             uuid.New(),
+		Gateway:        gateway,
+		Payload:        string(payload),
+		Signature:      signature,
+		SignatureValid: true,
+		Status:         models.WebhookEventStatusPending,
+		NextAttemptAt:  &now,
+	}
+
+	secretID, err := s.payments.VerifyWebhook(gateway, payload, signature)
+	if errors.Is(err, ErrInvalidWebhookSignature) {
+		log.Warn().
+			Str("gateway", gateway).
+			Str("event_id", eventID).
+			Int("payload_bytes", len(payload)).
+			Msg("webhook with invalid signature rejected")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	e.SecretID = &secretID
+
+	event, parseErr := s.payments.ParseWebhook(gateway, payload)
+	if parseErr == nil {
+		e.EventType = event.Name
+		if eventID == "" {
+			eventID = event.ID
+		}
+	} else {
+		// signed but unreadable; retrying won't help
+		msg := parseErr.Error()
+		e.Status = models.WebhookEventStatusFailed
+		e.NextAttemptAt = nil
+		e.LastError = &msg
+	}
+
+	if eventID == "" {
+		sum := sha256.Sum256(payload)
+		eventID = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	e.EventID = eventID
+
+	created, err := s.repo.Create(ctx, e)
+	if err != nil {
+		return err
+	}
+
+	if !created {
+		log.Info().
+			Str("gateway", gateway).
+			Str("event_id", eventID).
+			Msg("duplicate webhook delivery ignored")
+	}
+
+	return nil
+}
+
+// ProcessDue applies the events that are due. It is run by a background
+// job.
+func (s *WebhookInboxService) ProcessDue(ctx context.Context) error {
+	now := time.Now()
+
+	events, err := s.repo.ClaimDue(
+		ctx,
+		now,
+		now.Add(-webhookProcessingTimeout),
+		webhookBatchSize,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err := s.process(e); err != nil {
+			if markErr := s.markError(ctx, e, err); markErr != nil {
+				return markErr
+			}
+			continue
+		}
+
+		if err := s.repo.MarkProcessed(ctx, e.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *WebhookInboxService) process(e *models.WebhookEvent) error {
+	event, err := s.payments.ParseWebhook(e.Gateway, []byte(e.Payload))
+	if err != nil {
+		return err
+	}
+
+	return s.payments.HandleWebhookEvent(event)
+}
+
+// markError schedules a retry of a failed attempt, or marks the event
+// failed once it has used up its attempts.
+func (s *WebhookInboxService) markError(
+	ctx context.Context,
+	e *models.WebhookEvent,
+	cause error,
+) error {
+
+	l := log.Warn().
+		Err(cause).
+		Str("webhook_event", e.ID.String()).
+		Str("gateway", e.Gateway).
+		Str("event_type", e.EventType).
+		Int("attempts", e.Attempts)
+
+	if e.Attempts >= s.maxAttempts {
+		l.Msg("webhook event failed, giving up until replayed")
+		return s.repo.MarkFailed(ctx, e.ID, cause.Error())
+	}
+
+	next := time.Now().Add(webhookRetryDelay(e.Attempts))
+	l.Time("next_attempt_at", next).Msg("webhook event failed, will retry")
+
+	return s.repo.MarkRetry(ctx, e.ID, next, cause.Error())
+}
+
+// webhookRetryDelay doubles from the base delay after each attempt, up to
+// the max delay.
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := webhookRetryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= webhookRetryMaxDelay {
+			return webhookRetryMaxDelay
+		}
+	}
+	return delay
+}
+
+// ListEvents returns the newest events, optionally filtered by status and
+// gateway
+func (s *WebhookInboxService) ListEvents(
+	status string,
+	gateway string,
+	limit int,
+) ([]*dtos.WebhookEventResponse, *appErrors.AppError) {
+
+	switch status {
+	case "",
+		models.WebhookEventStatusPending,
+		models.WebhookEventStatusProcessing,
+		models.WebhookEventStatusProcessed,
+		models.WebhookEventStatusFailed,
+		models.WebhookEventStatusRejected:
+	default:
+		return nil, appErrors.InvalidWebhookEventFilter()
+	}
+
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events, err := s.repo.List(ctx, status, gateway, limit)
+	if err != nil {
+		return nil, appErrors.InternalServerError()
+	}
+
+	resp := make([]*dtos.WebhookEventResponse, 0, len(events))
+	for _, e := range events {
+		resp = append(resp, webhookEventResponse(e))
+	}
+
+	return resp, nil
+}
+
+func (s *WebhookInboxService) GetEvent(
+	id uuid.UUID,
+) (*dtos.WebhookEventDetail, *appErrors.AppError) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	e, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, webhookRepoError(err)
+	}
+
+	return &dtos.WebhookEventDetail{
+		WebhookEventResponse: *webhookEventResponse(e),
+		Payload:              e.Payload,
+		Signature:            e.Signature,
+	}, nil
+}
+
+// ReplayEvent queues a failed or processed event to be applied again.
+// Handlers are idempotent, so replaying a processed event is harmless.
+func (s *WebhookInboxService) ReplayEvent(
+	id uuid.UUID,
+) (*dtos.WebhookEventResponse, *appErrors.AppError) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	e, err := s.repo.Replay(ctx, id)
+	if err != nil {
+		return nil, webhookRepoError(err)
+	}
+
+	return webhookEventResponse(e), nil
+}
+
+func webhookRepoError(err error) *appErrors.AppError {
+	switch {
+	case errors.Is(err, repositories.ErrWebhookEventNotFound):
+		return appErrors.WebhookEventNotFound()
+	case errors.Is(err, repositories.ErrWebhookEventNotReplayable):
+		return appErrors.WebhookEventNotReplayable()
+	default:
+		return appErrors.InternalServerError()
+	}
+}
+
+func webhookEventResponse(e *models.WebhookEvent) *dtos.WebhookEventResponse {
+	return &dtos.WebhookEventResponse{
+		ID:             e.ID,
+		Gateway:        e.Gateway,
+		EventID:        e.EventID,
+		EventType:      e.EventType,
+		SignatureValid: e.SignatureValid,
+		SecretID:       e.SecretID,
+		Status:         e.Status,
+		Attempts:       e.Attempts,
+		NextAttemptAt:  e.NextAttemptAt,
+		LastError:      e.LastError,
+		ReceivedAt:     e.ReceivedAt,
+		ProcessedAt:    e.ProcessedAt,
+	}
+}
@@ -1,10 +1,20 @@
 package services
 
 import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // ZegoCloudService handles Zego Cloud integration for video sessions
@@ -32,37 +42,31 @@ type ZegoClaims struct {
 	jwt.RegisteredClaims
 }
 
-// GenerateToken generates a Zego Cloud token for a user to join a room
-// Parameters:
-// - userID: Unique identifier for the user
-// - userName: Display name for the user
-// - roomID: Unique identifier for the room/session
-// - expirationSeconds: Token expiration time in seconds (default 3600 = 1 hour)
+// GenerateToken generates a Zego Cloud token for a user to join a room.
+// The token is valid from issuedAt until expiresAt.
 func (zc *ZegoCloudService) GenerateToken(
 	userID string,
 	userName string,
 	roomID string,
-	expirationSeconds int64,
+	issuedAt time.Time,
+	expiresAt time.Time,
 ) (string, error) {
 	fmt.Println("[ZegoService] GenerateToken called for user:", userID, "room:", roomID)
 
-	if expirationSeconds <= 0 {
-		expirationSeconds = 3600 // Default 1 hour
+	if !expiresAt.After(issuedAt) {
+		return "", errors.New("token would already be expired")
 	}
 
-	now := time.Now()
-	expirationTime := now.Add(time.Duration(expirationSeconds) * time.Second)
-
 	claims := ZegoClaims{
 		AppID:    zc.appID,
 		UserID:   userID,
 		UserName: userName,
 		RoomID:   roomID,
 		Type:     "token",
-		Nonce:    now.UnixNano(),
+		Nonce:    issuedAt.UnixNano(),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
 		},
 	}
 
@@ -80,6 +84,50 @@ func (zc *ZegoCloudService) GenerateToken(
 	return tokenString, nil
 }
 
+// RoomID derives the video room of a booking. It is keyed with the
+// server secret so room names cannot be guessed from booking IDs.
+func (zc *ZegoCloudService) RoomID(bookingID uuid.UUID) string {
+	mac := hmac.New(sha256.New, []byte(zc.serverSecret))
+	mac.Write(bookingID[:])
+	return "session_" + hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// callbackMaxAge bounds how old a signed callback may be, so captured
+// callbacks cannot be replayed later
+const callbackMaxAge = 10 * time.Minute
+
+// VerifyCallback checks the signature of a ZEGOCLOUD server callback:
+// the hex SHA-1 of the server secret, timestamp and nonce, sorted and
+// concatenated.
+func (zc *ZegoCloudService) VerifyCallback(
+	timestamp string,
+	nonce string,
+	signature string,
+	now time.Time,
+) error {
+	parts := []string{zc.serverSecret, timestamp, nonce}
+	sort.Strings(parts)
+
+	sum := sha1.Sum([]byte(strings.Join(parts, "")))
+	expected := hex.EncodeToString(sum[:])
+
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(signature))) != 1 {
+		return errors.New("invalid callback signature")
+	}
+
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid callback timestamp")
+	}
+
+	age := now.Sub(time.Unix(sec, 0))
+	if age > callbackMaxAge || age < -callbackMaxAge {
+		return errors.New("callback timestamp out of range")
+	}
+
+	return nil
+}
+
 // GetAppID returns the app ID
 func (zc *ZegoCloudService) GetAppID() int64 {
 	return zc.appID
@@ -3,6 +3,8 @@ package utils
 import (
 	"errors"
 	"time"
+
+	"github.com/preetsinghmakkar/OpenCall/internal/constants"
 )
 
 // ValidateSessionTime checks if current time is within (or just around)
@@ -52,12 +54,44 @@ func FormatTimeInTimezone(t time.Time, timezone string) (string, error) {
 	return t.In(loc).Format("15:04 MST"), nil
 }
 
-// GetMaxSessionDuration returns the duration in seconds (30 minutes)
+// GetMaxSessionDuration returns the longest bookable session in seconds
 func GetMaxSessionDuration() int {
-	return 30 * 60 // 1800 seconds
+	return constants.MaxSessionDurationMinutes * 60
 }
 
 // CalculateDuration calculates duration between two times in seconds
 func CalculateDuration(start, end time.Time) int {
 	return int(end.Sub(start).Seconds())
 }
+
+// LoadLocation resolves an IANA timezone name. An empty name falls back
+// to UTC so legacy rows without a timezone keep working.
+func LoadLocation(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, errors.New("invalid timezone")
+	}
+
+	return loc, nil
+}
+
+// ParseLocalDate parses a YYYY-MM-DD calendar date as midnight in loc.
+func ParseLocalDate(date string, loc *time.Location) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", date, loc)
+}
+
+// AtWallClock returns the instant at which the wall clock in loc shows
+// the hour and minute of clock on the calendar day of day. Wall-clock
+// times skipped by a DST transition are normalized forward by Go.
+func AtWallClock(day time.Time, clock time.Time, loc *time.Location) time.Time {
+	d := day.In(loc)
+	return time.Date(
+		d.Year(), d.Month(), d.Day(),
+		clock.Hour(), clock.Minute(), 0, 0,
+		loc,
+	)
+}
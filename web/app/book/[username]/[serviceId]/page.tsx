@@ -7,6 +7,8 @@ import { Navigation } from "@/components/layout/Navigation"
 import { Button } from "@/components/ui/button"
 import { DatePicker } from "@/components/ui/date-picker"
 import { Field, FieldDescription, FieldError, FieldGroup, FieldLabel } from "@/components/ui/field"
+import { Input } from "@/components/ui/input"
+import { Select } from "@/components/ui/select"
 import { mentorApi } from "@/lib/api/mentor"
 import { servicesApi } from "@/lib/api/services"
 import { availabilityApi } from "@/lib/api/availability"
@@ -15,6 +17,7 @@ import type { MentorProfileResponse } from "@/lib/api/mentor"
 import type { MentorServiceResponse } from "@/lib/api/services"
 import type { AvailableSlot } from "@/lib/api/availability"
 import { formatPrice } from "@/lib/currencies"
+import { gstStates } from "@/lib/gstStates"
 import { toast } from "sonner"
 import { useAuthStore } from "@/stores/auth.store"
 
@@ -33,6 +36,8 @@ function BookingFlowContent() {
   const [availableSlots, setAvailableSlots] = useState<AvailableSlot[]>([])
   const [selectedSlot, setSelectedSlot] = useState<AvailableSlot | null>(null)
   const [loadingSlots, setLoadingSlots] = useState(false)
+  const [couponCode, setCouponCode] = useState("")
+  const [billingState, setBillingState] = useState("")
   const [error, setError] = useState("")
 
   // Load mentor and service info
@@ -133,11 +138,24 @@ function BookingFlowContent() {
         service_id: service.id,
         booking_date: selectedDate,
         start_time: selectedSlot.start,
+        coupon_code: couponCode.trim() || undefined,
       })
 
+      if (booking.discount_cents) {
+        toast.success(`Coupon applied: ${formatPrice(booking.discount_cents, booking.currency)} off`)
+      }
+
+      // Free sessions and package credits confirm the booking right away
+      if (booking.status === "confirmed") {
+        toast.success("Booking confirmed!")
+        router.push("/dashboard")
+        return
+      }
+
       toast.success("Booking created! Redirecting to payment...")
       // Store booking ID in session storage for payment processing
       sessionStorage.setItem("pendingPaymentBookingId", booking.id)
+      sessionStorage.setItem("pendingPaymentBillingState", billingState)
       router.push("/bookings/payment")
     } catch (err: any) {
       const errorMessage = err?.message || "Failed to create booking"
@@ -270,6 +288,45 @@ function BookingFlowContent() {
               </Field>
             )}
 
+            {/* Coupon Code */}
+            {selectedDate && selectedSlot && service.price_cents > 0 && (
+              <Field>
+                <FieldLabel className="text-gray-800">Coupon Code</FieldLabel>
+                <Input
+                  value={couponCode}
+                  onChange={(e) => setCouponCode(e.target.value.toUpperCase())}
+                  placeholder="Optional"
+                  maxLength={32}
+                  className="border-gray-300"
+                />
+                <FieldDescription>
+                  The discount is applied when you confirm the booking.
+                </FieldDescription>
+              </Field>
+            )}
+
+            {/* Billing State, for GST on INR invoices */}
+            {selectedDate && selectedSlot && service.price_cents > 0 && service.currency === "INR" && (
+              <Field>
+                <FieldLabel className="text-gray-800">Billing State</FieldLabel>
+                <Select
+                  value={billingState}
+                  onChange={(e) => setBillingState(e.target.value)}
+                  className="border-gray-300"
+                >
+                  <option value="">Select your state (optional)</option>
+                  {gstStates.map((state) => (
+                    <option key={state.code} value={state.code}>
+                      {state.name}
+                    </option>
+                  ))}
+                </Select>
+                <FieldDescription>
+                  Used for the GST on your invoice.
+                </FieldDescription>
+              </Field>
+            )}
+
             {/* Booking Summary */}
             {selectedDate && selectedSlot && (
               <div className="bg-orange-50 border border-orange-200 rounded-lg p-4">
@@ -1,6 +1,6 @@
 "use client"
 
-import { useState, useEffect } from "react"
+import { useState, useEffect, useRef } from "react"
 import { useRouter } from "next/navigation"
 import { ProtectedRoute } from "@/components/auth/ProtectedRoute"
 import { Navigation } from "@/components/layout/Navigation"
@@ -13,9 +13,15 @@ import { toast } from "sonner"
 declare global {
   interface Window {
     Razorpay: any
+    Stripe: any
   }
 }
 
+const GATEWAY_SCRIPTS = {
+  razorpay: "https://checkout.razorpay.com/v1/checkout.js",
+  stripe: "https://js.stripe.com/v3",
+}
+
 function PaymentFlowContent() {
   const router = useRouter()
   const [loading, setLoading] = useState(true)
@@ -23,6 +29,7 @@ function PaymentFlowContent() {
   const [booking, setBooking] = useState<MyBookingResponse | null>(null)
   const [paymentData, setPaymentData] = useState<CreatePaymentResponse | null>(null)
   const [error, setError] = useState("")
+  const stripeRef = useRef<{ stripe: any; elements: any } | null>(null)
 
   useEffect(() => {
     const initializePayment = async () => {
@@ -49,14 +56,18 @@ function PaymentFlowContent() {
         // Create payment order
         const paymentResponse = await paymentsApi.createPayment({
           booking_id: bookingId,
+          billing_state: sessionStorage.getItem("pendingPaymentBillingState") || undefined,
         })
 
         setPaymentData(paymentResponse)
 
-        // Load Razorpay script
+        // Load the checkout script of the gateway chosen for this currency
         const script = document.createElement("script")
-        script.src = "https://checkout.razorpay.com/v1/checkout.js"
+        script.src = GATEWAY_SCRIPTS[paymentResponse.gateway]
         script.async = true
+        if (paymentResponse.gateway === "stripe") {
+          script.onload = () => mountStripe(paymentResponse)
+        }
         document.body.appendChild(script)
       } catch (err: any) {
         const errorMessage = err?.message || "Failed to initialize payment"
@@ -77,12 +88,65 @@ function PaymentFlowContent() {
     }
   }, [])
 
+  const mountStripe = (payment: CreatePaymentResponse) => {
+    const stripe = window.Stripe(process.env.NEXT_PUBLIC_STRIPE_PUBLISHABLE_KEY || "")
+    const elements = stripe.elements({ clientSecret: payment.client_secret })
+    elements.create("payment").mount("#stripe-payment-element")
+    stripeRef.current = { stripe, elements }
+  }
+
+  const completePayment = async (verify: () => Promise<unknown>) => {
+    try {
+      // Verify payment on backend
+      await verify()
+
+      // Clear session storage
+      sessionStorage.removeItem("pendingPaymentBookingId")
+
+      toast.success("Payment successful! Booking confirmed.")
+      router.push("/bookings")
+    } catch (verifyErr: any) {
+      const errorMessage = verifyErr?.message || "Payment verification failed"
+      toast.error(errorMessage)
+      setProcessing(false)
+    }
+  }
+
+  const handleStripePayment = async (payment: CreatePaymentResponse) => {
+    if (!stripeRef.current) {
+      toast.error("Payment gateway not loaded. Please refresh and try again.")
+      return
+    }
+
+    setProcessing(true)
+
+    const { stripe, elements } = stripeRef.current
+    const { error: stripeError } = await stripe.confirmPayment({
+      elements,
+      redirect: "if_required",
+    })
+    if (stripeError) {
+      toast.error(stripeError.message || "Payment failed")
+      setProcessing(false)
+      return
+    }
+
+    await completePayment(() =>
+      paymentsApi.verifyPayment({ payment_id: payment.payment_id })
+    )
+  }
+
   const handlePayment = async () => {
     if (!paymentData || !booking) {
       toast.error("Payment data not loaded")
       return
     }
 
+    if (paymentData.gateway === "stripe") {
+      await handleStripePayment(paymentData)
+      return
+    }
+
     if (!window.Razorpay) {
       toast.error("Payment gateway not loaded. Please refresh and try again.")
       return
@@ -93,31 +157,19 @@ function PaymentFlowContent() {
     try {
       const options = {
         key: process.env.NEXT_PUBLIC_RAZORPAY_KEY_ID || "",
-        order_id: paymentData.razorpay_order_id,
+        order_id: paymentData.gateway_order_id,
         amount: paymentData.amount,
         currency: paymentData.currency,
         name: "OpenCall",
         description: `Booking: ${booking.service} with ${booking.mentor}`,
-        handler: async (response: any) => {
-          try {
-            // Verify payment on backend
-            await paymentsApi.verifyPayment({
+        handler: (response: any) =>
+          completePayment(() =>
+            paymentsApi.verifyPayment({
               payment_id: paymentData.payment_id,
               razorpay_payment_id: response.razorpay_payment_id,
               razorpay_signature: response.razorpay_signature,
             })
-
-            // Clear session storage
-            sessionStorage.removeItem("pendingPaymentBookingId")
-
-            toast.success("Payment successful! Booking confirmed.")
-            router.push("/bookings")
-          } catch (verifyErr: any) {
-            const errorMessage = verifyErr?.message || "Payment verification failed"
-            toast.error(errorMessage)
-            setProcessing(false)
-          }
-        },
+          ),
         prefill: {
           name: "", // Could be filled with user data if available
           email: "",
@@ -232,11 +284,16 @@ function PaymentFlowContent() {
           <div className="space-y-4">
             <div className="bg-blue-50 border border-blue-200 rounded-lg p-4">
               <p className="text-sm text-blue-800">
-                Click the button below to proceed to secure payment via Razorpay.
+                Click the button below to proceed to secure payment via{" "}
+                {paymentData?.gateway === "stripe" ? "Stripe" : "Razorpay"}.
                 Your booking will be confirmed once payment is successful.
               </p>
             </div>
 
+            {paymentData?.gateway === "stripe" && (
+              <div id="stripe-payment-element" className="p-4 border border-gray-200 rounded-lg" />
+            )}
+
             <div className="flex gap-4">
               <Button
                 onClick={handlePayment}
@@ -21,6 +21,17 @@ import { useAuthStore } from "@/stores/auth.store"
 import { usersApi } from "@/lib/api/users"
 import { toast } from "sonner"
 
+// Any length in 15-minute steps, up to 4 hours
+const durationOptions = Array.from({ length: 16 }, (_, i) => (i + 1) * 15)
+
+function formatDuration(minutes: number) {
+  const hours = Math.floor(minutes / 60)
+  const rest = minutes % 60
+  if (hours === 0) return `${rest} minutes`
+  if (rest === 0) return hours === 1 ? "1 hour" : `${hours} hours`
+  return `${hours} h ${rest} min`
+}
+
 function CreateServiceContent() {
   const router = useRouter()
   const { user } = useAuthStore()
@@ -127,8 +138,8 @@ function CreateServiceContent() {
       errors.title = "Title must be at least 3 characters long"
     }
 
-    if (!duration || duration !== "30") {
-      errors.duration_minutes = "Duration must be 30 minutes"
+    if (!duration || !durationOptions.includes(parseInt(duration, 10))) {
+      errors.duration_minutes = "Duration must be a multiple of 15 minutes, up to 4 hours"
     }
 
     if (!price) {
@@ -155,7 +166,7 @@ function CreateServiceContent() {
       await servicesApi.create({
         title: title.trim(),
         description: description?.trim() || undefined,
-        duration_minutes: 30,
+        duration_minutes: parseInt(duration, 10),
         price_cents: dollarsToCents(parseFloat(price)),
         currency: currency.toUpperCase(),
       })
@@ -260,22 +271,23 @@ function CreateServiceContent() {
                 <FieldLabel htmlFor="duration_minutes" className="text-gray-800">
                   Session Duration <span className="text-red-500">*</span>
                 </FieldLabel>
-                <div className="flex gap-4">
-                  <label className="flex items-center gap-2 cursor-pointer">
-                    <input
-                      type="radio"
-                      name="duration_minutes"
-                      value="30"
-                      defaultChecked
-                      className="w-4 h-4 text-orange-500 border-gray-300 focus:ring-orange-500"
-                      aria-invalid={formErrors.duration_minutes ? "true" : "false"}
-                    />
-                    <span className="text-gray-700">30 minutes</span>
-                  </label>
-                </div>
+                <Select
+                  id="duration_minutes"
+                  name="duration_minutes"
+                  required
+                  defaultValue="30"
+                  className="border-gray-300"
+                  aria-invalid={formErrors.duration_minutes ? "true" : "false"}
+                >
+                  {durationOptions.map((minutes) => (
+                    <option key={minutes} value={minutes}>
+                      {formatDuration(minutes)}
+                    </option>
+                  ))}
+                </Select>
                 <FieldDescription className="text-gray-500">
-                  Choose the duration for this service. Sessions are booked in
-                  30-minute blocks.
+                  Choose the duration for this service, from 15 minutes up to
+                  4 hours.
                 </FieldDescription>
                 {formErrors.duration_minutes && (
                   <FieldError>{formErrors.duration_minutes}</FieldError>
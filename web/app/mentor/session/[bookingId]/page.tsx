@@ -31,6 +31,7 @@ function MentorVideoCallContent() {
   const [error, setError] = useState("")
   const [zegoToken, setZegoToken] = useState("")
   const [zegoAppId, setZegoAppId] = useState<number>(0)
+  const [zegoRoomId, setZegoRoomId] = useState("")
 
   // Initialize call
   useEffect(() => {
@@ -52,15 +53,11 @@ function MentorVideoCallContent() {
         }
 
         // Get Zego token
-        const tokenResponse = await zeroApi.generateToken({
-          userId: user.id,
-          userName: user.username,
-          roomId: bookingId,
-          expirationSeconds: 3600,
-        })
+        const tokenResponse = await zeroApi.generateToken({ bookingId })
 
         setZegoToken(tokenResponse.token)
         setZegoAppId(tokenResponse.appId)
+        setZegoRoomId(tokenResponse.roomId)
         setSession(selectedSession)
         setLoading(false)
       } catch (err) {
@@ -74,7 +71,12 @@ function MentorVideoCallContent() {
     initializeCall()
   }, [bookingId, user])
 
-  const handleEndCall = () => {
+  const handleJoinedCall = () => {
+    zeroApi.recordSessionEvent(bookingId, "joined")
+  }
+
+  const handleEndCall = async () => {
+    await zeroApi.recordSessionEvent(bookingId, "left")
     router.push("/mentor/sessions")
   }
 
@@ -121,7 +123,8 @@ function MentorVideoCallContent() {
               token={zegoToken}
               userId={user.id}
               userName={user.username}
-              roomId={bookingId}
+              roomId={zegoRoomId}
+              onJoinRoom={handleJoinedCall}
               onLeaveRoom={handleEndCall}
             />
           )}
@@ -31,6 +31,7 @@ function VideoCallContent() {
   const [error, setError] = useState("")
   const [zegoToken, setZegoToken] = useState("")
   const [zegoAppId, setZegoAppId] = useState<number>(0)
+  const [zegoRoomId, setZegoRoomId] = useState("")
 
   // Initialize call
   useEffect(() => {
@@ -59,12 +60,7 @@ function VideoCallContent() {
         }
 
         console.log("[SessionPage] Generating Zego token...")
-        const tokenResponse = await zeroApi.generateToken({
-          userId: user.id,
-          userName: user.username,
-          roomId: bookingId,
-          expirationSeconds: 3600,
-        })
+        const tokenResponse = await zeroApi.generateToken({ bookingId })
 
         console.log("[SessionPage] Full token response:", tokenResponse)
         console.log("[SessionPage] Token received, appId:", tokenResponse.appId, "token length:", tokenResponse.token?.length)
@@ -76,6 +72,7 @@ function VideoCallContent() {
         
         setZegoToken(tokenResponse.token)
         setZegoAppId(tokenResponse.appId)
+        setZegoRoomId(tokenResponse.roomId)
         setBooking(selectedBooking)
         setLoading(false)
         console.log("[SessionPage] Ready to join call with appId:", tokenResponse.appId)
@@ -91,7 +88,12 @@ function VideoCallContent() {
     initializeCall()
   }, [bookingId, user])
 
-  const handleEndCall = () => {
+  const handleJoinedCall = () => {
+    zeroApi.recordSessionEvent(bookingId, "joined")
+  }
+
+  const handleEndCall = async () => {
+    await zeroApi.recordSessionEvent(bookingId, "left")
     router.push("/bookings")
   }
 
@@ -138,7 +140,8 @@ function VideoCallContent() {
               token={zegoToken}
               userId={user.id}
               userName={user.username}
-              roomId={bookingId}
+              roomId={zegoRoomId}
+              onJoinRoom={handleJoinedCall}
               onLeaveRoom={handleEndCall}
             />
           )}
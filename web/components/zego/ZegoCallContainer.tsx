@@ -9,6 +9,7 @@ interface ZegoCallContainerProps {
   userName?: string
   roomId: string
   onLeaveRoom: () => void
+  onJoinRoom?: () => void
 }
 
 export default function ZegoCallContainer({
@@ -18,8 +19,12 @@ export default function ZegoCallContainer({
   userName,
   roomId,
   onLeaveRoom,
+  onJoinRoom,
 }: ZegoCallContainerProps) {
   const containerRef = useRef<HTMLDivElement>(null)
+  // Kept in a ref so a new callback does not re-create the call
+  const onJoinRoomRef = useRef(onJoinRoom)
+  onJoinRoomRef.current = onJoinRoom
   const componentRef = useRef<any>(null)
   const initializedRef = useRef<boolean>(false)
 
@@ -100,6 +105,7 @@ export default function ZegoCallContainer({
           showMyMicrophoneToggleButton: true,
           showAudioVideoSettingsButton: true,
           showScreenSharingButton: true,
+          onJoinRoom: () => onJoinRoomRef.current?.(),
           onLeaveRoom: onLeaveRoom,
         })
 
@@ -10,6 +10,7 @@ export interface CreateBookingRequest {
   service_id: string // UUID, required
   booking_date: string // format "YYYY-MM-DD", required
   start_time: string // format "HH:MM", required
+  coupon_code?: string // optional discount code
 }
 
 /**
@@ -21,8 +22,22 @@ export interface BookingResponse {
   date: string // format "YYYY-MM-DD"
   start_time: string // format "HH:MM"
   end_time: string // format "HH:MM"
+  status: string
   price_cents: number
+  list_price_cents?: number // set when a coupon was applied
+  discount_cents?: number // set when a coupon was applied
   currency: string
+  attendance: SessionAttendance
+}
+
+/**
+ * Matches backend SessionAttendance; null when no event was recorded
+ */
+export interface SessionAttendance {
+  mentee_joined_at: string | null
+  mentee_left_at: string | null
+  mentor_joined_at: string | null
+  mentor_left_at: string | null
 }
 
 /**
@@ -35,9 +50,10 @@ export interface MyBookingResponse {
   date: string // format "YYYY-MM-DD"
   start_time: string // format "HH:MM"
   end_time: string // format "HH:MM"
-  status: string // "pending" | "confirmed" | "cancelled" | "completed"
+  status: string // "pending" | "confirmed" | "cancelled" | "completed" | "no_show_mentor" | "no_show_user"
   price_cents: number
   currency: string
+  attendance: SessionAttendance
 }
 
 /**
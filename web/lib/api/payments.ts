@@ -7,6 +7,7 @@ import { apiClient } from "./client"
  */
 export interface CreatePaymentRequest {
   booking_id: string // UUID, required
+  billing_state?: string // GST state code for the invoice, optional
 }
 
 /**
@@ -14,18 +15,27 @@ export interface CreatePaymentRequest {
  */
 export interface CreatePaymentResponse {
   payment_id: string // UUID
-  razorpay_order_id: string
-  amount: number // in paise
-  currency: string // "INR"
+  gateway: PaymentGateway
+  gateway_order_id: string
+  razorpay_order_id: string // same as gateway_order_id
+  client_secret?: string // Stripe only
+  amount: number // in the currency's smallest unit
+  currency: string
 }
 
 /**
- * Matches backend VerifyPaymentRequest
+ * Gateway chosen by the backend for the booking's currency
+ */
+export type PaymentGateway = "razorpay" | "stripe"
+
+/**
+ * Matches backend VerifyPaymentRequest.
+ * The Razorpay fields are only needed for Razorpay payments.
  */
 export interface VerifyPaymentRequest {
   payment_id: string // UUID
-  razorpay_payment_id: string
-  razorpay_signature: string
+  razorpay_payment_id?: string
+  razorpay_signature?: string
 }
 
 /**
@@ -54,6 +64,10 @@ export enum PaymentStatus {
   Created = "created",
   Paid = "paid",
   Failed = "failed",
+  Superseded = "superseded",
+  AmountMismatch = "amount_mismatch",
+  RefundPending = "refund_pending",
+  Refunded = "refunded",
 }
 
 /**
@@ -65,7 +79,7 @@ export const paymentsApi = {
    * Create a payment order (protected)
    * POST /api/payments
    * 
-   * Initiates a Razorpay order for a booking
+   * Initiates a gateway order (Razorpay or Stripe) for a booking
    */
   createPayment(payload: CreatePaymentRequest) {
     return apiClient<CreatePaymentResponse, CreatePaymentRequest>(
@@ -9,8 +9,9 @@ export interface CreateMentorServiceRequest {
   title: string // required, min 3 chars
   description?: string
   duration_minutes: 30 // required (30 minutes only)
-  price_cents: number // required, min 0
+  price_cents: number // required, min 0; 0 makes the service free
   currency: string // required, exactly 3 characters (e.g., "USD")
+  free_bookings_per_mentee?: number // free services only, 0 for no cap
 }
 
 /**
@@ -24,6 +25,7 @@ export interface MentorServiceResponse {
   price_cents: number
   currency: string
   is_active: boolean
+  free_bookings_per_mentee: number
 }
 
 export const servicesApi = {
@@ -1,10 +1,8 @@
 import { apiClient } from "./client"
 
+// The server derives the user, room and expiry from the booking
 export interface GenerateTokenRequest {
-  userId: string
-  userName: string
-  roomId: string
-  expirationSeconds?: number
+  bookingId: string
 }
 
 // This matches what the backend sends (snake_case)
@@ -23,6 +21,8 @@ export interface GenerateTokenResponse {
   userId: string
 }
 
+export type SessionEvent = "joined" | "left"
+
 export interface SessionInfoResponse {
   appId: number
   roomId: string
@@ -32,17 +32,14 @@ export interface SessionInfoResponse {
 
 export const zeroApi = {
   async generateToken(request: GenerateTokenRequest): Promise<GenerateTokenResponse> {
-    console.log("[ZegoAPI] Calling generateToken for room:", request.roomId)
+    console.log("[ZegoAPI] Calling generateToken for booking:", request.bookingId)
     try {
       const response = await apiClient<any>(
         "/api/zego/token",
         {
           method: "POST",
           body: {
-            user_id: request.userId,
-            user_name: request.userName,
-            room_id: request.roomId,
-            expiration_seconds: request.expirationSeconds || 3600,
+            booking_id: request.bookingId,
           },
         }
       )
@@ -65,6 +62,18 @@ export const zeroApi = {
     }
   },
 
+  // Attendance reporting must never break the call, so failures are only logged
+  async recordSessionEvent(bookingId: string, event: SessionEvent): Promise<void> {
+    try {
+      await apiClient<void>(`/api/zego/session/${bookingId}/events`, {
+        method: "POST",
+        body: { event },
+      })
+    } catch (error) {
+      console.warn("[ZegoAPI] recordSessionEvent failed:", event, error)
+    }
+  },
+
   async getSessionInfo(bookingId: string): Promise<SessionInfoResponse> {
     console.log("[ZegoAPI] Calling getSessionInfo for booking:", bookingId)
     try {